@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/netip"
+	"regexp"
+)
+
+// IP 주소 후보 (IPv4, IPv6 문자만으로 된 토큰). 실제 IP인지는 netip.ParseAddr로 확인합니다.
+var ipCandidateRegex = regexp.MustCompile(`[0-9A-Fa-f:.]*[:.][0-9A-Fa-f:.]*`)
+
+// anonymizeIPs는 문자열 안의 IP 주소를 웹 분석 도구와 같은 방식으로 익명화합니다.
+// IPv4는 마지막 옥텟(/24), IPv6는 하위 80비트(/48)를 0으로 바꾸며, IP가 아닌 부분은 그대로 둡니다.
+func anonymizeIPs(s string) string {
+	return ipCandidateRegex.ReplaceAllStringFunc(s, func(token string) string {
+		addr, err := netip.ParseAddr(token)
+		if err != nil {
+			return token
+		}
+		bits := 48
+		if addr.Is4() || addr.Is4In6() {
+			bits = 24
+			if addr.Is4In6() {
+				bits = 96 + 24
+			}
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return token
+		}
+		return prefix.Addr().String()
+	})
+}
+
+// extractIPs는 문자열 안의 IP 주소를 찾아 정규화된 표기(대괄호 제거, IPv4-mapped IPv6는 IPv4로)로 중복 없이 반환합니다.
+func extractIPs(s string) []string {
+	var ips []string
+	for _, token := range ipCandidateRegex.FindAllString(s, -1) {
+		if addr, err := netip.ParseAddr(token); err == nil {
+			ips = appendUnique(ips, addr.Unmap().String())
+		}
+	}
+	return ips
+}
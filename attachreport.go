@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// attachmentStat은 첨부 보고서의 한 행으로, 같은 내용의 첨부가 나온 메일 목록입니다.
+type attachmentStat struct {
+	SHA256   string   `json:"sha256"`
+	Count    int      `json:"count"`
+	Messages []string `json:"messages"`
+}
+
+// attachmentReport는 레코드의 첨부 해시를 모아 캠페인에 공통으로 쓰인 첨부를 찾습니다.
+// 레코드는 출력과 같은 순서로 한 고루틴에서만 추가됩니다.
+type attachmentReport struct {
+	stats map[string]*attachmentStat
+}
+
+func newAttachmentReport() *attachmentReport {
+	return &attachmentReport{stats: make(map[string]*attachmentStat)}
+}
+
+// add는 레코드의 첨부 해시를 집계합니다. 한 메일에 같은 첨부가 여러 번 있어도 한 번만 셉니다.
+func (a *attachmentReport) add(r EmailRecord) {
+	if r.AttachmentHashes == "" {
+		return
+	}
+	msg := filepath.ToSlash(filepath.Join(r.Folder, r.OriginalFile))
+	for _, h := range appendUnique(nil, strings.Split(r.AttachmentHashes, "\n")...) {
+		st, ok := a.stats[h]
+		if !ok {
+			st = &attachmentStat{SHA256: h}
+			a.stats[h] = st
+		}
+		st.Count++
+		st.Messages = append(st.Messages, msg)
+	}
+}
+
+// sorted는 나온 메일 수가 많은 순(같으면 해시 순)으로 정렬한 목록을 반환합니다.
+func (a *attachmentReport) sorted() []attachmentStat {
+	stats := make([]attachmentStat, 0, len(a.stats))
+	for _, st := range a.stats {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].SHA256 < stats[j].SHA256
+	})
+	return stats
+}
+
+// write는 보고서를 path에 저장합니다. 확장자가 .json이면 JSON 배열로, 그 외에는 CSV로 저장합니다.
+func (a *attachmentReport) write(path string) error {
+	stats := a.sorted()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"SHA-256", "메일 수", "메일"})
+	for _, st := range stats {
+		w.Write([]string{st.SHA256, strconv.Itoa(st.Count), strings.Join(st.Messages, "\n")})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// attachmentReportWriter는 레코드를 out에 그대로 넘기면서 첨부 해시를 집계합니다.
+type attachmentReportWriter struct {
+	out    recordWriter
+	report *attachmentReport
+}
+
+func (w *attachmentReportWriter) WriteRecord(r EmailRecord) error {
+	w.report.add(r)
+	return w.out.WriteRecord(r)
+}
+
+func (w *attachmentReportWriter) Close() error { return w.out.Close() }
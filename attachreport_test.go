@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 첨부 내용("abc")을 디코딩한 바이트의 SHA-256을 AttachmentHashes에 기록해야 함
+func TestAttachmentHashesFromMessage(t *testing.T) {
+	path := writeEml(t, "From: a@example.com\r\n"+
+		"Subject: invoice\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n"+
+		"--b\r\nContent-Type: text/plain\r\n\r\nsee attached\r\n"+
+		"--b\r\nContent-Type: application/octet-stream\r\nContent-Disposition: attachment; filename=a.bin\r\n"+
+		"Content-Transfer-Encoding: base64\r\n\r\nYWJj\r\n"+
+		"--b\r\nContent-Type: text/plain\r\nContent-Disposition: attachment; filename=b.txt\r\n\r\nabc\r\n"+
+		"--b--\r\n")
+	rec, _, err := processEmlFile(path, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := abcSHA256 + "\n" + abcSHA256; rec.AttachmentHashes != want {
+		t.Errorf("AttachmentHashes = %q, want %q", rec.AttachmentHashes, want)
+	}
+}
+
+// 한 메일 안의 같은 첨부는 한 번만 세고, 메일 수가 많은 순(같으면 해시 순)으로 정렬
+func TestAttachmentReport(t *testing.T) {
+	var out recordingWriter
+	a := newAttachmentReport()
+	w := &attachmentReportWriter{out: &out, report: a}
+	records := []EmailRecord{
+		{Folder: "inbox", OriginalFile: "1.eml", AttachmentHashes: "bb\nbb\naa"},
+		{Folder: "inbox", OriginalFile: "2.eml"},
+		{Folder: "sent", OriginalFile: "3.eml", AttachmentHashes: "bb"},
+		{OriginalFile: "4.eml", AttachmentHashes: "cc"},
+	}
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.written) != len(records) {
+		t.Errorf("넘긴 레코드 %d개, want %d개", len(out.written), len(records))
+	}
+
+	want := []attachmentStat{
+		{SHA256: "bb", Count: 2, Messages: []string{"inbox/1.eml", "sent/3.eml"}},
+		{SHA256: "aa", Count: 1, Messages: []string{"inbox/1.eml"}},
+		{SHA256: "cc", Count: 1, Messages: []string{"4.eml"}},
+	}
+	got := a.sorted()
+	if len(got) != len(want) {
+		t.Fatalf("보고서 = %+v", got)
+	}
+	for i := range want {
+		if got[i].SHA256 != want[i].SHA256 || got[i].Count != want[i].Count || !equalStrings(got[i].Messages, want[i].Messages) {
+			t.Errorf("%d번째 = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "attachments.csv")
+	if err := a.write(csvPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{
+		{"SHA-256", "메일 수", "메일"},
+		{"bb", "2", "inbox/1.eml\nsent/3.eml"},
+		{"aa", "1", "inbox/1.eml"},
+		{"cc", "1", "4.eml"},
+	}
+	if len(rows) != len(wantRows) {
+		t.Fatalf("CSV 행 = %q", rows)
+	}
+	for i := range wantRows {
+		if !equalStrings(rows[i], wantRows[i]) {
+			t.Errorf("CSV %d번째 행 = %q, want %q", i+1, rows[i], wantRows[i])
+		}
+	}
+
+	jsonPath := filepath.Join(dir, "attachments.Json")
+	if err := a.write(jsonPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats []attachmentStat
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 || stats[0].SHA256 != "bb" || !strings.Contains(string(data), `"messages"`) {
+		t.Errorf("JSON 보고서 = %s", data)
+	}
+}
+
+// 첨부가 없으면 JSON은 빈 배열, CSV는 헤더만
+func TestAttachmentReportEmpty(t *testing.T) {
+	a := newAttachmentReport()
+	a.add(EmailRecord{OriginalFile: "1.eml"})
+	dir := t.TempDir()
+	for name, want := range map[string]string{
+		"empty.json": "[]\n",
+		"empty.csv":  "SHA-256,메일 수,메일\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := a.write(path); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
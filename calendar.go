@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+)
+
+// urlSourceCalendar는 일정(ICS) 본문에서 추출한 URL의 출처입니다.
+const urlSourceCalendar = "calendar"
+
+// maxCalendarBytes는 일정 파트에서 읽어 두는 최대 크기입니다.
+const maxCalendarBytes = 1 << 20
+
+// isCalendarPart는 일정 초대(ICS) 파트인지 확인합니다. 첨부로 온 .ics 파일도 포함합니다.
+func isCalendarPart(p *mimePart) bool {
+	switch p.mediaType {
+	case "text/calendar", "application/ics", "text/x-vcalendar":
+		return true
+	}
+	return strings.HasSuffix(strings.ToLower(p.filename), ".ics")
+}
+
+// findCalendar는 트리에서 첫 번째 일정 파트를 찾습니다.
+func findCalendar(p *mimePart) *mimePart {
+	if p == nil {
+		return nil
+	}
+	if !p.isMultipart() {
+		if isCalendarPart(p) {
+			return p
+		}
+		return nil
+	}
+	for _, c := range p.children {
+		if found := findCalendar(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// calendarInfo는 ICS 본문에서 뽑은 필드입니다.
+type calendarInfo struct {
+	organizer string
+	summary   string
+	urls      []string
+}
+
+// parseCalendar는 ICS 본문을 줄 단위로 읽어 첫 ORGANIZER/SUMMARY와 본문 속 URL을 추출합니다.
+// 접힌 줄(RFC 5545 §3.1)을 잇고 TEXT 값의 이스케이프(\n, \, 등)를 풀며, 그 외 구조는 해석하지 않습니다.
+func parseCalendar(ics string) calendarInfo {
+	var info calendarInfo
+	ics = strings.ReplaceAll(ics, "\r\n", "\n")
+	ics = strings.NewReplacer("\n ", "", "\n\t", "").Replace(ics)
+
+	var text strings.Builder
+	for _, line := range strings.Split(ics, "\n") {
+		nameParams, value, ok := splitICSLine(line)
+		if !ok {
+			continue
+		}
+		name, params, _ := strings.Cut(nameParams, ";")
+		value = unescapeICSText(value)
+		switch strings.ToUpper(name) {
+		case "ORGANIZER":
+			if info.organizer == "" {
+				info.organizer = icsOrganizer(params, value)
+			}
+		case "SUMMARY":
+			if info.summary == "" {
+				info.summary = value
+			}
+		}
+		text.WriteString(value)
+		text.WriteByte('\n')
+	}
+	info.urls = extractUrlsRegex(text.String())
+	return info
+}
+
+// splitICSLine은 "NAME;PARAM=...:VALUE" 줄을 나눕니다. 따옴표로 감싼 파라미터 안의 콜론은 구분자가 아닙니다.
+func splitICSLine(line string) (nameParams, value string, ok bool) {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			return line[:i], line[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// unescapeICSText는 TEXT 값의 이스케이프를 풉니다.
+func unescapeICSText(v string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(v)
+}
+
+// icsOrganizer는 ORGANIZER 값을 "이름 <주소>" 형식으로 만듭니다 (CN 파라미터가 없으면 주소만).
+func icsOrganizer(params, value string) string {
+	addr := value
+	if len(addr) >= 7 && strings.EqualFold(addr[:7], "mailto:") {
+		addr = addr[7:]
+	}
+	for _, p := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "CN") {
+			if cn := strings.Trim(v, `"`); cn != "" {
+				return cn + " <" + addr + ">"
+			}
+		}
+	}
+	return addr
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-message"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+func init() {
+	// go-message의 CharsetReader를 설정하여 다양한 문자셋 지원.
+	// 알 수 없는 문자셋도 오류 대신 Latin-1로 통과시켜 본문을 잃지 않도록 합니다.
+	message.CharsetReader = func(cs string, input io.Reader) (io.Reader, error) {
+		enc, ok := lookupCharset(cs)
+		if !ok {
+			enc = charmap.ISO8859_1
+		}
+		return transform.NewReader(input, enc.NewDecoder()), nil
+	}
+}
+
+// charsetOverrides는 WHATWG 표준(htmlindex)과 다르게 디코딩할 문자셋입니다.
+// WHATWG는 iso-8859-1/ascii를 windows-1252로, gb2312를 GBK로 취급하지만 메일에서는 원래 의미를 유지합니다.
+var charsetOverrides = map[string]encoding.Encoding{
+	"iso-8859-1": charmap.ISO8859_1,
+	"ascii":      encoding.Nop,
+	"us-ascii":   encoding.Nop,
+	"gb2312":     simplifiedchinese.GB18030,
+}
+
+// charsetAliases는 메일 클라이언트가 쓰지만 htmlindex에 없는 문자셋 이름입니다.
+var charsetAliases = map[string]string{
+	"cp949":         "euc-kr",
+	"ms949":         "euc-kr",
+	"uhc":           "euc-kr",
+	"euckr":         "euc-kr",
+	"x-windows-949": "euc-kr",
+	"cp932":         "shift_jis",
+	"x-ms-cp932":    "shift_jis",
+	"eucjp":         "euc-jp",
+	"cp936":         "gbk",
+	"ms936":         "gbk",
+	"koi8r":         "koi8-r",
+	"utf8":          "utf-8",
+}
+
+// lookupCharset은 MIME charset 이름에 해당하는 인코딩을 찾습니다.
+// 대소문자, 따옴표, "_"/"-" 차이와 "x-" 접두어를 허용합니다.
+func lookupCharset(label string) (encoding.Encoding, bool) {
+	l := strings.ToLower(strings.Trim(strings.TrimSpace(label), `"'`))
+	if l == "" {
+		return nil, false
+	}
+	if alias, ok := charsetAliases[l]; ok {
+		l = alias
+	}
+	if enc, ok := charsetOverrides[l]; ok {
+		return enc, true
+	}
+	for _, c := range []string{l, strings.ReplaceAll(l, "_", "-"), strings.TrimPrefix(l, "x-")} {
+		if enc, err := htmlindex.Get(c); err == nil {
+			return enc, true
+		}
+	}
+	return nil, false
+}
+
+// encodedWordCharsetRegex는 RFC 2047 encoded-word의 charset 부분입니다 (RFC 2231 언어 태그 제외).
+var encodedWordCharsetRegex = regexp.MustCompile(`=\?([^?*\s]+)(?:\*[^?\s]*)?\?[bBqQ]\?`)
+
+// headerCharsetFallback은 헤더 값의 encoded-word 중 알 수 없는 문자셋이 있는지 확인합니다.
+func headerCharsetFallback(values ...string) bool {
+	for _, v := range values {
+		for _, m := range encodedWordCharsetRegex.FindAllStringSubmatch(v, -1) {
+			if _, ok := lookupCharset(m[1]); !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headerCharsets는 헤더 값의 encoded-word가 선언한 문자셋 이름(소문자)을 처음 나온 순서대로 중복 없이 줄바꿈으로 연결합니다.
+// encoded-word가 없으면(ASCII/8비트 원문) 빈 문자열입니다.
+func headerCharsets(value string) string {
+	var charsets []string
+	for _, m := range encodedWordCharsetRegex.FindAllStringSubmatch(value, -1) {
+		charsets = appendUnique(charsets, strings.ToLower(m[1]))
+	}
+	return strings.Join(charsets, "\n")
+}
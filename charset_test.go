@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupCharset(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string // 인코딩으로 디코딩한 결과, 빈 문자열이면 찾지 못해야 함
+		input string
+	}{
+		{"cp949", "똠", "\x8c\x63"},
+		{"CP949", "똠", "\x8c\x63"},
+		{"ks_c_5601-1987", "한", "\xc7\xd1"},
+		{"\"euc-kr\"", "한", "\xc7\xd1"},
+		{"shift_jis", "注", "\x92\x8d"},
+		{"Shift-JIS", "注", "\x92\x8d"},
+		{"cp932", "注", "\x92\x8d"},
+		{"x-sjis", "注", "\x92\x8d"},
+		{"euc-jp", "注", "\xc3\xed"},
+		{"koi8-r", "Ж", "\xf6"},
+		{"windows-1251", "Ж", "\xc6"},
+		{"windows-1253", "Ω", "\xd9"},
+		{"windows-1255", "א", "\xe0"},
+		{"gbk", "中", "\xd6\xd0"},
+		{"gb2312", "中", "\xd6\xd0"},
+		{"iso-8859-1", "é", "\xe9"},
+		{"us-ascii", "a", "a"},
+		{"x-made-up", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		enc, ok := lookupCharset(tt.label)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("lookupCharset(%q)이 인코딩을 찾음, want 없음", tt.label)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("lookupCharset(%q) 실패", tt.label)
+			continue
+		}
+		got, err := enc.NewDecoder().String(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("lookupCharset(%q)로 디코딩 = %q, %v, want %q", tt.label, got, err, tt.want)
+		}
+	}
+}
+
+// cp949/shift_jis 제목과 본문은 원래 문자로 디코딩되고, 알 수 없는 문자셋은 본문을 버리지 않고 Latin-1로 읽어야 함
+func TestCharsetFixtures(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		subject, from   string
+		preview, urls   string
+		charsetFallback bool
+	}{
+		{
+			fixture: "cp949.eml",
+			subject: "똠방각하 주문 확인", from: "쇼핑몰",
+			preview: "안녕하세요, 똠방각하 주문이 접수되었습니다. 배송 조회: https://shop.example.kr/order?id=7",
+			urls:    "https://shop.example.kr/order?id=7",
+		},
+		{
+			fixture: "shift_jis.eml",
+			subject: "ご注文ありがとうございます", from: "ショップ",
+			preview: "ご注文ありがとうございます。 注文を確認",
+			urls:    "https://shop.example.jp/注文/123",
+		},
+		{
+			fixture: "unknown-charset.eml",
+			subject: "Unknown charset",
+			preview: "Café menu: https://cafe.example/menu",
+			urls:    "https://cafe.example/menu", charsetFallback: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.Subject != tt.subject || rec.FromName != tt.from {
+				t.Errorf("Subject, FromName = %q, %q, want %q, %q", rec.Subject, rec.FromName, tt.subject, tt.from)
+			}
+			if rec.BodyPreview != tt.preview || rec.URLs != tt.urls {
+				t.Errorf("BodyPreview, URLs = %q, %q, want %q, %q", rec.BodyPreview, rec.URLs, tt.preview, tt.urls)
+			}
+			if rec.CharsetFallback != tt.charsetFallback {
+				t.Errorf("CharsetFallback = %v, want %v", rec.CharsetFallback, tt.charsetFallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCollectWalkers는 경로 수집 시 동시에 탐색할 디렉토리 수의 상한입니다.
+// NFS 등 느린 파일시스템에서 지연을 숨기되 서버에 과도한 부하를 주지 않도록 작게 유지합니다.
+const maxCollectWalkers = 8
+
+// collectedFile은 처리 대상 파일과 그 파일이 속한 입력 루트를 담습니다.
+// 루트는 HTML 변환/복사 시 상대 경로를 계산하는 기준이 됩니다.
+// size는 수집 시 읽은 파일 크기로, 처리 단계에서 다시 stat하지 않고 MessageSize에 사용합니다.
+type collectedFile struct {
+	root string
+	path string
+	size int64
+}
+
+// collectStats는 경로 수집 결과 통계입니다.
+type collectStats struct {
+	skipped  int
+	failures []fileFailure
+}
+
+// collectJob은 하나의 탐색 단위(루트의 최상위 파일 목록 또는 1단계 하위 디렉토리)입니다.
+type collectJob struct {
+	root string
+	dir  string
+	walk bool
+}
+
+type collectOutput struct {
+	files    []collectedFile
+	skipped  int
+	failures []fileFailure
+}
+
+// collectFiles는 여러 입력 루트에서 .eml 파일을 수집합니다.
+// recursive가 설정되면 각 루트의 1단계 하위 디렉토리를 작은 고루틴 풀로 동시에 탐색합니다.
+// 한 하위 트리의 오류는 경고로 기록하고 나머지 탐색은 계속하며,
+// 결과는 경로 기준으로 정렬하여 실행마다 같은 순서를 보장합니다.
+// maildir이 설정되면 루트를 maildir로 보고 collectMaildir로 수집합니다.
+func collectFiles(roots []string, recursive, maildir bool, parallelism int) ([]collectedFile, collectStats) {
+	var jobs []collectJob
+	var out collectOutput
+
+	for _, root := range roots {
+		if maildir {
+			o := collectMaildir(root, recursive)
+			out.files = append(out.files, o.files...)
+			out.skipped += o.skipped
+			out.failures = append(out.failures, o.failures...)
+			continue
+		}
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			out.addFailure(root, err)
+			continue
+		}
+		// 루트 바로 아래 파일은 여기서 처리하고, 하위 디렉토리는 탐색 작업으로 분배
+		for _, entry := range entries {
+			path := filepath.Join(root, entry.Name())
+			if entry.IsDir() {
+				if recursive {
+					jobs = append(jobs, collectJob{root: root, dir: path, walk: true})
+				}
+				continue
+			}
+			out.addEntry(root, path, entry)
+		}
+	}
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > maxCollectWalkers {
+		parallelism = maxCollectWalkers
+	}
+
+	jobCh := make(chan collectJob)
+	outCh := make(chan collectOutput)
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				outCh <- walkCollectJob(job)
+			}
+		}()
+	}
+	go func() {
+		for _, job := range jobs {
+			jobCh <- job
+		}
+		close(jobCh)
+	}()
+	go func() {
+		wg.Wait()
+		close(outCh)
+	}()
+
+	for o := range outCh {
+		out.files = append(out.files, o.files...)
+		out.skipped += o.skipped
+		out.failures = append(out.failures, o.failures...)
+	}
+
+	sort.SliceStable(out.files, func(i, j int) bool { return out.files[i].path < out.files[j].path })
+	// 겹치는 루트(예: dir 과 dir/sub)를 함께 지정해도 같은 파일은 한 번만 처리
+	files := out.files[:0]
+	for i, f := range out.files {
+		if i > 0 && f.path == out.files[i-1].path {
+			continue
+		}
+		files = append(files, f)
+	}
+	out.files = files
+	sort.Slice(out.failures, func(i, j int) bool { return out.failures[i].File < out.failures[j].File })
+	return out.files, collectStats{skipped: out.skipped, failures: out.failures}
+}
+
+// walkCollectJob은 하나의 하위 디렉토리를 재귀적으로 탐색합니다.
+// 읽을 수 없는 디렉토리는 실패로 기록하고 건너뜁니다.
+func walkCollectJob(job collectJob) collectOutput {
+	var out collectOutput
+	filepath.WalkDir(job.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			out.addFailure(path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			out.addEntry(job.root, path, d)
+		}
+		return nil
+	})
+	return out
+}
+
+// maildir 하위 디렉토리 이름 (tmp는 전달 중인 메일이라 읽지 않음)
+const (
+	maildirCur = "cur"
+	maildirNew = "new"
+	maildirTmp = "tmp"
+)
+
+// collectMaildir는 maildir의 cur/, new/ 아래 파일을 확장자와 관계없이 수집합니다.
+// tmp/와 "."으로 시작하는 파일은 제외하며, recursive가 설정되면 하위 디렉토리의
+// maildir(Maildir++의 ".Sent" 등)도 함께 수집합니다.
+func collectMaildir(root string, recursive bool) collectOutput {
+	var out collectOutput
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			out.addFailure(path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			switch d.Name() {
+			case maildirCur, maildirNew:
+				return nil
+			case maildirTmp:
+				return filepath.SkipDir
+			}
+			if !recursive || filepath.Base(filepath.Dir(path)) == maildirCur || filepath.Base(filepath.Dir(path)) == maildirNew {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Base(filepath.Dir(path)) {
+		case maildirCur, maildirNew:
+			if !strings.HasPrefix(d.Name(), ".") {
+				out.addFile(root, path, d)
+				return nil
+			}
+		}
+		out.skipped++
+		return nil
+	})
+	return out
+}
+
+// maildirName은 maildir의 cur/ 또는 new/ 아래 파일이 속한 maildir의 이름을 반환합니다.
+// Maildir++ 하위 폴더의 앞 "."은 제거합니다 (".Sent" → "Sent").
+func maildirName(filePath string) string {
+	dir := filepath.Dir(filepath.Dir(filePath))
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if name := strings.TrimPrefix(filepath.Base(dir), "."); name != "" {
+		return name
+	}
+	return filepath.Base(dir)
+}
+
+func (o *collectOutput) addEntry(root, path string, entry os.DirEntry) {
+	if !shouldProcessFile(entry.Name()) {
+		o.skipped++
+		return
+	}
+	o.addFile(root, path, entry)
+}
+
+func (o *collectOutput) addFile(root, path string, entry os.DirEntry) {
+	// 심볼릭 링크는 링크 자체가 아닌 대상 파일의 크기를 사용
+	var info os.FileInfo
+	var err error
+	if entry.Type()&os.ModeSymlink != 0 {
+		info, err = os.Stat(path)
+	} else {
+		info, err = entry.Info()
+	}
+	var size int64
+	if err == nil {
+		size = info.Size()
+	}
+	o.files = append(o.files, collectedFile{root: root, path: path, size: size})
+}
+
+func (o *collectOutput) addFailure(path string, err error) {
+	f := newFileFailure(path, stageCollect, err)
+	warnf("경로 수집 실패: %s (%s)", f.File, f.Error)
+	o.failures = append(o.failures, f)
+}
+
+func shouldProcessFile(name string) bool {
+	matched, _ := filepath.Match("*.eml", name)
+	return matched
+}
+
+// filterSince는 수정 시각이 since보다 이전인 파일을 제외하고, 제외한 수를 반환합니다.
+// 상태를 확인할 수 없는 파일은 남겨 두어 처리 단계에서 오류로 기록되게 합니다.
+func filterSince(files []collectedFile, since time.Time) ([]collectedFile, int) {
+	kept := files[:0]
+	tooOld := 0
+	for _, f := range files {
+		if info, err := os.Stat(f.path); err == nil && info.ModTime().Before(since) {
+			tooOld++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, tooOld
+}
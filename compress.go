@@ -0,0 +1,40 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// gzipOutput은 출력 경로가 .gz로 끝나 gzip으로 압축하여 저장해야 하는지 확인합니다.
+func gzipOutput(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".gz")
+}
+
+// gzipRecordWriter는 출력을 gzip으로 압축하고, 닫을 때 남은 압축 데이터와 gzip 트레일러를 기록합니다.
+type gzipRecordWriter struct {
+	recordWriter
+	zw *gzip.Writer
+}
+
+// Flush는 내부 writer의 버퍼만 압축기로 내보냅니다. 압축기까지 비우면 압축률이 떨어지므로
+// 크기 기준 분할(-rotate-size)은 압축기가 내보낸 만큼만 세며, 파일은 압축기 버퍼만큼 기준보다 커질 수 있습니다.
+func (g gzipRecordWriter) Flush() error {
+	if f, ok := g.recordWriter.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (g gzipRecordWriter) Close() error {
+	if err := g.recordWriter.Close(); err != nil {
+		return err
+	}
+	return g.zw.Close()
+}
+
+// newGzipRecordWriter는 w에 gzip으로 압축하여 쓰는 writer를 만들어 newOut에 넘깁니다.
+func newGzipRecordWriter(w io.Writer, newOut func(io.Writer) recordWriter) recordWriter {
+	zw := gzip.NewWriter(w)
+	return gzipRecordWriter{recordWriter: newOut(zw), zw: zw}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func gunzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGzipOutput(t *testing.T) {
+	for path, want := range map[string]bool{
+		"out.csv.gz": true, "OUT.JSON.GZ": true, "out.gz": true,
+		"out.csv": false, "out.gzip": false, "": false, "gz": false,
+	} {
+		if got := gzipOutput(path); got != want {
+			t.Errorf("gzipOutput(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// 압축을 풀면 압축하지 않은 출력과 같아야 함
+func TestGzipRecordWriter(t *testing.T) {
+	records := []EmailRecord{{Subject: "첫 메일", URLs: "https://a.example/"}, {Subject: "둘째", FromEmail: "b@example.com"}}
+	for _, format := range []string{formatCSV, formatJSON, formatNDJSON} {
+		newOut := func(w io.Writer) recordWriter { return newRecordWriter(w, format) }
+		var plain, zipped bytes.Buffer
+		for _, w := range []recordWriter{newOut(&plain), newGzipRecordWriter(&zipped, newOut)} {
+			for _, r := range records {
+				if err := w.WriteRecord(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := gunzipBytes(t, zipped.Bytes()); !bytes.Equal(got, plain.Bytes()) {
+			t.Errorf("%s: 압축 해제 결과가 다름:\n%s\nwant:\n%s", format, got, plain.Bytes())
+		}
+	}
+
+	// 레코드가 없어도 올바른 gzip 파일
+	var buf bytes.Buffer
+	w := newGzipRecordWriter(&buf, func(w io.Writer) recordWriter { return newRecordWriter(w, formatJSON) })
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var empty []EmailRecord
+	if err := json.Unmarshal(gunzipBytes(t, buf.Bytes()), &empty); err != nil || len(empty) != 0 {
+		t.Errorf("빈 JSON: %v, %v", empty, err)
+	}
+}
+
+// 분할 출력에서도 파일마다 완결된 gzip 파일이며 CSV 헤더가 붙어야 함
+func TestGzipRotatedFiles(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "out.csv.gz")
+	newOut := func(w io.Writer) recordWriter {
+		return newGzipRecordWriter(w, func(w io.Writer) recordWriter { return newCSVRecordWriter(w) })
+	}
+	w := newRotatingRecordWriter(base, 2, 0, newOut)
+	for i := 0; i < 3; i++ {
+		if err := w.WriteRecord(EmailRecord{Subject: "메일"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{2, 1} {
+		path := rotatedPath(base, i+1)
+		if filepath.Base(path) != []string{"out.0001.csv.gz", "out.0002.csv.gz"}[i] {
+			t.Errorf("분할 파일 이름 = %s", filepath.Base(path))
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(bytes.NewReader(gunzipBytes(t, data))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != want+1 || indexOf(rows[0], "제목") < 0 {
+			t.Errorf("%s: %d행 (헤더 %q), want 헤더 + %d행", filepath.Base(path), len(rows), rows[0], want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 재명명/복사 대상 파일이 이미 있을 때의 처리 정책 (-on-conflict)
+const (
+	conflictSuffix = "suffix" // "이름 (2).eml"처럼 번호를 붙여 새 파일로 저장
+	conflictSkip   = "skip"   // 기존 파일을 두고 건너뜀
+	conflictError  = "error"  // 실패로 기록
+)
+
+// maxConflictSuffix는 번호 접미사를 시도할 최대 값입니다.
+const maxConflictSuffix = 1000
+
+func validConflictPolicy(policy string) bool {
+	return policy == conflictSuffix || policy == conflictSkip || policy == conflictError
+}
+
+// suffixedPath는 "dir/name.eml"을 "dir/name (n).eml"로 바꿉니다.
+func suffixedPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// createExclusive는 기존 파일을 덮어쓰지 않도록 O_EXCL로 대상 파일을 만들고, 실제로 만든 경로를 반환합니다.
+// 이미 있으면 policy에 따라 번호를 붙이거나, 건너뛰거나(파일 nil 반환), 오류를 반환합니다.
+func createExclusive(path, policy string) (*os.File, string, error) {
+	for n := 1; n <= maxConflictSuffix; n++ {
+		p := path
+		if n > 1 {
+			p = suffixedPath(path, n)
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return f, p, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, "", err
+		}
+		switch policy {
+		case conflictSkip:
+			warnf("대상 파일이 이미 있어 건너뜀: %s", p)
+			return nil, "", nil
+		case conflictError:
+			return nil, "", fmt.Errorf("대상 파일이 이미 있음: %s", p)
+		}
+	}
+	return nil, "", fmt.Errorf("사용할 수 있는 파일명을 찾지 못함: %s", path)
+}
+
+// availablePath는 제자리 재명명에 쓸 경로를 policy에 따라 고릅니다. 건너뛸 때는 빈 문자열을 반환합니다.
+// 원본 자신과 같은 경로(이미 재명명된 파일)는 그대로 반환합니다.
+func availablePath(src, path, policy string) (string, error) {
+	for n := 1; n <= maxConflictSuffix; n++ {
+		p := path
+		if n > 1 {
+			p = suffixedPath(path, n)
+		}
+		if p == src {
+			return p, nil
+		}
+		if _, err := os.Lstat(p); errors.Is(err, fs.ErrNotExist) {
+			return p, nil
+		} else if err != nil {
+			return "", err
+		}
+		switch policy {
+		case conflictSkip:
+			warnf("대상 파일이 이미 있어 건너뜀: %s", p)
+			return "", nil
+		case conflictError:
+			return "", fmt.Errorf("대상 파일이 이미 있음: %s", p)
+		}
+	}
+	return "", fmt.Errorf("사용할 수 있는 파일명을 찾지 못함: %s", path)
+}
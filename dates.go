@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	messageMail "github.com/emersion/go-message/mail"
+)
+
+// 날짜 출처 (DateSource 필드 값)
+const (
+	dateSourceHeader   = "Date"
+	dateSourceReceived = "Received"
+)
+
+// dateLayouts는 h.Date()가 실패한 Date 헤더에 추가로 시도할 형식입니다.
+// 국내/일본 메일 클라이언트가 만드는 비표준 형식과 RFC 5322 §4.3의 2자리 연도를 포함하며,
+// -date-layout 옵션으로 지정한 형식이 앞에 추가됩니다.
+var dateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04:05",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05",
+	"Mon, 2 Jan 2006 15.04.05 -0700",
+	"Mon Jan 2 15:04:05 2006",
+	"Mon Jan 2 15:04:05 -0700 2006",
+	"2006.01.02 15:04:05 -0700",
+	"2006.1.2 15:04:05 -0700",
+	"2006.01.02 15:04:05",
+	"2006.1.2 15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05-07:00",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05 -0700",
+	"2006/01/02 15:04:05",
+	"2006년 1월 2일 PM 3:04:05",
+	"2006년 1월 2일 PM 3:04",
+	"2006년 1월 2일 15:04:05",
+	"2006年1月2日 15:04:05",
+	"2006年1月2日 15:04",
+	"2006年1月2日 PM 3:04",
+}
+
+// receivedDateLayouts는 Received 헤더 타임스탬프에서 흔히 보이는 형식입니다.
+// net/mail.ParseDate로 파싱되지 않을 때 순서대로 시도합니다.
+var receivedDateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon Jan 2 15:04:05 2006",
+	"Mon, 02 Jan 2006 15:04:05.000 -0700",
+}
+
+// zoneOffsets는 숫자 오프셋 대신 쓰이는 시간대 약어입니다 (RFC 822의 obs-zone과 오래된 메일 프로그램이 쓰는 약어).
+// time.Parse는 모르는 약어를 오프셋 0으로 처리하므로 파싱 전에 숫자로 바꿉니다.
+// 여러 지역에서 쓰는 약어는 RFC 822의 뜻(CST는 미국 중부)을 따르고, IST처럼 흔히 겹치는 약어는 넣지 않습니다.
+var zoneOffsets = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"AST": "-0400", "ADT": "-0300",
+	"NST": "-0330", "NDT": "-0230",
+	"AKST": "-0900", "AKDT": "-0800",
+	"HST": "-1000",
+	"WET": "+0000", "WEST": "+0100", "BST": "+0100",
+	"CET": "+0100", "CEST": "+0200", "MET": "+0100", "MEST": "+0200",
+	"EET": "+0200", "EEST": "+0300", "MSK": "+0300",
+	"HKT": "+0800", "SGT": "+0800", "AWST": "+0800",
+	"KST": "+0900", "JST": "+0900",
+	"ACST": "+0930", "AEST": "+1000", "AEDT": "+1100",
+	"NZST": "+1200", "NZDT": "+1300",
+}
+
+var (
+	// 끝의 "(KST)" 같은 주석과 "(화)" 같은 요일 표기
+	dateCommentRegex = regexp.MustCompile(`\([^()]*\)`)
+	// 끝에 오는 시간대 약어. 앞에 숫자 오프셋이 있으면("-0800 PST") 약어는 지우기만 합니다.
+	trailingZoneRegex = regexp.MustCompile(`(\s[+-]\d{4})?\s([A-Za-z]{1,4})$`)
+	// RFC 5322 형식에서 연도가 2자리인 경우 (예: "Tue, 5 Mar 24 ...")
+	twoDigitYearRegex = regexp.MustCompile(`^(?:[A-Za-z]{3},\s*)?\d{1,2}\s+[A-Za-z]{3}\s+\d{2}\s`)
+)
+
+// dateLayoutRFC5322는 날짜가 표준 형식(net/mail.ParseDate)으로 파싱되었음을 나타내는 DateLayout 값입니다.
+const dateLayoutRFC5322 = "RFC5322"
+
+// parsedDate는 파싱한 날짜와 그 과정입니다.
+type parsedDate struct {
+	time   time.Time
+	source string // 날짜 출처 (dateSourceHeader, dateSourceReceived)
+	layout string // 파싱에 성공한 형식 (표준 형식이면 dateLayoutRFC5322)
+	zone   string // 숫자 오프셋으로 바꾼 시간대 약어 (없으면 빈 문자열)
+}
+
+// messageDate는 메일의 발송 시각과 그 출처, 파싱에 성공한 형식을 반환합니다.
+// Date 헤더는 표준 형식 외에 비표준 형식도 시도하고, 그래도 실패하면 가장 위 Received 헤더의 타임스탬프를 사용합니다.
+func messageDate(h messageMail.Header) (parsedDate, bool) {
+	if d, ok := parseLenientDate(h.Get("Date"), dateLayouts); ok {
+		d.source = dateSourceHeader
+		return d, true
+	}
+	if d, ok := parseReceivedDate(h.Values("Received")); ok {
+		d.source = dateSourceReceived
+		return d, true
+	}
+	return parsedDate{}, false
+}
+
+// 날짜 범위 필터(-since/-until)로 제외된 메일을 나타냅니다.
+var (
+	errOutsideDateRange = errors.New("날짜 범위 밖")
+	errUndatedExcluded  = errors.New("날짜를 알 수 없어 제외")
+)
+
+// 날짜를 알 수 없는 메일의 처리 방식 (-undated-policy)
+const (
+	undatedInclude = "include"
+	undatedExclude = "exclude"
+)
+
+// dateRange는 -since/-until 날짜 범위 필터입니다. since 이상, until 미만의 메일만 포함하며
+// 0 값은 제한이 없음을 뜻합니다. 헤더만 읽고 판단하므로 제외되는 메일은 본문을 읽지 않습니다.
+type dateRange struct {
+	since, until   time.Time
+	includeUndated bool
+	// undated는 날짜를 알 수 없었던 메일 수로, 워커에서 동시에 증가시킵니다.
+	undated atomic.Int64
+	// excluded는 범위 밖이거나 날짜를 알 수 없어 제외된 메일 수입니다.
+	excluded atomic.Int64
+}
+
+// check는 메일이 범위 안이면 nil을, 아니면 errOutsideDateRange 또는 errUndatedExcluded를 반환합니다.
+func (d *dateRange) check(h messageMail.Header) error {
+	date, ok := messageDate(h)
+	if !ok {
+		if d.includeUndated {
+			return nil
+		}
+		return errUndatedExcluded
+	}
+	if (!d.since.IsZero() && date.time.Before(d.since)) || (!d.until.IsZero() && !date.time.Before(d.until)) {
+		return errOutsideDateRange
+	}
+	return nil
+}
+
+// parseDateFlag는 -since/-until 값을 파싱합니다. "2024-03-01" 같은 날짜는 로컬 시간대의 자정으로 보고,
+// endOfDay이면 그 다음 날 자정(해당 날짜를 포함하는 배타적 상한)을 반환합니다. RFC 3339 시각은 그대로 사용합니다.
+func parseDateFlag(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("날짜(2006-01-02) 또는 RFC 3339 시각이어야 합니다: %q", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseReceivedDate는 가장 위(가장 마지막에 추가된) Received 헤더의 타임스탬프를 파싱합니다.
+// 타임스탬프는 RFC 5321에 따라 마지막 ";" 뒤에 옵니다.
+func parseReceivedDate(received []string) (parsedDate, bool) {
+	if len(received) == 0 {
+		return parsedDate{}, false
+	}
+	value := received[0]
+	i := strings.LastIndex(value, ";")
+	if i < 0 {
+		return parsedDate{}, false
+	}
+	return parseLenientDate(value[i+1:], receivedDateLayouts)
+}
+
+// normalizeDateString은 파싱 전에 날짜 문자열을 정리합니다.
+// 주석 제거, 연속 공백 정리, 오전/오후 표기 변환, 끝의 시간대 약어를 숫자 오프셋으로 변환하며,
+// 약어를 오프셋으로 바꿨으면 그 약어(대문자)를 함께 반환합니다. 숫자 오프셋 뒤의 약어는 지우기만 합니다.
+func normalizeDateString(value string) (string, string) {
+	value = dateCommentRegex.ReplaceAllString(value, " ")
+	value = strings.NewReplacer("오전", "AM", "오후", "PM", "午前", "AM", "午後", "PM").Replace(value)
+	value = strings.Join(strings.Fields(value), " ")
+	if m := trailingZoneRegex.FindStringSubmatchIndex(value); m != nil {
+		zone := strings.ToUpper(value[m[4]:m[5]])
+		if offset, ok := zoneOffsets[zone]; ok {
+			if m[2] >= 0 {
+				return value[:m[3]], ""
+			}
+			return value[:m[4]] + offset, zone
+		}
+	}
+	return value, ""
+}
+
+// parseLenientDate는 net/mail.ParseDate를 먼저 시도하고, 실패하면 layouts를 순서대로 시도합니다.
+// 성공하면 사용한 형식(표준 형식이면 dateLayoutRFC5322)과 오프셋으로 바꾼 시간대 약어를 함께 반환합니다.
+func parseLenientDate(value string, layouts []string) (parsedDate, bool) {
+	value, zone := normalizeDateString(value)
+	if value == "" {
+		return parsedDate{}, false
+	}
+	if t, err := netmail.ParseDate(value); err == nil {
+		if twoDigitYearRegex.MatchString(value) {
+			t = fixTwoDigitYear(t)
+		}
+		return parsedDate{time: t, layout: dateLayoutRFC5322, zone: zone}, true
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if isTwoDigitYearLayout(layout) {
+			t = fixTwoDigitYear(t)
+		}
+		return parsedDate{time: t, layout: layout, zone: zone}, true
+	}
+	return parsedDate{}, false
+}
+
+func isTwoDigitYearLayout(layout string) bool {
+	return strings.Contains(layout, "06") && !strings.Contains(layout, "2006")
+}
+
+// fixTwoDigitYear는 RFC 5322 §4.3 규칙(00-49는 2000년대, 50-99는 1900년대)을 적용합니다.
+// time.Parse는 69를 경계로 사용하므로 50-68년을 보정합니다.
+func fixTwoDigitYear(t time.Time) time.Time {
+	if y := t.Year(); y >= 2050 && y < 2069 {
+		return t.AddDate(-100, 0, 0)
+	}
+	return t
+}
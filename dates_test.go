@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	messageMail "github.com/emersion/go-message/mail"
+)
+
+func TestParseLenientDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     string // "2006-01-02 15:04:05 -0700" 형식, 빈 문자열이면 파싱 실패
+		wantZone string
+	}{
+		// 표준 형식
+		{"Tue, 5 Mar 2024 14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"5 Mar 2024 14:22:10 -0000", "2024-03-05 14:22:10 +0000", ""},
+		// 실제 메일에서 본 비표준 형식
+		{"2024.03.05 14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"2024.3.5 14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"2024-03-05 14:22:10", "2024-03-05 14:22:10 +0000", ""},
+		{"2024/03/05 14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"2024-03-05T14:22:10+09:00", "2024-03-05 14:22:10 +0900", ""},
+		{"Tue,  5 Mar 2024  14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"Tue, 5 Mar 2024 14:22:10 +0900 (KST)", "2024-03-05 14:22:10 +0900", ""},
+		{"Tue, 5 Mar 2024 14.22.10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"Tue Mar  5 14:22:10 2024", "2024-03-05 14:22:10 +0000", ""},
+		{"Tue, 5 Mar 2024 14:22:10", "2024-03-05 14:22:10 +0000", ""},
+		{"2024년 3월 5일 오후 2:22:10", "2024-03-05 14:22:10 +0000", ""},
+		{"2024년 3월 5일 (화) 오후 2:22", "2024-03-05 14:22:00 +0000", ""},
+		{"2024年3月5日 14:22:10", "2024-03-05 14:22:10 +0000", ""},
+		{"2024年3月5日 午後 2:22", "2024-03-05 14:22:00 +0000", ""},
+		// 2자리 연도 (RFC 5322 §4.3: 00-49는 2000년대, 50-99는 1900년대)
+		{"Tue, 5 Mar 24 14:22:10 +0900", "2024-03-05 14:22:10 +0900", ""},
+		{"Tue, 5 Mar 24 14:22 +0900", "2024-03-05 14:22:00 +0900", ""},
+		{"5 Mar 49 14:22:10 +0000", "2049-03-05 14:22:10 +0000", ""},
+		{"Sun, 5 Mar 50 14:22:10 +0000", "1950-03-05 14:22:10 +0000", ""},
+		{"Wed, 5 Mar 68 14:22:10 +0000", "1968-03-05 14:22:10 +0000", ""},
+		{"Tue, 5 Mar 96 14:22:10 +0000", "1996-03-05 14:22:10 +0000", ""},
+		// 시간대 약어
+		{"Tue, 5 Mar 24 14:22:10 KST", "2024-03-05 14:22:10 +0900", "KST"},
+		{"Tue, 5 Mar 2024 14:22:10 JST", "2024-03-05 14:22:10 +0900", "JST"},
+		{"Tue, 5 Mar 2024 14:22:10 pst", "2024-03-05 14:22:10 -0800", "PST"},
+		{"Tue, 5 Mar 2024 14:22:10 EDT", "2024-03-05 14:22:10 -0400", "EDT"},
+		{"Tue, 5 Mar 2024 14:22:10 CST", "2024-03-05 14:22:10 -0600", "CST"},
+		{"Tue, 5 Mar 2024 14:22:10 UT", "2024-03-05 14:22:10 +0000", "UT"},
+		{"Tue, 5 Mar 2024 14:22:10 -0800 PST", "2024-03-05 14:22:10 -0800", ""},
+		{"Tue, 5 Mar 2024 14:22:10 +0900 (KST)", "2024-03-05 14:22:10 +0900", ""},
+		// 뜻이 겹치는 약어(IST)는 바꾸지 않으므로 오프셋 0으로 읽힘
+		{"Tue, 5 Mar 2024 14:22:10 IST", "2024-03-05 14:22:10 +0000", ""},
+		// 파싱할 수 없는 값
+		{"", "", ""},
+		{"yesterday", "", ""},
+		{"Tue, 5 Mar 2024", "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := parseLenientDate(tt.value, dateLayouts)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("parseLenientDate(%q) = %s (%s), want 실패", tt.value, got.time.Format(time.RFC3339), got.layout)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("parseLenientDate(%q) 실패, want %s", tt.value, tt.want)
+			continue
+		}
+		if s := got.time.Format("2006-01-02 15:04:05 -0700"); s != tt.want || got.zone != tt.wantZone {
+			t.Errorf("parseLenientDate(%q) = %s zone %q (%s), want %s zone %q", tt.value, s, got.zone, got.layout, tt.want, tt.wantZone)
+		}
+	}
+}
+
+func TestFixTwoDigitYear(t *testing.T) {
+	tests := []struct{ year, want int }{
+		{2000, 2000}, {2049, 2049}, {2050, 1950}, {2068, 1968}, {1969, 1969}, {1999, 1999},
+	}
+	for _, tt := range tests {
+		got := fixTwoDigitYear(time.Date(tt.year, 1, 1, 0, 0, 0, 0, time.UTC)).Year()
+		if got != tt.want {
+			t.Errorf("fixTwoDigitYear(%d) = %d, want %d", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestMessageDateSource(t *testing.T) {
+	received := "from mx.example.com by mail.example.org; Tue, 5 Mar 2024 05:22:11 +0000"
+	tests := []struct {
+		date, received string
+		want, source   string
+	}{
+		{"Tue, 5 Mar 2024 14:22:10 +0900", received, "2024-03-05 14:22:10 +0900", dateSourceHeader},
+		{"2024.03.05 14:22:10 +0900", received, "2024-03-05 14:22:10 +0900", dateSourceHeader},
+		{"", received, "2024-03-05 05:22:11 +0000", dateSourceReceived},
+		{"not a date", received, "2024-03-05 05:22:11 +0000", dateSourceReceived},
+		{"not a date", "from mx.example.com by mail.example.org", "", ""},
+	}
+	for _, tt := range tests {
+		var h messageMail.Header
+		if tt.date != "" {
+			h.Set("Date", tt.date)
+		}
+		h.Set("Received", tt.received)
+		got, ok := messageDate(h)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("messageDate(%q) = %v, want 실패", tt.date, got.time)
+			}
+			continue
+		}
+		if s := got.time.Format("2006-01-02 15:04:05 -0700"); !ok || s != tt.want || got.source != tt.source {
+			t.Errorf("messageDate(%q) = %s (%s), want %s (%s)", tt.date, s, got.source, tt.want, tt.source)
+		}
+	}
+}
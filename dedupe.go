@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// duplicateGroup은 중복 보고서의 한 항목으로, 같은 Message-ID를 가진 파일 목록입니다.
+// Kept는 출력에 남긴 파일(경로 순으로 처음), Duplicates는 건너뛴 나머지 파일입니다.
+type duplicateGroup struct {
+	MessageID  string   `json:"message_id"`
+	Kept       string   `json:"kept"`
+	Duplicates []string `json:"duplicates"`
+}
+
+// msgIDIndex는 Message-ID별로 경로 순 첫 파일을 기록합니다 (-dedupe-by-msgid).
+// 워커가 처리를 끝내는 순서와 관계없이 같은 파일이 남도록, 본 처리 전에 모든 파일의 헤더를 읽어 만듭니다.
+type msgIDIndex struct {
+	groups map[string][]int // Message-ID → 파일 번호 (files 안의 위치, 오름차순)
+	files  []collectedFile
+}
+
+// scanMessageIDs는 workerCount개의 고루틴으로 파일의 헤더만 읽어 Message-ID 색인을 만듭니다.
+// 날짜/헤더 필터는 적용하지 않으므로(필터별 제외 수가 두 번 세지지 않도록) 첫 파일은 필터와 관계없이 정해집니다.
+// 헤더를 읽지 못한 파일과 Message-ID가 없는 파일은 색인에 넣지 않습니다.
+func scanMessageIDs(files []collectedFile, workerCount int) *msgIDIndex {
+	ids := make([]string, len(files))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(max(workerCount, 1))
+	for i := 0; i < max(workerCount, 1); i++ {
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				if rec, _, err := processEmlFile(files[n].path, parseOptions{headersOnly: true}); err == nil {
+					ids[n] = rec.MessageID
+				}
+			}
+		}()
+	}
+	for n := range files {
+		next <- n
+	}
+	close(next)
+	wg.Wait()
+
+	idx := &msgIDIndex{groups: make(map[string][]int), files: files}
+	for n, id := range ids {
+		if id != "" {
+			idx.groups[id] = append(idx.groups[id], n)
+		}
+	}
+	return idx
+}
+
+// isDuplicate는 path가 같은 Message-ID를 가진 파일 중 경로 순으로 처음이 아니면 true를 반환합니다.
+func (x *msgIDIndex) isDuplicate(messageID, path string) bool {
+	if messageID == "" {
+		return false
+	}
+	group := x.groups[messageID]
+	return len(group) > 1 && x.files[group[0]].path != path
+}
+
+// duplicates는 파일이 둘 이상인 Message-ID의 목록을 Message-ID 순으로 반환합니다.
+func (x *msgIDIndex) duplicates() []duplicateGroup {
+	var groups []duplicateGroup
+	for id, ns := range x.groups {
+		if len(ns) < 2 {
+			continue
+		}
+		g := duplicateGroup{MessageID: id, Kept: x.files[ns[0]].path}
+		for _, n := range ns[1:] {
+			g.Duplicates = append(g.Duplicates, x.files[n].path)
+		}
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].MessageID < groups[j].MessageID })
+	return groups
+}
+
+// writeDuplicatesReport는 Message-ID 중복 목록을 path에 저장합니다.
+// 확장자가 .json이면 JSON 배열로, 그 외에는 파일마다 한 행인 CSV로 저장합니다.
+func writeDuplicatesReport(path string, groups []duplicateGroup) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if groups == nil {
+			groups = []duplicateGroup{}
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Message-ID의 <...>를 그대로 기록
+		if err := enc.Encode(groups); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"Message-ID", "파일", "처리"})
+	for _, g := range groups {
+		w.Write([]string{g.MessageID, g.Kept, "유지"})
+		for _, d := range g.Duplicates {
+			w.Write([]string{g.MessageID, d, "중복"})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDedupeFixtures는 dir에 name → Message-ID인 메일을 만들고 경로 순 collectedFile 목록을 반환합니다.
+// Message-ID가 빈 문자열이면 헤더를 넣지 않습니다.
+func writeDedupeFixtures(t *testing.T, dir string, ids [][2]string) []collectedFile {
+	t.Helper()
+	var files []collectedFile
+	for _, e := range ids {
+		content := "From: a@example.com\r\nSubject: " + e[0] + "\r\n"
+		if e[1] != "" {
+			content += "Message-ID: " + e[1] + "\r\n"
+		}
+		path := filepath.Join(dir, e[0])
+		if err := os.WriteFile(path, []byte(content+"\r\nbody\r\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, collectedFile{root: dir, path: path})
+	}
+	return files
+}
+
+// 같은 Message-ID는 경로 순 첫 파일만 남기고, Message-ID가 없는 파일은 중복으로 보지 않음
+func TestMsgIDIndex(t *testing.T) {
+	dir := t.TempDir()
+	files := writeDedupeFixtures(t, dir, [][2]string{
+		{"a.eml", "<one@example.com>"},
+		{"b.eml", "<two@example.com>"},
+		{"c.eml", "<one@example.com>"},
+		{"d.eml", ""},
+		{"e.eml", ""},
+		{"f.eml", "<one@example.com>"},
+	})
+	for _, workers := range []int{0, 1, 4} {
+		idx := scanMessageIDs(files, workers)
+		for _, tt := range []struct {
+			name, id string
+			want     bool
+		}{
+			{"a.eml", "<one@example.com>", false},
+			{"b.eml", "<two@example.com>", false},
+			{"c.eml", "<one@example.com>", true},
+			{"d.eml", "", false},
+			{"e.eml", "", false},
+			{"f.eml", "<one@example.com>", true},
+		} {
+			if got := idx.isDuplicate(tt.id, filepath.Join(dir, tt.name)); got != tt.want {
+				t.Errorf("워커 %d, %s: isDuplicate = %v, want %v", workers, tt.name, got, tt.want)
+			}
+		}
+		groups := idx.duplicates()
+		if len(groups) != 1 || groups[0].MessageID != "<one@example.com>" || groups[0].Kept != filepath.Join(dir, "a.eml") ||
+			!equalStrings(groups[0].Duplicates, []string{filepath.Join(dir, "c.eml"), filepath.Join(dir, "f.eml")}) {
+			t.Errorf("워커 %d: 중복 목록 = %+v", workers, groups)
+		}
+	}
+}
+
+func TestWriteDuplicatesReport(t *testing.T) {
+	groups := []duplicateGroup{
+		{MessageID: "<one@example.com>", Kept: "a.eml", Duplicates: []string{"c.eml", "f.eml"}},
+		{MessageID: "<two@example.com>", Kept: "b.eml", Duplicates: []string{"g.eml"}},
+	}
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "dups.csv")
+	if err := writeDuplicatesReport(csvPath, groups); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Message-ID", "파일", "처리"},
+		{"<one@example.com>", "a.eml", "유지"},
+		{"<one@example.com>", "c.eml", "중복"},
+		{"<one@example.com>", "f.eml", "중복"},
+		{"<two@example.com>", "b.eml", "유지"},
+		{"<two@example.com>", "g.eml", "중복"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("행 %d개, want %d개: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !equalStrings(rows[i], want[i]) {
+			t.Errorf("%d번째 행 = %q, want %q", i+1, rows[i], want[i])
+		}
+	}
+
+	// 확장자는 대소문자를 구분하지 않으며, <...>는 이스케이프하지 않음
+	jsonPath := filepath.Join(dir, "dups.JSON")
+	if err := writeDuplicatesReport(jsonPath, groups); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(data) || !strings.Contains(string(data), `"<one@example.com>"`) {
+		t.Errorf("JSON 보고서 = %s", data)
+	}
+	var got []duplicateGroup
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Kept != "b.eml" || !equalStrings(got[0].Duplicates, groups[0].Duplicates) {
+		t.Errorf("JSON 항목 = %+v", got)
+	}
+
+	// 중복이 없으면 null 대신 빈 배열
+	if err := writeDuplicatesReport(jsonPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(jsonPath); string(data) != "[]\n" {
+		t.Errorf("빈 JSON 보고서 = %q", data)
+	}
+}
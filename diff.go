@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 비교 결과 종류
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+)
+
+// diffEntry는 -diff 출력의 한 줄로, 레코드 필드 앞에 변경 종류(Change)를 붙입니다.
+type diffEntry struct {
+	Change string
+	EmailRecord
+}
+
+// diffKeys는 레코드를 실행 간에 비교할 키입니다. RecordID가 같거나, RecordID가 다르더라도(다른 버전의 출력,
+// RecordID 규칙이 바뀌기 전의 출력 등) Message-ID가 같으면 같은 메일로 봅니다.
+func diffKeys(r EmailRecord) []string {
+	var keys []string
+	if r.RecordID != "" {
+		keys = append(keys, "id:"+r.RecordID)
+	}
+	if r.MessageID != "" {
+		keys = append(keys, "mid:"+r.MessageID)
+	}
+	return keys
+}
+
+// loadPreviousRecords는 이전 실행의 JSON 배열 또는 NDJSON 출력을 읽습니다.
+func loadPreviousRecords(path string) ([]EmailRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []EmailRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("JSON 읽기 실패: %w", err)
+		}
+		return records, nil
+	}
+	var records []EmailRecord
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var r EmailRecord
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("NDJSON %d번째 레코드 읽기 실패: %w", len(records)+1, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// diffRecordWriter는 이번 실행의 레코드를 이전 출력과 비교하여, 새로 나온 메일은 도착하는 대로 added로,
+// 이전에만 있던 메일은 닫을 때 이전 출력 순서대로 removed로 NDJSON 출력합니다.
+// 키가 없는 이전 레코드는 비교할 수 없으므로 무시합니다.
+type diffRecordWriter struct {
+	w       *bufio.Writer
+	old     []EmailRecord
+	oldKeys map[string]bool
+	seen    map[string]bool
+}
+
+func newDiffRecordWriter(w io.Writer, old []EmailRecord) *diffRecordWriter {
+	d := &diffRecordWriter{w: bufio.NewWriter(w), old: old, oldKeys: make(map[string]bool), seen: make(map[string]bool)}
+	for _, r := range old {
+		for _, k := range diffKeys(r) {
+			d.oldKeys[k] = true
+		}
+	}
+	return d
+}
+
+func (d *diffRecordWriter) write(change string, r EmailRecord) error {
+	b, err := json.Marshal(diffEntry{Change: change, EmailRecord: r})
+	if err != nil {
+		return err
+	}
+	if _, err := d.w.Write(b); err != nil {
+		return err
+	}
+	return d.w.WriteByte('\n')
+}
+
+// anyKey는 keys 중 set에 있는 키가 있는지 확인합니다.
+func anyKey(set map[string]bool, keys []string) bool {
+	for _, k := range keys {
+		if set[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *diffRecordWriter) WriteRecord(r EmailRecord) error {
+	keys := diffKeys(r)
+	for _, k := range keys {
+		d.seen[k] = true
+	}
+	if anyKey(d.oldKeys, keys) {
+		return nil
+	}
+	return d.write(diffAdded, r)
+}
+
+func (d *diffRecordWriter) Close() error {
+	for _, r := range d.old {
+		keys := diffKeys(r)
+		if len(keys) == 0 || anyKey(d.seen, keys) {
+			continue
+		}
+		// 이전 출력에 같은 키가 여러 번 있어도 한 번만 출력
+		for _, k := range keys {
+			d.seen[k] = true
+		}
+		if err := d.write(diffRemoved, r); err != nil {
+			return err
+		}
+	}
+	return d.w.Flush()
+}
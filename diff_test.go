@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePrevious는 레코드를 format(json/ndjson) 출력으로 파일에 써서 이전 실행의 출력을 만듭니다.
+func writePrevious(t *testing.T, format string, records []EmailRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newRecordWriter(&buf, format)
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "old."+format)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPreviousRecords(t *testing.T) {
+	records := []EmailRecord{{RecordID: "1", Subject: "첫 메일"}, {MessageID: "<b@example.com>", Subject: "둘째"}}
+	for _, format := range []string{formatJSON, formatNDJSON} {
+		got, err := loadPreviousRecords(writePrevious(t, format, records))
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(got) != 2 || got[0].RecordID != "1" || got[0].Subject != "첫 메일" || got[1].MessageID != "<b@example.com>" {
+			t.Errorf("%s: 읽은 레코드 = %+v", format, got)
+		}
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		name, content, wantErr string
+	}{
+		{"empty.ndjson", "", ""},
+		{"bad.json", `[{"RecordID": "1"},`, "JSON 읽기 실패"},
+		{"bad.ndjson", "{\"RecordID\":\"1\"}\n{\"RecordID\":\n", "NDJSON 2번째 레코드 읽기 실패"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadPreviousRecords(path)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: 오류 = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+	if _, err := loadPreviousRecords(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("없는 파일: 오류 = %v", err)
+	}
+}
+
+func TestDiffRecordWriter(t *testing.T) {
+	old := []EmailRecord{
+		{RecordID: "id-a", MessageID: "<a@x>", Subject: "그대로"},
+		{RecordID: "id-b", MessageID: "<b@x>", Subject: "사라짐"},
+		{RecordID: "id-b", MessageID: "<b@x>", Subject: "사라짐"},
+		// RecordID가 없는 이전 출력은 Message-ID로 비교
+		{MessageID: "<c@x>", Subject: "Message-ID로 일치"},
+		// RecordID 규칙이 바뀌기 전의 출력도 Message-ID로 비교
+		{RecordID: "old-rule-d", MessageID: "<d@x>", Subject: "RecordID가 바뀜"},
+		// 키가 없는 이전 레코드는 비교하지 않음
+		{Subject: "키 없음"},
+		{RecordID: "id-e", Subject: "Message-ID 없이 사라짐"},
+	}
+	current := []EmailRecord{
+		{RecordID: "id-a", MessageID: "<a@x>", Subject: "그대로"},
+		{RecordID: "id-c", MessageID: "<c@x>", Subject: "Message-ID로 일치"},
+		{RecordID: "id-d", MessageID: "<d@x>", Subject: "RecordID가 바뀜"},
+		{RecordID: "id-f", MessageID: "<f@x>", Subject: "새 메일"},
+		{RecordID: "id-g", Subject: "Message-ID 없는 새 메일"},
+	}
+	var buf bytes.Buffer
+	w := newDiffRecordWriter(&buf, old)
+	for _, r := range current {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var e diffEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("NDJSON 줄 읽기 실패: %q (%v)", line, err)
+		}
+		got = append(got, e.Change+" "+e.Subject)
+	}
+	want := []string{
+		"added 새 메일",
+		"added Message-ID 없는 새 메일",
+		"removed 사라짐",
+		"removed Message-ID 없이 사라짐",
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("diff 출력 = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// domainList는 도메인 패턴 목록입니다 (-url-domain-deny, -match-url-domain).
+// "example.com"과 "*.example.com"은 모두 example.com 자신과 모든 하위 도메인(login.example.com 등)에 맞습니다.
+type domainList []string
+
+// loadDomainList는 명령행 패턴과 파일(한 줄에 하나, 빈 줄과 "#" 주석은 무시)에서 도메인 목록을 만듭니다.
+func loadDomainList(patterns, files []string) (domainList, error) {
+	var list domainList
+	for _, p := range patterns {
+		list = list.add(p)
+	}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			list = list.add(line)
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return list, nil
+}
+
+func (l domainList) add(pattern string) domainList {
+	p := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+	p = strings.TrimPrefix(p, "*.")
+	if p == "" {
+		return l
+	}
+	return append(l, p)
+}
+
+// match는 호스트(포트가 붙어 있어도 됨)가 목록의 도메인이거나 그 하위 도메인인지 확인합니다.
+// 패턴이 등록 도메인(eTLD+1)이면 "evil.com"이 "login.evil.com"에 맞고, "co.kr" 같은 공개 접미사는
+// 등록 도메인 비교로 걸러 여러 조직의 도메인이 한꺼번에 맞지 않게 합니다.
+func (l domainList) match(host string) bool {
+	host, ok := resolvableDomain(host)
+	if !ok {
+		return false
+	}
+	registered, _ := registeredDomain(host)
+	for _, p := range l {
+		if host == p {
+			return true
+		}
+		if strings.HasSuffix(host, "."+p) && len(p) >= len(registered) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyLine은 줄바꿈으로 나뉜 호스트 중 하나라도 목록에 맞는지 확인합니다.
+func (l domainList) matchAnyLine(hosts string) bool {
+	for _, h := range strings.Split(hosts, "\n") {
+		if l.match(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubURLDomains는 목록에 맞는 도메인(사내/무해한 도메인 등)의 URL을 URLs, URLSources, URLDomains, URLCounts에서 제거하고 URLCount를 다시 셉니다.
+func scrubURLDomains(r *EmailRecord, deny domainList) {
+	if r.URLs == "" {
+		return
+	}
+	urls := strings.Split(r.URLs, "\n")
+	sources := strings.Split(r.URLSources, "\n")
+	var keptURLs, keptSources []string
+	for i, u := range urls {
+		if host, ok := urlDomain(u); ok && deny.match(host) {
+			continue
+		}
+		keptURLs = append(keptURLs, u)
+		if i < len(sources) {
+			keptSources = append(keptSources, sources[i])
+		}
+	}
+	var keptDomains []string
+	for _, d := range strings.Split(r.URLDomains, "\n") {
+		if d != "" && !deny.match(d) {
+			keptDomains = append(keptDomains, d)
+		}
+	}
+	r.URLs = strings.Join(keptURLs, "\n")
+	r.URLSources = strings.Join(keptSources, "\n")
+	r.URLDomains = strings.Join(keptDomains, "\n")
+	r.URLCount = len(appendUnique(nil, keptURLs...))
+	if r.URLCounts != "" {
+		var keptCounts []string
+		for _, line := range strings.Split(r.URLCounts, "\n") {
+			_, u, _ := strings.Cut(line, " ")
+			if host, ok := urlDomain(u); !ok || !deny.match(host) {
+				keptCounts = append(keptCounts, line)
+			}
+		}
+		r.URLCounts = strings.Join(keptCounts, "\n")
+	}
+}
+
+// parseDomainArg는 쉼표로 나눈 도메인 목록이나 "@파일"(한 줄에 하나) 인자로 도메인 목록을 만듭니다 (-own-domains).
+func parseDomainArg(arg string) (domainList, error) {
+	if path, ok := strings.CutPrefix(arg, "@"); ok {
+		return loadDomainList(nil, []string{path})
+	}
+	return loadDomainList(strings.Split(arg, ","), nil)
+}
+
+// separateOwnDomains는 자사 도메인(-own-domains)을 URLDomains에서 빼고, 자사 도메인 URL 수를 InternalURLCount에 기록합니다.
+// URLs와 URLCount는 그대로 두므로 전체 URL은 계속 확인할 수 있습니다.
+func separateOwnDomains(r *EmailRecord, own domainList) {
+	r.InternalURLCount = 0
+	for _, u := range appendUnique(nil, strings.Split(r.URLs, "\n")...) {
+		if host, ok := urlDomain(u); ok && own.match(host) {
+			r.InternalURLCount++
+		}
+	}
+	var kept []string
+	for _, d := range strings.Split(r.URLDomains, "\n") {
+		if d != "" && !own.match(d) {
+			kept = append(kept, d)
+		}
+	}
+	r.URLDomains = strings.Join(kept, "\n")
+}
+
+// urlDomainFilters는 목록에 맞는 도메인의 URL이 하나 이상 있는 메일만 남기는 필터(-match-url-domain)를 구성합니다.
+func urlDomainFilters(allow domainList) recordFilters {
+	if len(allow) == 0 {
+		return nil
+	}
+	return recordFilters{newRecordFilter("-match-url-domain", func(r *EmailRecord) bool { return allow.matchAnyLine(r.URLDomains) })}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emersion/go-message"
+)
+
+// errFailFast는 -fail-fast 옵션으로 처리가 중단되었음을 나타냅니다.
+var errFailFast = errors.New("파일 처리 실패로 중단됨 (-fail-fast)")
+
+// 실패가 발생한 처리 단계
+const (
+	stageCollect     = "경로 수집"
+	stageOpen        = "파일 열기"
+	stageHeaderParse = "헤더 파싱"
+	stageBodyParse   = "본문 파싱"
+	stageCharset     = "문자셋 디코딩"
+	stageSeenDB      = "중복 제거 DB"
+	stageHTML        = "HTML 파일 생성"
+	stageJSONFile    = "JSON 파일 생성"
+	stageRename      = "파일 재명명"
+	stageRenameTo    = "파일 복사 재명명"
+)
+
+// errEmptyFile은 0바이트 파일을 나타냅니다.
+var errEmptyFile = errors.New("빈 파일 (0바이트)")
+
+// errNoHeaders는 헤더 필드가 하나도 없는 파일(메일이 아닌 파일)을 나타냅니다.
+var errNoHeaders = errors.New("메일 헤더가 없습니다")
+
+// errCharsetFallback은 알 수 없는 문자셋이 있어 일부를 대체 문자셋으로 읽었음을 나타냅니다.
+var errCharsetFallback = errors.New("알 수 없는 문자셋이 있어 일부를 대체 문자셋으로 읽음 (CharsetFallback)")
+
+// 파일 처리 오류의 종류. withStage로 단계를 붙인 오류는 errors.Is로 종류를 구분할 수 있습니다.
+// 예: errors.Is(err, ErrOpen)이면 파일이 없거나 읽을 수 없는 경우
+var (
+	ErrOpen    = errors.New("파일 열기 오류")
+	ErrParse   = errors.New("메일 파싱 오류")
+	ErrCharset = errors.New("문자셋 오류")
+)
+
+// 오류 보고서의 분류 열 값
+const (
+	categoryOpen    = "open"
+	categoryParse   = "parse"
+	categoryCharset = "charset"
+	categoryOther   = "other"
+)
+
+// stageError는 파일 처리 오류에 실패한 처리 단계와 오류 종류(ErrOpen 등)를 붙입니다.
+type stageError struct {
+	stage string
+	kind  error
+	err   error
+}
+
+func (e *stageError) Error() string { return e.err.Error() }
+func (e *stageError) Unwrap() error { return e.err }
+
+// Is는 오류 종류로 비교할 수 있게 합니다 (errors.Is(err, ErrParse) 등).
+func (e *stageError) Is(target error) bool { return e.kind != nil && target == e.kind }
+
+func withStage(stage string, err error) error {
+	return &stageError{stage: stage, kind: errorKind(stage, err), err: err}
+}
+
+// errorKind는 단계와 원인으로 오류 종류를 정합니다. 알 수 없는 문자셋으로 파싱에 실패한 경우는 ErrCharset입니다.
+func errorKind(stage string, err error) error {
+	switch stage {
+	case stageOpen:
+		return ErrOpen
+	case stageCharset:
+		return ErrCharset
+	case stageHeaderParse, stageBodyParse:
+		if message.IsUnknownCharset(err) {
+			return ErrCharset
+		}
+		return ErrParse
+	}
+	return nil
+}
+
+// charsetWarning은 알 수 없는 문자셋을 대체 문자셋으로 읽은 레코드이면 ErrCharset 종류의 오류를, 아니면 nil을 반환합니다.
+// 레코드는 만들어지므로 처리 실패가 아닌 경고로 오류 보고서에 기록합니다.
+func charsetWarning(rec EmailRecord) error {
+	if !rec.CharsetFallback {
+		return nil
+	}
+	return withStage(stageCharset, errCharsetFallback)
+}
+
+// errorCategory는 오류 보고서에 기록할 분류(open, parse, charset, other)를 반환합니다.
+func errorCategory(err error) string {
+	switch {
+	case errors.Is(err, ErrOpen):
+		return categoryOpen
+	case errors.Is(err, ErrCharset):
+		return categoryCharset
+	case errors.Is(err, ErrParse):
+		return categoryParse
+	}
+	return categoryOther
+}
+
+// fileFailure는 오류 보고서의 한 행으로, 실패한 파일과 원인을 담습니다.
+type fileFailure struct {
+	File     string `json:"file"`
+	Stage    string `json:"stage"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+// newFileFailure는 실패 항목을 만듭니다. err에 단계가 붙어 있으면(withStage) stage 대신 그 단계를 사용합니다.
+func newFileFailure(path, stage string, err error) fileFailure {
+	var se *stageError
+	if errors.As(err, &se) {
+		stage = se.stage
+	}
+	return fileFailure{File: path, Stage: stage, Category: errorCategory(err), Error: err.Error()}
+}
+
+// writeErrorReport는 실패 목록을 path에 저장합니다.
+// 확장자가 .json이면 JSON 배열로, 그 외에는 CSV로 저장합니다.
+func writeErrorReport(path string, failures []fileFailure) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if failures == nil {
+			failures = []fileFailure{}
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(failures); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"파일", "단계", "분류", "오류"})
+	for _, fl := range failures {
+		w.Write([]string{fl.File, fl.Stage, fl.Category, fl.Error})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 빈 파일은 "empty file" 오류로, 헤더만 있거나 본문이 잘린 파일은 읽은 만큼 레코드로 처리해야 함
+func TestEmptyAndTruncatedFiles(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantErr     error
+		wantQuality string
+		wantSubject string
+		wantURLs    string
+	}{
+		{fixture: "empty.eml", wantErr: errEmptyFile},
+		{fixture: "headers-only.eml", wantQuality: parseQualityFull, wantSubject: "Only headers"},
+		{
+			fixture:     "truncated-base64.eml",
+			wantQuality: parseQualityDegraded, wantSubject: "계정 확인 안내",
+			wantURLs: "https://example.com/login?user=1&next=%2Fhome",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrOpen) {
+					t.Fatalf("err = %v, want %v (ErrOpen)", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.ParseQuality != tt.wantQuality || rec.Subject != tt.wantSubject || rec.URLs != tt.wantURLs {
+				t.Errorf("ParseQuality, Subject, URLs = %q, %q, %q, want %q, %q, %q",
+					rec.ParseQuality, rec.Subject, rec.URLs, tt.wantQuality, tt.wantSubject, tt.wantURLs)
+			}
+		})
+	}
+}
+
+// 실패 항목에는 파일 경로와 실패 단계가 붙어야 함
+func TestFailuresCarryPathAndStage(t *testing.T) {
+	files := []collectedFile{
+		{root: "testdata", path: filepath.Join("testdata", "empty.eml")},
+		{root: "testdata", path: filepath.Join("testdata", "missing.eml")},
+		{root: "testdata", path: filepath.Join("testdata", "headers-only.eml")},
+	}
+	summary, err := processFilesConcurrently(files, processOptions{workerCount: 2, ordered: true}, &recordingWriter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.succeeded != 1 || summary.failed != 2 {
+		t.Fatalf("성공 %d, 실패 %d, want 1, 2", summary.succeeded, summary.failed)
+	}
+	if len(summary.failures) != 2 {
+		t.Fatalf("실패 항목 %d개, want 2개", len(summary.failures))
+	}
+	want := map[string]string{files[0].path: errEmptyFile.Error(), files[1].path: "no such file"}
+	for _, f := range summary.failures {
+		msg, ok := want[f.File]
+		if !ok {
+			t.Errorf("예상하지 못한 실패 항목: %+v", f)
+			continue
+		}
+		if f.Stage != stageOpen || f.Category != categoryOpen || !strings.Contains(f.Error, msg) {
+			t.Errorf("%s: 단계 %q, 분류 %q, 오류 %q, want %q, %q, %q 포함", f.File, f.Stage, f.Category, f.Error, stageOpen, categoryOpen, msg)
+		}
+	}
+}
+
+// 실제 입력에서 오류 종류(ErrOpen, ErrParse, ErrCharset)를 errors.Is로 구분할 수 있어야 함
+func TestErrorKindsFromRealInput(t *testing.T) {
+	kinds := []error{ErrOpen, ErrParse, ErrCharset}
+	tests := []struct {
+		name string
+		err  func(t *testing.T) error
+		want error
+	}{
+		{"없는 파일", func(t *testing.T) error {
+			_, _, err := processEmlFile(filepath.Join("testdata", "missing.eml"), parseOptions{})
+			return err
+		}, ErrOpen},
+		{"빈 파일", func(t *testing.T) error {
+			_, _, err := processEmlFile(filepath.Join("testdata", "empty.eml"), parseOptions{})
+			return err
+		}, ErrOpen},
+		{"메일이 아닌 텍스트", func(t *testing.T) error {
+			_, _, err := processEmlFile(writeEml(t, "hello, this is not a mail\nsecond line\n"), parseOptions{})
+			return err
+		}, ErrParse},
+		{"PNG 파일", func(t *testing.T) error {
+			_, _, err := processEmlFile(writeEml(t, "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00"), parseOptions{})
+			return err
+		}, ErrParse},
+		{"헤더 없이 본문만", func(t *testing.T) error {
+			_, _, err := processEmlFile(writeEml(t, "\nbody only\n"), parseOptions{})
+			return err
+		}, ErrParse},
+		{"알 수 없는 문자셋", func(t *testing.T) error {
+			rec, _, err := processEmlFile(filepath.Join("testdata", "unknown-charset.eml"), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return charsetWarning(rec)
+		}, ErrCharset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err(t)
+			if err == nil {
+				t.Fatal("오류 없음")
+			}
+			for _, kind := range kinds {
+				if got := errors.Is(err, kind); got != (kind == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, kind, got)
+				}
+			}
+		})
+	}
+
+	// 문자셋 대체가 없는 메일은 경고가 없어야 함
+	rec, _, err := processEmlFile(filepath.Join("testdata", "cp949.eml"), parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := charsetWarning(rec); err != nil {
+		t.Errorf("cp949.eml: 경고 = %v, want 없음", err)
+	}
+}
+
+// 문자셋을 대체해 읽은 메일은 레코드를 만들고 오류 보고서에는 charset 경고로 기록해야 함
+func TestCharsetFallbackReportedAsWarning(t *testing.T) {
+	files := []collectedFile{
+		{root: "testdata", path: filepath.Join("testdata", "unknown-charset.eml")},
+		{root: "testdata", path: writeEml(t, "plain text, no headers\n")},
+	}
+	w := &recordingWriter{}
+	summary, err := processFilesConcurrently(files, processOptions{workerCount: 1, ordered: true}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.succeeded != 1 || summary.failed != 1 || len(w.written) != 1 {
+		t.Fatalf("성공 %d, 실패 %d, 기록 %d, want 1, 1, 1", summary.succeeded, summary.failed, len(w.written))
+	}
+	want := map[string][2]string{
+		files[0].path: {stageCharset, categoryCharset},
+		files[1].path: {stageHeaderParse, categoryParse},
+	}
+	if len(summary.failures) != len(want) {
+		t.Fatalf("보고 항목 %d개, want %d개: %+v", len(summary.failures), len(want), summary.failures)
+	}
+	for _, f := range summary.failures {
+		if w, ok := want[f.File]; !ok || f.Stage != w[0] || f.Category != w[1] {
+			t.Errorf("%s: 단계 %q, 분류 %q, want %q", f.File, f.Stage, f.Category, w)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-message"
+	messageMail "github.com/emersion/go-message/mail"
+)
+
+// 파싱 품질 (ParseQuality 필드 값)
+const (
+	parseQualityFull     = "full"     // 정상 파싱
+	parseQualityDegraded = "degraded" // 헤더 보정 후 파싱했거나 MIME 트리 일부만 읽음
+	parseQualityRaw      = "raw"      // 헤더만 직접 읽고 본문은 정규식으로 URL 추출
+)
+
+// isHeaderFieldName은 s가 RFC 5322 헤더 이름(출력 가능한 ASCII, 콜론 제외)인지 확인합니다.
+func isHeaderFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' || s[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// splitHeaderLine은 "Key: value" 형식의 헤더 줄을 나눕니다.
+func splitHeaderLine(line string) (key, value string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	key = strings.TrimRight(line[:i], " \t")
+	if !isHeaderFieldName(key) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+// maxHeaderFieldBytes는 헤더 필드 하나(접힌 줄 포함)에 허용하는 최대 크기입니다.
+// 수 MB짜리 DKIM 서명이나 깨진 접힘 헤더가 go-message의 헤더 크기 제한(1MB)에 걸려
+// 파일 전체가 raw 파싱으로 떨어지지 않도록, 이보다 긴 필드는 잘라냅니다.
+const maxHeaderFieldBytes = 256 << 10
+
+// newCappedHeaderReader는 헤더 블록에서 maxHeaderFieldBytes를 넘는 필드를 잘라낸 reader를 반환합니다.
+// 잘라낸 필드 이름은 truncated에 추가하며, 본문은 그대로 전달합니다.
+func newCappedHeaderReader(r io.Reader, truncated *[]string) io.Reader {
+	br := bufio.NewReader(r)
+	var header bytes.Buffer
+	var key string
+	var fieldLen int
+	var cut bool
+	for {
+		line, err := br.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case trimmed == "":
+			// 헤더 끝 (빈 줄 또는 입력 끝)
+			header.WriteString(line)
+			return io.MultiReader(&header, br)
+		case trimmed[0] == ' ' || trimmed[0] == '\t':
+			if cut {
+				break
+			}
+			if fieldLen += len(trimmed); fieldLen > maxHeaderFieldBytes {
+				cut = true
+				*truncated = append(*truncated, key)
+				break
+			}
+			header.WriteString(trimmed + "\r\n")
+		default:
+			key, _, _ = strings.Cut(trimmed, ":")
+			fieldLen = len(trimmed)
+			cut = fieldLen > maxHeaderFieldBytes
+			if cut {
+				trimmed = trimmed[:maxHeaderFieldBytes]
+				*truncated = append(*truncated, key)
+			}
+			header.WriteString(trimmed + "\r\n")
+		}
+		if err != nil {
+			return io.MultiReader(&header, br)
+		}
+	}
+}
+
+// newLenientHeaderReader는 헤더 블록을 보정한 reader를 반환합니다.
+//   - 헤더 이름에 8비트 문자 등이 있는 줄은 버립니다.
+//   - 헤더 뒤에 빈 줄이 없으면 헤더 형식이 아닌 첫 줄 앞에 빈 줄을 넣어 본문으로 취급합니다.
+func newLenientHeaderReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	var header bytes.Buffer
+	for {
+		line, err := br.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case trimmed == "":
+			// 정상적인 헤더 끝
+			header.WriteString("\r\n")
+			return io.MultiReader(&header, br)
+		case trimmed[0] == ' ' || trimmed[0] == '\t':
+			if header.Len() > 0 {
+				header.WriteString(trimmed + "\r\n")
+			}
+		default:
+			if _, _, ok := splitHeaderLine(trimmed); ok {
+				header.WriteString(trimmed + "\r\n")
+			} else if i := strings.Index(trimmed, ":"); i > 0 && !strings.ContainsAny(trimmed[:i], " \t") {
+				// 헤더 이름이 잘못된 줄은 버림
+			} else {
+				// 헤더 뒤 빈 줄이 빠진 경우: 이 줄부터 본문
+				header.WriteString("\r\n" + line)
+				return io.MultiReader(&header, br)
+			}
+		}
+		if err != nil {
+			header.WriteString("\r\n")
+			return io.MultiReader(&header, br)
+		}
+	}
+}
+
+// scanRawMessage는 go-message 없이 헤더를 직접 읽습니다.
+// 첫 빈 줄에서 헤더와 본문을 나누고, 접힌 줄을 합친 뒤 "Key: value" 줄만 헤더로 사용합니다.
+// 값의 RFC 2047 디코딩은 mail.Header의 Subject()/AddressList()가 수행합니다.
+func scanRawMessage(r io.Reader) (messageMail.Header, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return messageMail.Header{}, "", err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	headerPart, body := text, ""
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		headerPart, body = text[:i], text[i+2:]
+	}
+
+	var h message.Header
+	var key string
+	var value strings.Builder
+	flush := func() {
+		if key != "" {
+			h.Add(key, value.String())
+		}
+		key = ""
+		value.Reset()
+	}
+	for _, line := range strings.Split(headerPart, "\n") {
+		if line != "" && (line[0] == ' ' || line[0] == '\t') {
+			// 매우 긴 접힘 헤더도 선형 시간에 합치고, 최대 크기를 넘는 부분은 버림
+			if key != "" && value.Len() < maxHeaderFieldBytes {
+				value.WriteString(" ")
+				value.WriteString(strings.TrimSpace(line))
+			}
+			continue
+		}
+		flush()
+		if k, v, ok := splitHeaderLine(line); ok {
+			key = k
+			value.WriteString(v)
+		}
+	}
+	flush()
+	return messageMail.Header{Header: h}, body, nil
+}
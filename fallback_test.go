@@ -0,0 +1,100 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gunzipFixture는 testdata의 .gz fixture를 임시 디렉토리에 풀어 경로를 반환합니다.
+// 5MB 헤더처럼 큰 fixture는 압축해 저장소 크기를 줄입니다.
+func gunzipFixture(t *testing.T, name string) string {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), strings.TrimSuffix(name, ".gz"))
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, zr); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 5MB짜리 헤더 줄이 있어도 파일을 건너뛰지 않고 나머지 헤더와 본문을 정상 처리해야 함
+func TestFiveMegabyteHeaderLine(t *testing.T) {
+	path := gunzipFixture(t, "long-header.eml.gz")
+	if info, err := os.Stat(path); err != nil || info.Size() < 5<<20 {
+		t.Fatalf("fixture 크기가 5MB보다 작음: %v", err)
+	}
+	rec, _, err := processEmlFile(path, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ParseQuality != parseQualityFull {
+		t.Errorf("ParseQuality = %q, want %q", rec.ParseQuality, parseQualityFull)
+	}
+	if rec.Subject != "Message with a 5MB header line" || rec.FromEmail != "big@example.com" {
+		t.Errorf("Subject, FromEmail = %q, %q", rec.Subject, rec.FromEmail)
+	}
+	if rec.URLs != "https://example.com/after-long-header" {
+		t.Errorf("URLs = %q", rec.URLs)
+	}
+}
+
+func TestCappedHeaderReader(t *testing.T) {
+	long := strings.Repeat("a", maxHeaderFieldBytes+1)
+	fold := strings.Repeat(" "+strings.Repeat("b", 1000)+"\r\n", maxHeaderFieldBytes/1000+1)
+	tests := []struct {
+		name          string
+		input         string
+		wantTruncated []string
+		wantKept      []string // 결과에 남아야 하는 줄
+	}{
+		{"short headers", "From: a@example.com\r\nSubject: hi\r\n\r\nbody\r\n", nil, []string{"Subject: hi", "body"}},
+		{"single long line", "X-Long: " + long + "\r\nSubject: hi\r\n\r\nbody\r\n", []string{"X-Long"}, []string{"Subject: hi", "body"}},
+		{"long folded field", "DKIM-Signature: v=1;\r\n" + fold + "Subject: hi\r\n\r\nbody\r\n", []string{"DKIM-Signature"}, []string{"Subject: hi", "body"}},
+		{"long body line", "Subject: hi\r\n\r\n" + long + "\r\n", nil, []string{"Subject: hi", long}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var truncated []string
+			out, err := io.ReadAll(newCappedHeaderReader(strings.NewReader(tt.input), &truncated))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalStrings(truncated, tt.wantTruncated) {
+				t.Errorf("잘라낸 필드 = %q, want %q", truncated, tt.wantTruncated)
+			}
+			for _, line := range tt.wantKept {
+				if !strings.Contains(string(out), line) {
+					t.Errorf("결과에 %.40q가 없음", line)
+				}
+			}
+			// 헤더 블록의 각 필드(접힌 줄 포함)는 maxHeaderFieldBytes 이하여야 함
+			header, _, _ := strings.Cut(string(out), "\r\n\r\n")
+			var field int
+			for _, line := range strings.Split(header, "\r\n") {
+				if line != "" && line[0] != ' ' && line[0] != '\t' {
+					field = 0
+				}
+				if field += len(line); field > maxHeaderFieldBytes {
+					t.Fatalf("%d바이트를 넘는 헤더 필드가 남음", maxHeaderFieldBytes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// recordFilter는 레코드를 결과에 포함할지 판단합니다. name은 필터를 만든 옵션 이름이며,
+// rejected는 이 필터 때문에 제외된 레코드 수입니다 (처리 요약에 출력).
+type recordFilter struct {
+	name     string
+	keep     func(r *EmailRecord) bool
+	rejected *atomic.Int64
+}
+
+func newRecordFilter(name string, keep func(r *EmailRecord) bool) recordFilter {
+	return recordFilter{name: name, keep: keep, rejected: new(atomic.Int64)}
+}
+
+// recordFilters는 모든 필터를 통과(AND)한 레코드만 포함합니다.
+// 제외된 레코드는 처음 통과하지 못한 필터 하나에만 셉니다.
+type recordFilters []recordFilter
+
+func (fs recordFilters) match(r *EmailRecord) bool {
+	for _, f := range fs {
+		if !f.keep(r) {
+			f.rejected.Add(1)
+			return false
+		}
+	}
+	return true
+}
+
+// rejectedSummary는 필터별 제외 수를 "-has-urls 3, -from 1" 형식으로 반환합니다.
+func (fs recordFilters) rejectedSummary() string {
+	parts := make([]string, 0, len(fs))
+	for _, f := range fs {
+		parts = append(parts, fmt.Sprintf("%s %d", f.name, f.rejected.Load()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// structureFilters는 MIME 구조 기반 필터(-has-attachment, -has-html, -has-text)와
+// URL 유무 필터(-has-urls)를 구성합니다. -has-urls는 -url-domain-deny로 URL을 제거한 뒤의 목록에 적용합니다.
+func structureFilters(hasAttachment, hasHTML, hasText, hasURLs bool) recordFilters {
+	var fs recordFilters
+	if hasAttachment {
+		fs = append(fs, newRecordFilter("-has-attachment", func(r *EmailRecord) bool { return r.AttachmentCount > 0 }))
+	}
+	if hasHTML {
+		fs = append(fs, newRecordFilter("-has-html", func(r *EmailRecord) bool { return r.HasHTML }))
+	}
+	if hasText {
+		fs = append(fs, newRecordFilter("-has-text", func(r *EmailRecord) bool { return r.HasText }))
+	}
+	if hasURLs {
+		fs = append(fs, newRecordFilter("-has-urls", func(r *EmailRecord) bool { return r.URLs != "" }))
+	}
+	return fs
+}
+
+// attachmentFilters는 첨부 필터(-attachment-match, -attachment-type)를 구성합니다. 헤더 정보(파일명, 선언된 MIME 형식)만 쓰므로
+// -hash-attachments 없이 동작합니다. -attachment-match는 디코딩한 파일명에 대한 정규식(대소문자 무시),
+// -attachment-type은 "application/x-msdownload"처럼 정확한 형식이나 "image/*" 같은 주 형식이며, 각각 여러 개면 하나라도 맞으면 됩니다.
+func attachmentFilters(nameExprs, types []string) (recordFilters, error) {
+	var fs recordFilters
+	if len(nameExprs) > 0 {
+		var res []*regexp.Regexp
+		for _, expr := range nameExprs {
+			re, err := compileFilterRegex(expr, false)
+			if err != nil {
+				return nil, fmt.Errorf("-attachment-match %q: %w", expr, err)
+			}
+			res = append(res, re)
+		}
+		fs = append(fs, newRecordFilter("-attachment-match", func(r *EmailRecord) bool {
+			for _, name := range strings.Split(r.AttachmentNames, "\n") {
+				for _, re := range res {
+					if name != "" && re.MatchString(name) {
+						return true
+					}
+				}
+			}
+			return false
+		}))
+	}
+	if len(types) > 0 {
+		var want []string
+		for _, t := range types {
+			want = append(want, strings.ToLower(strings.TrimSpace(t)))
+		}
+		fs = append(fs, newRecordFilter("-attachment-type", func(r *EmailRecord) bool {
+			for _, t := range strings.Split(r.AttachmentTypes, "\n") {
+				for _, w := range want {
+					if t != "" && (t == w || (strings.HasSuffix(w, "/*") && strings.HasPrefix(t, strings.TrimSuffix(w, "*")))) {
+						return true
+					}
+				}
+			}
+			return false
+		}))
+	}
+	return fs, nil
+}
+
+// urlCountFilters는 서로 다른 URL 수 필터(-min-urls, -max-urls)를 구성합니다. max가 음수이면 상한이 없습니다.
+func urlCountFilters(min, max int) recordFilters {
+	var fs recordFilters
+	if min > 0 {
+		fs = append(fs, newRecordFilter("-min-urls", func(r *EmailRecord) bool { return r.URLCount >= min }))
+	}
+	if max >= 0 {
+		fs = append(fs, newRecordFilter("-max-urls", func(r *EmailRecord) bool { return r.URLCount <= max }))
+	}
+	return fs
+}
+
+// textMatcher는 부분 문자열 또는 "~"로 시작하는 정규식 패턴입니다. 대소문자를 구분하지 않습니다.
+type textMatcher func(s string) bool
+
+// newTextMatcher는 패턴을 textMatcher로 만듭니다. "~정규식"이면 정규식으로, 그 외에는 부분 문자열로 비교합니다.
+func newTextMatcher(pattern string) (textMatcher, error) {
+	if expr, ok := strings.CutPrefix(pattern, "~"); ok {
+		re, err := compileFilterRegex(expr, false)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	needle := strings.ToLower(pattern)
+	return func(s string) bool { return strings.Contains(strings.ToLower(s), needle) }, nil
+}
+
+// newTextMatchers는 여러 패턴을 textMatcher 목록으로 만듭니다.
+func newTextMatchers(patterns []string) ([]textMatcher, error) {
+	matchers := make([]textMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		m, err := newTextMatcher(p)
+		if err != nil {
+			return nil, fmt.Errorf("잘못된 패턴 %q: %w", p, err)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// matchAny는 values 중 하나라도 matchers 중 하나에 맞는지 확인합니다.
+func matchAny(matchers []textMatcher, values ...string) bool {
+	for _, m := range matchers {
+		for _, v := range values {
+			if v != "" && m(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileFilterRegex는 필터용 정규식(RE2)을 컴파일합니다. caseSensitive가 아니면 대소문자를 무시합니다.
+// 문법 오류는 패턴에서 문제가 된 부분과 그 위치(글자 단위, 1부터)를 함께 알려 줍니다.
+func compileFilterRegex(expr string, caseSensitive bool) (*regexp.Regexp, error) {
+	flags := "(?i)"
+	if caseSensitive {
+		flags = ""
+	}
+	re, err := regexp.Compile(flags + expr)
+	if err == nil {
+		return re, nil
+	}
+	var se *syntax.Error
+	if errors.As(err, &se) {
+		fragment := strings.TrimPrefix(se.Expr, flags)
+		if pos := regexErrorPos(expr, flags, se.Code, fragment); pos > 0 {
+			return nil, fmt.Errorf("%d번째 글자: %s: `%s`", pos, se.Code, fragment)
+		}
+		return nil, fmt.Errorf("%s: `%s`", se.Code, fragment)
+	}
+	return nil, err
+}
+
+// regexErrorPos는 문법 오류가 난 부분(fragment)이 패턴의 몇 번째 글자에서 시작하는지 찾습니다. 찾지 못하면 0입니다.
+// 파서 오류에는 위치가 없고 같은 부분이 패턴에 여러 번 나올 수 있으므로, 그 부분까지 자른 패턴이
+// 같은 오류를 내는 첫 위치를 고릅니다 (파서는 앞에서부터 읽어 처음 만난 오류를 알림).
+func regexErrorPos(expr, flags string, code syntax.ErrorCode, fragment string) int {
+	if fragment == "" {
+		return 0
+	}
+	for from := 0; ; {
+		i := strings.Index(expr[from:], fragment)
+		if i < 0 {
+			return 0
+		}
+		start := from + i
+		_, err := syntax.Parse(flags+expr[:start+len(fragment)], syntax.Perl)
+		var se *syntax.Error
+		if errors.As(err, &se) && se.Code == code && strings.TrimPrefix(se.Expr, flags) == fragment {
+			return utf8.RuneCountInString(expr[:start]) + 1
+		}
+		from = start + 1
+	}
+}
+
+// subjectFilters는 제목 정규식 필터(-subject-match, -subject-not-match)를 구성합니다.
+// RFC 2047 디코딩과 제어 문자 정리를 마친 Subject에 적용합니다.
+func subjectFilters(match, notMatch string, caseSensitive bool) (recordFilters, error) {
+	var fs recordFilters
+	if match != "" {
+		re, err := compileFilterRegex(match, caseSensitive)
+		if err != nil {
+			return nil, fmt.Errorf("-subject-match: %w", err)
+		}
+		fs = append(fs, newRecordFilter("-subject-match", func(r *EmailRecord) bool { return re.MatchString(r.Subject) }))
+	}
+	if notMatch != "" {
+		re, err := compileFilterRegex(notMatch, caseSensitive)
+		if err != nil {
+			return nil, fmt.Errorf("-subject-not-match: %w", err)
+		}
+		fs = append(fs, newRecordFilter("-subject-not-match", func(r *EmailRecord) bool { return !re.MatchString(r.Subject) }))
+	}
+	return fs, nil
+}
+
+// senderFilters는 보낸 사람 필터(-from, -not-from)를 구성합니다.
+// 주소와 표시 이름을 각각 비교하며, -from은 하나라도 맞으면(OR) 포함하고 -not-from은 하나라도 맞으면 제외합니다.
+func senderFilters(from, notFrom []string) (recordFilters, error) {
+	include, err := newTextMatchers(from)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newTextMatchers(notFrom)
+	if err != nil {
+		return nil, err
+	}
+	var fs recordFilters
+	if len(include) > 0 {
+		fs = append(fs, newRecordFilter("-from", func(r *EmailRecord) bool { return matchAny(include, senderValues(r)...) }))
+	}
+	if len(exclude) > 0 {
+		fs = append(fs, newRecordFilter("-not-from", func(r *EmailRecord) bool { return !matchAny(exclude, senderValues(r)...) }))
+	}
+	return fs, nil
+}
+
+// senderValues는 보낸 사람 필터가 비교할 주소와 이름 목록입니다. 여러 주소는 줄바꿈으로 나눠 각각 비교합니다.
+func senderValues(r *EmailRecord) []string {
+	values := strings.Split(r.FromEmail, "\n")
+	return append(values, strings.Split(r.FromName, "\n")...)
+}
+
+// recipientFilters는 받는 사람 필터(-to, -not-to)를 구성합니다. To, Cc, Delivered-To의 주소와 표시 이름을 각각 비교하며,
+// -to는 하나라도 맞으면(OR) 포함하고 -not-to는 하나라도 맞으면 제외합니다.
+func recipientFilters(to, notTo []string) (recordFilters, error) {
+	include, err := newTextMatchers(to)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newTextMatchers(notTo)
+	if err != nil {
+		return nil, err
+	}
+	var fs recordFilters
+	if len(include) > 0 {
+		fs = append(fs, newRecordFilter("-to", func(r *EmailRecord) bool { return matchAny(include, recipientValues(r)...) }))
+	}
+	if len(exclude) > 0 {
+		fs = append(fs, newRecordFilter("-not-to", func(r *EmailRecord) bool { return !matchAny(exclude, recipientValues(r)...) }))
+	}
+	return fs, nil
+}
+
+// recipientValues는 받는 사람 필터가 비교할 주소와 이름 목록입니다.
+func recipientValues(r *EmailRecord) []string {
+	var values []string
+	for _, s := range []string{r.ToEmail, r.ToName, r.CcEmail, r.CcName, r.DeliveredTo} {
+		values = append(values, strings.Split(s, "\n")...)
+	}
+	return values
+}
+
+// 언어를 알 수 없는 메일의 처리 방식 (-lang-unknown-policy)
+const (
+	langUnknownInclude = "include"
+	langUnknownExclude = "exclude"
+)
+
+// languageFilters는 언어 필터(-lang, -lang-not)를 구성합니다. 언어는 Content-Language 헤더에 선언된 언어 태그이며,
+// "ko"는 "ko"와 "ko-KR"에, "en-US"는 "en-US"에만 맞습니다 (RFC 4647 기본 필터링, 대소문자 무시).
+// 선언된 언어가 없는 메일은 includeUnknown에 따라 두 필터 모두 통과하거나 제외됩니다.
+func languageFilters(langs, notLangs []string, includeUnknown bool) recordFilters {
+	var fs recordFilters
+	if len(langs) > 0 {
+		fs = append(fs, newRecordFilter("-lang", func(r *EmailRecord) bool {
+			if r.ContentLanguage == "" {
+				return includeUnknown
+			}
+			return matchLanguage(langs, r.ContentLanguage)
+		}))
+	}
+	if len(notLangs) > 0 {
+		fs = append(fs, newRecordFilter("-lang-not", func(r *EmailRecord) bool {
+			if r.ContentLanguage == "" {
+				return includeUnknown
+			}
+			return !matchLanguage(notLangs, r.ContentLanguage)
+		}))
+	}
+	return fs
+}
+
+// matchLanguage는 줄바꿈으로 나뉜 언어 태그 중 하나라도 목록의 언어 범위에 맞는지 확인합니다.
+func matchLanguage(ranges []string, tags string) bool {
+	for _, tag := range strings.Split(strings.ToLower(tags), "\n") {
+		for _, r := range ranges {
+			if tag == r || strings.HasPrefix(tag, r+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseLanguageList는 "ko,en" 같은 쉼표 목록을 소문자 언어 범위 목록으로 바꿉니다.
+func parseLanguageList(s string) []string {
+	var langs []string
+	for _, l := range strings.Split(s, ",") {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			langs = appendUnique(langs, l)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestCompileFilterRegexError(t *testing.T) {
+	tests := []struct {
+		expr          string
+		caseSensitive bool
+		want          string
+	}{
+		// (?i) 접두사는 위치와 패턴에 섞이지 않아야 함
+		{"invoice(", false, "1번째 글자: missing closing ): `invoice(`"},
+		{"invoice(", true, "1번째 글자: missing closing ): `invoice(`"},
+		{"[a-", false, "1번째 글자: missing closing ]: `[a-`"},
+		{"urgent|[a-", false, "8번째 글자: missing closing ]: `[a-`"},
+		{"*urgent", false, "1번째 글자: missing argument to repetition operator: `*`"},
+		// 같은 부분이 앞에 정상으로 나와도 오류가 난 위치를 알림
+		{"a*b|*c", false, "5번째 글자: missing argument to repetition operator: `*`"},
+		{"청구서|**", true, "5번째 글자: missing argument to repetition operator: `*`"},
+		{`a\qb`, false, "2번째 글자: invalid escape sequence: `\\q`"},
+		{"x{2,1}", false, "2번째 글자: invalid repeat count: `{2,1}`"},
+		{"[z-a]", false, "2번째 글자: invalid character class range: `z-a`"},
+	}
+	for _, tt := range tests {
+		_, err := compileFilterRegex(tt.expr, tt.caseSensitive)
+		if err == nil {
+			t.Errorf("compileFilterRegex(%q) 오류 없음", tt.expr)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("compileFilterRegex(%q) = %q, want %q", tt.expr, err.Error(), tt.want)
+		}
+	}
+}
+
+func TestCompileFilterRegexCase(t *testing.T) {
+	re, err := compileFilterRegex("invoice", false)
+	if err != nil || !re.MatchString("INVOICE #12") {
+		t.Errorf("대소문자 구분 없는 정규식이 맞지 않음: %v", err)
+	}
+	re, err = compileFilterRegex("invoice", true)
+	if err != nil || re.MatchString("INVOICE #12") {
+		t.Errorf("대소문자 구분 정규식이 대문자에 맞음: %v", err)
+	}
+}
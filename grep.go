@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxGrepSnippets는 메일 하나의 Matches 열에 기록하는 일치 부분의 최대 개수입니다 (MatchCount는 모두 셈).
+const maxGrepSnippets = 20
+
+// bodyGrep은 디코딩한 본문(문자셋·전송 인코딩 해제 후의 텍스트와 태그를 뺀 HTML 텍스트)에서 정규식을 찾습니다 (-grep).
+// 여러 패턴은 하나라도 맞으면(OR) 일치로 봅니다.
+type bodyGrep struct {
+	patterns []*regexp.Regexp
+	context  int
+}
+
+func newBodyGrep(patterns []string, ignoreCase bool, context int) (*bodyGrep, error) {
+	g := &bodyGrep{context: context}
+	for _, p := range patterns {
+		re, err := compileFilterRegex(p, !ignoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("-grep %q: %w", p, err)
+		}
+		g.patterns = append(g.patterns, re)
+	}
+	return g, nil
+}
+
+// search는 본문들에서 일치 부분을 앞뒤 context 글자와 함께 잘라 중복 없이 반환하고, 일치 수를 반환합니다.
+// 텍스트/HTML 대체 본문처럼 같은 내용이 여러 본문에 있으면 가장 많이 일치한 본문의 수를 사용합니다.
+func (g *bodyGrep) search(bodies ...string) (snippets []string, count int) {
+	for _, body := range bodies {
+		n := 0
+		for _, re := range g.patterns {
+			for _, m := range re.FindAllStringIndex(body, -1) {
+				n++
+				if len(snippets) < maxGrepSnippets {
+					snippets = appendUnique(snippets, g.snippet(body, m[0], m[1]))
+				}
+			}
+		}
+		count = max(count, n)
+	}
+	return snippets, count
+}
+
+// snippet은 body[start:end] 앞뒤로 context 글자를 붙이고 공백을 한 칸으로 줄입니다. 잘린 쪽에는 "…"를 붙입니다.
+func (g *bodyGrep) snippet(body string, start, end int) string {
+	from := start
+	for i := 0; i < g.context && from > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(body[:from])
+		from -= size
+	}
+	to := end
+	for i := 0; i < g.context && to < len(body); i++ {
+		_, size := utf8.DecodeRuneInString(body[to:])
+		to += size
+	}
+	s := strings.Join(strings.Fields(body[from:to]), " ")
+	if from > 0 {
+		s = "…" + s
+	}
+	if to < len(body) {
+		s += "…"
+	}
+	return s
+}
+
+// grepFilters는 본문이 -grep 패턴에 맞는 메일만 남기는 필터를 구성합니다.
+func grepFilters(g *bodyGrep) recordFilters {
+	if g == nil {
+		return nil
+	}
+	return recordFilters{newRecordFilter("-grep", func(r *EmailRecord) bool { return r.MatchCount > 0 })}
+}
+
+// countRecordWriter는 레코드 대신 레코드 수만 출력합니다 (-grep-count).
+type countRecordWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (c *countRecordWriter) WriteRecord(EmailRecord) error {
+	c.count++
+	return nil
+}
+
+func (c *countRecordWriter) Close() error {
+	_, err := fmt.Fprintln(c.w, c.count)
+	return err
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 집계 기준 (-group-by)
+const (
+	groupBySender       = "sender"
+	groupBySenderDomain = "sender-domain"
+	groupByURLDomain    = "url-domain"
+	groupByDate         = "date"
+)
+
+func validGroupBy(key string) bool {
+	switch key {
+	case groupBySender, groupBySenderDomain, groupByURLDomain, groupByDate:
+		return true
+	}
+	return false
+}
+
+// groupRow는 -group-by 출력의 한 행입니다.
+type groupRow struct {
+	Key        string
+	Count      int
+	FirstSeen  string
+	LastSeen   string
+	Recipients int
+	URLDomains int
+}
+
+// groupStat은 키 하나의 집계 상태입니다. 받는 사람과 URL 도메인은 개수를 세기 위해 서로 다른 값만 보관합니다.
+type groupStat struct {
+	groupRow
+	recipients map[string]struct{}
+	urlDomains map[string]struct{}
+}
+
+// groupRecordWriter는 메일별 행 대신 키(보낸 사람, 보낸 사람 도메인, URL 도메인, 날짜)별 집계 행을 출력합니다 (-group-by).
+// 메일마다 레코드를 보관하지 않고 키별 집계만 유지하므로 메모리 사용량은 서로 다른 키(와 그 받는 사람/도메인) 수에 비례합니다.
+// 닫을 때 메일 수가 많은 순(같으면 키 순)으로 format(CSV, JSON, NDJSON)에 맞게 출력합니다.
+type groupRecordWriter struct {
+	w      io.Writer
+	by     string
+	format string
+	stats  map[string]*groupStat
+}
+
+func newGroupRecordWriter(w io.Writer, by, format string) *groupRecordWriter {
+	return &groupRecordWriter{w: w, by: by, format: format, stats: make(map[string]*groupStat)}
+}
+
+// groupKeys는 레코드가 속하는 키 목록입니다. URL 도메인 기준이면 메일 하나가 여러 키에 속할 수 있습니다.
+func (g *groupRecordWriter) groupKeys(r EmailRecord) []string {
+	sender := strings.ToLower(r.PrimaryFromEmail)
+	switch g.by {
+	case groupBySender:
+		return []string{sender}
+	case groupBySenderDomain:
+		_, domain, _ := strings.Cut(sender, "@")
+		return []string{domain}
+	case groupByURLDomain:
+		if r.URLDomains == "" {
+			return nil
+		}
+		return appendUnique(nil, strings.Split(r.URLDomains, "\n")...)
+	case groupByDate:
+		date, _, _ := strings.Cut(r.SentDate, " ")
+		return []string{date}
+	}
+	return nil
+}
+
+func (g *groupRecordWriter) WriteRecord(r EmailRecord) error {
+	for _, key := range g.groupKeys(r) {
+		st, ok := g.stats[key]
+		if !ok {
+			st = &groupStat{groupRow: groupRow{Key: key}, recipients: make(map[string]struct{}), urlDomains: make(map[string]struct{})}
+			g.stats[key] = st
+		}
+		st.Count++
+		if r.SentDate != "" {
+			if st.FirstSeen == "" || r.SentDate < st.FirstSeen {
+				st.FirstSeen = r.SentDate
+			}
+			if r.SentDate > st.LastSeen {
+				st.LastSeen = r.SentDate
+			}
+		}
+		for _, addr := range strings.Split(r.ToEmail+"\n"+r.CcEmail, "\n") {
+			if addr != "" {
+				st.recipients[strings.ToLower(addr)] = struct{}{}
+			}
+		}
+		for _, d := range strings.Split(r.URLDomains, "\n") {
+			if d != "" {
+				st.urlDomains[d] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+// rows는 집계 행을 메일 수가 많은 순(같으면 키 순)으로 반환합니다.
+func (g *groupRecordWriter) rows() []groupRow {
+	rows := make([]groupRow, 0, len(g.stats))
+	for _, st := range g.stats {
+		row := st.groupRow
+		row.Recipients = len(st.recipients)
+		row.URLDomains = len(st.urlDomains)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+func (g *groupRecordWriter) Close() error {
+	rows := g.rows()
+	bw := bufio.NewWriter(g.w)
+	switch g.format {
+	case formatJSON:
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	case formatNDJSON:
+		enc := json.NewEncoder(bw)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	default:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"키", "메일 수", "처음", "마지막", "받는 사람 수", "URL 도메인 수"})
+		for _, row := range rows {
+			cw.Write([]string{row.Key, strconv.Itoa(row.Count), row.FirstSeen, row.LastSeen,
+				strconv.Itoa(row.Recipients), strconv.Itoa(row.URLDomains)})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// checkGroupByFormat은 -group-by를 사용할 수 있는 출력 형식인지 확인합니다.
+func checkGroupByFormat(format string) error {
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("-group-by는 CSV, -json, -ndjson 출력에서만 사용할 수 있습니다")
+}
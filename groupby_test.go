@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// groupInput은 -group-by 테스트에 쓰는 레코드입니다.
+var groupInput = []EmailRecord{
+	{PrimaryFromEmail: "Alice@Example.com", SentDate: "2024-03-05 10:00:00", ToEmail: "u1@corp.test\nu2@corp.test", URLDomains: "a.example\nb.example"},
+	{PrimaryFromEmail: "bob@example.com", SentDate: "2024-03-04 09:00:00", ToEmail: "U1@corp.test", CcEmail: "u3@corp.test", URLDomains: "a.example"},
+	{PrimaryFromEmail: "alice@example.com", SentDate: "2024-03-06 08:00:00", ToEmail: "u1@corp.test", URLDomains: "c.example\nc.example"},
+	{PrimaryFromEmail: "carol@other.test", SentDate: "2024-03-05 23:59:59", ToEmail: "u4@corp.test"},
+	// 날짜가 없는 메일은 처음/마지막에 반영하지 않음
+	{PrimaryFromEmail: "carol@other.test", ToEmail: "u4@corp.test"},
+}
+
+func groupRows(t *testing.T, by string) []groupRow {
+	t.Helper()
+	var buf bytes.Buffer
+	g := newGroupRecordWriter(&buf, by, formatNDJSON)
+	for _, r := range groupInput {
+		if err := g.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var rows []groupRow
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var row groupRow
+		if err := dec.Decode(&row); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestGroupByKeysAndCounts(t *testing.T) {
+	tests := []struct {
+		by   string
+		want []groupRow
+	}{
+		// 보낸 사람 주소는 대소문자를 무시하고, 메일 수가 많은 순(같으면 키 순)으로 출력
+		{groupBySender, []groupRow{
+			{Key: "alice@example.com", Count: 2, FirstSeen: "2024-03-05 10:00:00", LastSeen: "2024-03-06 08:00:00", Recipients: 2, URLDomains: 3},
+			{Key: "carol@other.test", Count: 2, FirstSeen: "2024-03-05 23:59:59", LastSeen: "2024-03-05 23:59:59", Recipients: 1, URLDomains: 0},
+			{Key: "bob@example.com", Count: 1, FirstSeen: "2024-03-04 09:00:00", LastSeen: "2024-03-04 09:00:00", Recipients: 2, URLDomains: 1},
+		}},
+		{groupBySenderDomain, []groupRow{
+			{Key: "example.com", Count: 3, FirstSeen: "2024-03-04 09:00:00", LastSeen: "2024-03-06 08:00:00", Recipients: 3, URLDomains: 3},
+			{Key: "other.test", Count: 2, FirstSeen: "2024-03-05 23:59:59", LastSeen: "2024-03-05 23:59:59", Recipients: 1, URLDomains: 0},
+		}},
+		// 메일 하나가 여러 URL 도메인에 속할 수 있고, 같은 메일의 중복 도메인은 한 번만 셈. URL이 없는 메일은 제외
+		{groupByURLDomain, []groupRow{
+			{Key: "a.example", Count: 2, FirstSeen: "2024-03-04 09:00:00", LastSeen: "2024-03-05 10:00:00", Recipients: 3, URLDomains: 2},
+			{Key: "b.example", Count: 1, FirstSeen: "2024-03-05 10:00:00", LastSeen: "2024-03-05 10:00:00", Recipients: 2, URLDomains: 2},
+			{Key: "c.example", Count: 1, FirstSeen: "2024-03-06 08:00:00", LastSeen: "2024-03-06 08:00:00", Recipients: 1, URLDomains: 1},
+		}},
+		// 날짜가 없는 메일은 빈 키로 모음
+		{groupByDate, []groupRow{
+			{Key: "2024-03-05", Count: 2, FirstSeen: "2024-03-05 10:00:00", LastSeen: "2024-03-05 23:59:59", Recipients: 3, URLDomains: 2},
+			{Key: "", Count: 1, Recipients: 1},
+			{Key: "2024-03-04", Count: 1, FirstSeen: "2024-03-04 09:00:00", LastSeen: "2024-03-04 09:00:00", Recipients: 2, URLDomains: 1},
+			{Key: "2024-03-06", Count: 1, FirstSeen: "2024-03-06 08:00:00", LastSeen: "2024-03-06 08:00:00", Recipients: 1, URLDomains: 1},
+		}},
+	}
+	for _, tt := range tests {
+		got := groupRows(t, tt.by)
+		if len(got) != len(tt.want) {
+			t.Errorf("-group-by %s: 행 %d개, want %d개: %+v", tt.by, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("-group-by %s: %d번째 행 = %+v, want %+v", tt.by, i+1, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestGroupByOutputFormats(t *testing.T) {
+	write := func(format string, records []EmailRecord) string {
+		var buf bytes.Buffer
+		g := newGroupRecordWriter(&buf, groupBySenderDomain, format)
+		for _, r := range records {
+			if err := g.WriteRecord(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(write(formatCSV, groupInput))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"키", "메일 수", "처음", "마지막", "받는 사람 수", "URL 도메인 수"},
+		{"example.com", "3", "2024-03-04 09:00:00", "2024-03-06 08:00:00", "3", "3"},
+		{"other.test", "2", "2024-03-05 23:59:59", "2024-03-05 23:59:59", "1", "0"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("CSV 행 %d개, want %d개", len(rows), len(want))
+	}
+	for i := range want {
+		if !equalStrings(rows[i], want[i]) {
+			t.Errorf("CSV %d번째 행 = %q, want %q", i+1, rows[i], want[i])
+		}
+	}
+
+	var parsed []groupRow
+	if err := json.Unmarshal([]byte(write(formatJSON, groupInput)), &parsed); err != nil || len(parsed) != 2 || parsed[0].Key != "example.com" {
+		t.Errorf("JSON 출력 = %+v (%v)", parsed, err)
+	}
+
+	// 레코드가 없어도 형식에 맞는 빈 출력
+	if got := write(formatJSON, nil); got != "[]\n" {
+		t.Errorf("빈 JSON = %q", got)
+	}
+	if got := write(formatNDJSON, nil); got != "" {
+		t.Errorf("빈 NDJSON = %q", got)
+	}
+	if got := write(formatCSV, nil); got != "키,메일 수,처음,마지막,받는 사람 수,URL 도메인 수\n" {
+		t.Errorf("빈 CSV = %q", got)
+	}
+}
+
+func TestCheckGroupByFormat(t *testing.T) {
+	for _, format := range []string{formatCSV, formatJSON, formatNDJSON} {
+		if err := checkGroupByFormat(format); err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+	}
+	for _, format := range []string{formatTable, formatURLs, formatTemplate} {
+		if err := checkGroupByFormat(format); err == nil {
+			t.Errorf("%s: 오류 없음", format)
+		}
+	}
+	for key, want := range map[string]bool{"sender": true, "sender-domain": true, "url-domain": true, "date": true, "Sender": false, "": false} {
+		if got := validGroupBy(key); got != want {
+			t.Errorf("validGroupBy(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
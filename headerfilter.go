@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	messageMail "github.com/emersion/go-message/mail"
+)
+
+// errHeaderExcluded는 헤더 필터(-has-header, -header-match)로 제외된 메일을 나타냅니다.
+var errHeaderExcluded = errors.New("헤더 필터에 맞지 않음")
+
+// headerFilter는 헤더 하나에 대한 조건입니다. re가 nil이면 헤더가 있는지만 확인하고,
+// 아니면 같은 이름의 헤더 중 하나라도 디코딩한 값이 re에 맞는지 확인합니다.
+type headerFilter struct {
+	name     string // 요약에 표시할 옵션 이름과 값
+	key      string
+	re       *regexp.Regexp
+	rejected atomic.Int64
+}
+
+// headerFilters는 모든 조건을 만족(AND)하는 메일만 포함합니다. 레코드 필터와 달리 헤더를 읽은 직후 확인하므로
+// 제외되는 메일은 본문을 읽지 않습니다.
+type headerFilters []*headerFilter
+
+// newHeaderFilters는 -has-header 이름 목록과 -header-match "이름=정규식" 목록으로 헤더 필터를 구성합니다.
+// 헤더 이름은 대소문자를 구분하지 않으며, -header-match는 "이름~정규식"도 허용합니다.
+func newHeaderFilters(has, match []string) (headerFilters, error) {
+	var fs headerFilters
+	for _, name := range has {
+		key := strings.TrimSpace(name)
+		if key == "" {
+			return nil, fmt.Errorf("-has-header: 헤더 이름이 비어 있습니다")
+		}
+		fs = append(fs, &headerFilter{name: "-has-header " + key, key: key})
+	}
+	for _, m := range match {
+		i := strings.IndexAny(m, "=~")
+		if i <= 0 {
+			return nil, fmt.Errorf("-header-match: \"이름=정규식\" 형식이어야 합니다: %q", m)
+		}
+		key := strings.TrimSpace(m[:i])
+		re, err := compileFilterRegex(m[i+1:], false)
+		if err != nil {
+			return nil, fmt.Errorf("-header-match %s: %w", key, err)
+		}
+		fs = append(fs, &headerFilter{name: "-header-match " + key, key: key, re: re})
+	}
+	return fs, nil
+}
+
+// match는 헤더가 조건을 만족하는지 확인합니다.
+func (f *headerFilter) match(h messageMail.Header) bool {
+	values := h.Values(f.key)
+	if f.re == nil {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		decoded, _ := decodeHeaderLenient(v)
+		if f.re.MatchString(unfoldHeader(decoded)) {
+			return true
+		}
+	}
+	return false
+}
+
+// check는 모든 조건을 만족하면 nil을, 아니면 errHeaderExcluded를 반환합니다.
+// 제외된 메일은 처음 만족하지 못한 조건 하나에만 셉니다.
+func (fs headerFilters) check(h messageMail.Header) error {
+	for _, f := range fs {
+		if !f.match(h) {
+			f.rejected.Add(1)
+			return errHeaderExcluded
+		}
+	}
+	return nil
+}
+
+// rejectedSummary는 조건별 제외 수를 recordFilters.rejectedSummary와 같은 형식으로 반환합니다.
+func (fs headerFilters) rejectedSummary() string {
+	parts := make([]string, 0, len(fs))
+	for _, f := range fs {
+		parts = append(parts, fmt.Sprintf("%s %d", f.name, f.rejected.Load()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// unfoldHeader는 접힌 헤더 값(RFC 5322 §2.2.3)의 줄바꿈을 제거합니다.
+func unfoldHeader(v string) string {
+	return strings.NewReplacer("\r\n", "", "\n", "").Replace(v)
+}
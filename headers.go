@@ -0,0 +1,505 @@
+package main
+
+import (
+	"mime"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-message"
+	messageMail "github.com/emersion/go-message/mail"
+	"golang.org/x/text/language"
+)
+
+// List-Unsubscribe 헤더의 <...> 항목 (RFC 2369)
+var angleBracketRegex = regexp.MustCompile(`<([^<>]+)>`)
+
+// parseListUnsubscribe는 List-Unsubscribe 헤더에서 URL과 mailto 주소를 추출합니다.
+// 예: "<mailto:unsub@example.com?subject=x>, <https://example.com/u?id=1>"
+func parseListUnsubscribe(value string) []string {
+	var links []string
+	for _, m := range angleBracketRegex.FindAllStringSubmatch(value, -1) {
+		link := strings.TrimSpace(m[1])
+		lower := strings.ToLower(link)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:") {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// 스킴별 기본 포트: 호스트 정규화 시 제거
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ftp":   "21",
+}
+
+// urlDomain은 URL의 정규화된 호스트를 반환합니다. mailto: 링크는 수신 주소의 도메인을 반환합니다.
+// 소문자로 바꾸고, 스킴의 기본 포트(:80, :443 등)와 끝의 "."를 제거합니다.
+func urlDomain(u string) (string, bool) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme == "mailto" {
+		addr := parsed.Opaque
+		if i := strings.IndexAny(addr, ",?"); i >= 0 {
+			addr = addr[:i]
+		}
+		at := strings.LastIndex(addr, "@")
+		if at < 0 {
+			return "", false
+		}
+		return strings.TrimSuffix(strings.ToLower(addr[at+1:]), "."), true
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if port := parsed.Port(); port != "" && port != defaultPorts[scheme] {
+		host = net.JoinHostPort(host, port)
+	}
+	return host, true
+}
+
+// appendUnique는 dst에 없는 항목만 순서를 유지하며 추가합니다.
+func appendUnique(dst []string, items ...string) []string {
+	seen := make(map[string]struct{}, len(dst))
+	for _, d := range dst {
+		seen[d] = struct{}{}
+	}
+	for _, it := range items {
+		if _, ok := seen[it]; ok {
+			continue
+		}
+		seen[it] = struct{}{}
+		dst = append(dst, it)
+	}
+	return dst
+}
+
+// 제목 앞의 회신/전달 접두어. "Re[2]:", 전각 콜론(：) 형태도 허용합니다.
+var subjectPrefixRegex = regexp.MustCompile(`(?i)^\s*(re|aw|회신|답장|fw|fwd|전달)\s*(?:\[\d+\])?\s*[:：]\s*`)
+
+// parseSubjectPrefix는 제목 앞의 회신(RE:/AW:/회신)·전달(FW:/FWD:/전달) 접두어를
+// 모두 제거한 제목과 회신/전달 여부를 반환합니다. "RE: FW: 제목"처럼 중첩된 접두어도 처리합니다.
+func parseSubjectPrefix(subject string) (clean string, isReply, isForward bool) {
+	clean = subject
+	for {
+		m := subjectPrefixRegex.FindStringSubmatch(clean)
+		if m == nil {
+			break
+		}
+		switch strings.ToLower(m[1]) {
+		case "re", "aw", "회신", "답장":
+			isReply = true
+		default:
+			isForward = true
+		}
+		clean = clean[len(m[0]):]
+	}
+	return strings.TrimSpace(clean), isReply, isForward
+}
+
+// joinAddresses는 주소 목록의 이름과 이메일을 각각 줄바꿈으로 연결합니다.
+// 이름이 없는 주소도 빈 줄로 남겨 이름과 이메일의 줄 위치가 일치하도록 하되,
+// 이름이 하나도 없으면 줄바꿈만 남지 않도록 이름은 빈 문자열로 반환합니다.
+func joinAddresses(list []*messageMail.Address) (names, emails string) {
+	nameList := make([]string, 0, len(list))
+	emailList := make([]string, 0, len(list))
+	var hasName bool
+	for _, a := range list {
+		name := cleanText(a.Name)
+		hasName = hasName || name != ""
+		nameList = append(nameList, name)
+		emailList = append(emailList, a.Address)
+	}
+	if !hasName {
+		return "", strings.Join(emailList, "\n")
+	}
+	return strings.Join(nameList, "\n"), strings.Join(emailList, "\n")
+}
+
+// addressHeader는 주소 목록 헤더의 이름과 주소를 줄바꿈으로 연결하여 반환합니다.
+// 파싱할 수 없는 값은 fallbackAddresses로 주소처럼 보이는 부분만 추출합니다.
+func addressHeader(h messageMail.Header, key string) (names, emails string) {
+	list, _, err := parseAddressList(h, key)
+	if err == nil && len(list) > 0 {
+		return joinAddresses(list)
+	}
+	if raw := headerText(h, key); raw != "" {
+		return fallbackAddresses(raw)
+	}
+	return "", ""
+}
+
+// deliveredTo는 모든 Delivered-To 헤더의 주소를 중복 없이 위에서부터 순서대로 줄바꿈으로 연결합니다.
+func deliveredTo(h messageMail.Header) string {
+	var emails []string
+	for _, v := range h.Values("Delivered-To") {
+		emails = appendUnique(emails, emailAddressRegex.FindAllString(v, -1)...)
+	}
+	return strings.Join(emails, "\n")
+}
+
+// returnPath는 가장 위(마지막으로 추가된) Return-Path 헤더의 주소를 반환합니다. 반송 주소가 없는 "<>"이면 빈 문자열입니다.
+func returnPath(h messageMail.Header) string {
+	return emailAddressRegex.FindString(h.Get("Return-Path"))
+}
+
+// threadIndex는 Exchange/Outlook의 Thread-Index 헤더 값(base64)을 접힌 줄의 공백을 없애 반환합니다.
+// 앞 22바이트가 대화의 첫 메일을 나타내므로 같은 대화의 메일은 값의 앞부분이 같습니다.
+func threadIndex(h messageMail.Header) string {
+	return strings.Join(strings.Fields(h.Get("Thread-Index")), "")
+}
+
+// contentLanguage는 Content-Language 헤더의 언어 태그를 표준 표기(예: "en-us" → "en-US")로 바꿔 중복 없이 줄바꿈으로 연결합니다.
+// 태그로 해석할 수 없는 값은 공백만 다듬어 그대로 둡니다.
+func contentLanguage(h messageMail.Header) string {
+	var tags []string
+	for _, v := range strings.Split(h.Get("Content-Language"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if tag, err := language.Parse(v); err == nil {
+			v = tag.String()
+		}
+		tags = appendUnique(tags, v)
+	}
+	return strings.Join(tags, "\n")
+}
+
+// receivedIPs는 Received 헤더 체인(위에서부터)에 나온 IP 주소를 중복 없이 순서대로 반환합니다.
+// 타임스탬프(";" 뒤)는 IP로 오인하지 않도록 제외합니다.
+func receivedIPs(h messageMail.Header) []string {
+	var ips []string
+	for _, v := range h.Values("Received") {
+		if i := strings.LastIndex(v, ";"); i >= 0 {
+			v = v[:i]
+		}
+		ips = appendUnique(ips, extractIPs(v)...)
+	}
+	return ips
+}
+
+// parseAddressList는 주소 목록 헤더를 파싱합니다. 그룹 구문("Team: a@x, b@y;")은 구성원 주소로 펼쳐지며,
+// 표시 이름 중간의 주석("John (the man) Smith <j@x>")처럼 기본 파서가 거부하는 값은 주석을 지우고 다시 시도합니다.
+// 구성원이 없는 그룹("undisclosed-recipients:;")은 기본 파서가 거부하므로 목록에서 빼고 다시 시도합니다.
+// 그래도 실패하면 쉼표/세미콜론으로 나눈 주소를 하나씩 파싱하여("a@x.com; b@y.com", 끝에 붙은 쉼표 등)
+// 파싱한 주소는 list로, 파싱하지 못한 나머지는 unparsed로 반환합니다. err는 주소를 하나도 얻지 못했을 때만 반환합니다.
+func parseAddressList(h messageMail.Header, key string) (list []*messageMail.Address, unparsed string, err error) {
+	list, err = h.AddressList(key)
+	if err == nil {
+		return list, "", nil
+	}
+	raw := h.Get(key)
+	stripped := stripHeaderComments(raw)
+	_, stripped = parseAddressGroups(stripped)
+	stripped = strings.Trim(stripped, " ,")
+	if stripped == "" {
+		return nil, "", nil
+	}
+	if stripped != raw {
+		if list, err2 := messageMail.ParseAddressList(stripped); err2 == nil {
+			return list, "", nil
+		}
+	}
+	list, bad := parseAddressTokens(stripped)
+	if len(list) == 0 {
+		return nil, "", err
+	}
+	return list, strings.Join(bad, "; "), nil
+}
+
+// parseAddressTokens는 주소 목록을 따옴표/<...> 밖의 쉼표와 세미콜론으로 나눠 주소를 하나씩 파싱합니다.
+// 그룹 이름("Team: a@x")은 떼어 내고, 파싱하지 못한 항목은 bad로 반환합니다.
+// 주소가 없는 항목 뒤에 표시 이름이 있는 주소가 오면 따옴표 없이 쉼표를 쓴 표시 이름으로 보고 이어 붙입니다
+// (예: `Acme, Inc <no-reply@acme>` → 이름 "Acme, Inc").
+func parseAddressTokens(v string) (list []*messageMail.Address, bad []string) {
+	var pending []string
+	for _, tok := range splitAddressTokens(v) {
+		if i := topLevelIndex(tok, ':'); i >= 0 {
+			tok = strings.TrimSpace(tok[i+1:])
+		}
+		if tok == "" {
+			continue
+		}
+		a, err := messageMail.ParseAddress(tok)
+		switch {
+		case err == nil:
+			if len(pending) > 0 && a.Name != "" {
+				a.Name = strings.Join(append(pending, a.Name), ", ")
+			} else {
+				bad = append(bad, pending...)
+			}
+			pending = nil
+			list = append(list, a)
+		case !strings.Contains(tok, "@"):
+			pending = append(pending, tok)
+		default:
+			bad = append(append(bad, pending...), tok)
+			pending = nil
+		}
+	}
+	return list, append(bad, pending...)
+}
+
+// splitAddressTokens는 따옴표 문자열과 <...> 밖의 쉼표/세미콜론으로 나눈 비어 있지 않은 항목을 반환합니다.
+func splitAddressTokens(v string) []string {
+	var tokens []string
+	start := 0
+	quoted, escaped, angle := false, false, false
+	for i, r := range v {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quoted:
+			if r == '"' {
+				quoted = false
+			}
+		case r == '"':
+			quoted = true
+		case r == '<':
+			angle = true
+		case r == '>':
+			angle = false
+		case !angle && (r == ',' || r == ';'):
+			if tok := strings.TrimSpace(v[start:i]); tok != "" {
+				tokens = append(tokens, tok)
+			}
+			start = i + 1
+		}
+	}
+	if tok := strings.TrimSpace(v[start:]); tok != "" {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// topLevelIndex는 따옴표 문자열과 <...> 밖에서 처음 나오는 c의 위치를 반환합니다.
+func topLevelIndex(v string, c rune) int {
+	quoted, escaped, angle := false, false, false
+	for i, r := range v {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quoted:
+			if r == '"' {
+				quoted = false
+			}
+		case r == '"':
+			quoted = true
+		case r == '<':
+			angle = true
+		case r == '>':
+			angle = false
+		case !angle && r == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// addressGroup은 주소 목록 헤더의 그룹 구문(RFC 5322 §3.4 "Team: a@x, b@y;")입니다.
+type addressGroup struct {
+	name  string
+	empty bool // 구성원이 없는 그룹 ("undisclosed-recipients:;")
+}
+
+// parseAddressGroups는 주석을 제거한 주소 목록 값에서 그룹을 찾고, 구성원이 없는 그룹을 뺀 값을 함께 반환합니다.
+// 따옴표 문자열과 <...> 안의 콜론/세미콜론은 구분자로 보지 않습니다. 그룹 이름은 RFC 2047 디코딩합니다.
+func parseAddressGroups(v string) (groups []addressGroup, withoutEmpty string) {
+	var b strings.Builder
+	quoted, escaped, angle, inGroup := false, false, false, false
+	segStart, colon, copied := 0, 0, 0
+	for i, r := range v {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quoted:
+			if r == '"' {
+				quoted = false
+			}
+		case r == '"':
+			quoted = true
+		case r == '<':
+			angle = true
+		case r == '>':
+			angle = false
+		case angle:
+		case r == ',' && !inGroup:
+			segStart = i + 1
+		case r == ':' && !inGroup:
+			inGroup, colon = true, i
+		case r == ';' && inGroup:
+			name := strings.Trim(strings.TrimSpace(v[segStart:colon]), `"`)
+			name, _ = decodeHeaderLenient(name)
+			g := addressGroup{name: cleanText(name), empty: strings.Trim(v[colon+1:i], " ,") == ""}
+			groups = append(groups, g)
+			if g.empty {
+				b.WriteString(v[copied:segStart])
+				copied = i + 1
+			}
+			inGroup, segStart = false, i+1
+		}
+	}
+	b.WriteString(v[copied:])
+	return groups, b.String()
+}
+
+// emptyGroupAddresses는 구성원이 없는 그룹을 이름만 있고 주소는 빈 항목으로 바꿔,
+// 이름/주소 열에서 다른 주소와 같은 줄 순서로 기록되게 합니다.
+func emptyGroupAddresses(groups []addressGroup) []*messageMail.Address {
+	var list []*messageMail.Address
+	for _, g := range groups {
+		if g.empty {
+			list = append(list, &messageMail.Address{Name: g.name})
+		}
+	}
+	return list
+}
+
+// groupNames는 그룹 이름을 줄바꿈으로 연결합니다.
+func groupNames(groups []addressGroup) string {
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.name)
+	}
+	return strings.Join(names, "\n")
+}
+
+// stripHeaderComments는 따옴표 문자열 밖의 괄호 주석(RFC 5322 §3.2.2, 중첩 포함)을 공백으로 바꿉니다.
+func stripHeaderComments(v string) string {
+	var b strings.Builder
+	depth := 0
+	quoted := false
+	escaped := false
+	for _, r := range v {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quoted:
+			if r == '"' {
+				quoted = false
+			}
+		case r == '"' && depth == 0:
+			quoted = true
+		case r == '(':
+			depth++
+			continue
+		case r == ')' && depth > 0:
+			depth--
+			if depth == 0 {
+				b.WriteByte(' ')
+			}
+			continue
+		}
+		if depth == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Authentication-Results의 "spf=pass", "dkim=fail" 같은 결과 항목
+var authResultRegex = regexp.MustCompile(`(?i)\b(spf|dkim|dmarc)\s*=\s*([a-z]+)`)
+
+// parseAuthResults는 Authentication-Results 헤더(RFC 8601)들에서 SPF/DKIM/DMARC 결과를 추출하여
+// "spf=pass dkim=fail dmarc=fail" 형식으로 반환합니다. 방법별로 처음 나온 결과만 사용합니다.
+func parseAuthResults(values []string) string {
+	seen := map[string]bool{}
+	var parts []string
+	for _, v := range values {
+		for _, m := range authResultRegex.FindAllStringSubmatch(v, -1) {
+			method := strings.ToLower(m[1])
+			if seen[method] {
+				continue
+			}
+			seen[method] = true
+			parts = append(parts, method+"="+strings.ToLower(m[2]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// RFC 2047 encoded-word
+var encodedWordRegex = regexp.MustCompile(`=\?[^?\s]+\?[bBqQ]\?[^?\s]*\?=`)
+
+// 인접한 encoded-word 사이의 공백 (RFC 2047 §6.2에 따라 디코딩 시 제거)
+var encodedWordGapRegex = regexp.MustCompile(`(\?=)\s+(=\?)`)
+
+// decodeHeaderLenient는 RFC 2047 디코딩에 실패한 헤더 값을 encoded-word 단위로 최대한 디코딩합니다.
+// 유효한 encoded-word는 디코딩하고, 잘린 base64나 등록되지 않은 문자셋 등 잘못된 것은 원문 그대로 둡니다.
+// 하나라도 디코딩하지 못하면 ok는 false입니다.
+func decodeHeaderLenient(raw string) (decoded string, ok bool) {
+	dec := mime.WordDecoder{CharsetReader: message.CharsetReader}
+	ok = true
+	raw = encodedWordGapRegex.ReplaceAllString(raw, "$1$2")
+	decoded = encodedWordRegex.ReplaceAllStringFunc(raw, func(word string) string {
+		s, err := dec.Decode(word)
+		if err != nil {
+			ok = false
+			return word
+		}
+		return s
+	})
+	return decoded, ok
+}
+
+// normalizePriority는 X-Priority 값("1 (Highest)", "3", "5 (Lowest)" 등)을 high/normal/low로 정규화합니다.
+// X-Priority가 없으면 Importance 헤더(high/normal/low)를 사용합니다.
+func normalizePriority(xPriority, importance string) string {
+	xPriority = strings.TrimSpace(xPriority)
+	if xPriority != "" {
+		switch xPriority[0] {
+		case '1', '2':
+			return "high"
+		case '3':
+			return "normal"
+		case '4', '5':
+			return "low"
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(importance)) {
+	case "high":
+		return "high"
+	case "normal":
+		return "normal"
+	case "low":
+		return "low"
+	}
+	return ""
+}
+
+// headerText는 헤더 값을 RFC 2047 디코딩하여 반환합니다. 디코딩에 실패하면 최대한 디코딩한 값을 반환합니다.
+func headerText(h messageMail.Header, key string) string {
+	text, err := h.Text(key)
+	if err != nil {
+		text, _ = decodeHeaderLenient(h.Get(key))
+	}
+	return strings.TrimSpace(text)
+}
+
+// 주소처럼 보이는 문자열 (도메인에 점이 없는 사내 주소도 허용)
+var emailAddressRegex = regexp.MustCompile(`[A-Za-z0-9._%+\-=']+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*`)
+
+// fallbackAddresses는 주소 목록으로 파싱할 수 없는 헤더 값에서 주소처럼 보이는 부분을 정규식으로 찾고,
+// 주소를 제외한 나머지를 이름으로 사용합니다. 예: `Acme, Inc <no-reply@acme.com>` → "Acme, Inc", "no-reply@acme.com"
+// 그룹 구문("undisclosed-recipients:;")은 그룹 이름만 남깁니다.
+func fallbackAddresses(raw string) (name, emails string) {
+	found := emailAddressRegex.FindAllString(raw, -1)
+	rest := emailAddressRegex.ReplaceAllString(raw, "")
+	rest = strings.NewReplacer("<>", " ", "\"", "", ":;", " ", ";", " ").Replace(rest)
+	rest = strings.Join(strings.Fields(cleanText(rest)), " ")
+	rest = strings.Trim(rest, " ,:")
+	return rest, strings.Join(appendUnique(nil, found...), "\n")
+}
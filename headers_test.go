@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// 주소 목록으로 파싱할 수 없는 From/To도 이름과 주소를 잃지 않아야 함
+func TestBrokenAddressFixtures(t *testing.T) {
+	tests := []struct {
+		fixture                   string
+		fromName, fromEmail       string
+		toName, toEmail, toGroups string
+		fromRaw, toRaw            string
+	}{
+		{
+			fixture:  "from-unquoted-comma.eml",
+			fromName: "Acme, Inc", fromEmail: "no-reply@acme",
+			toName: "Sales, Team\n", toEmail: "sales@example.org\nops@example.org",
+		},
+		{
+			fixture:  "from-no-angle-brackets.eml",
+			fromName: "billing department", fromEmail: "billing@example.com",
+			toEmail: "alice@example.org\nbob@example.org",
+			fromRaw: "billing department billing@example.com", toRaw: "alice@example.org bob@example.org",
+		},
+		{
+			fixture:  "to-undisclosed-recipients.eml",
+			fromName: "Mailer", fromEmail: "mailer@example.com",
+			toName: "undisclosed-recipients", toGroups: "undisclosed-recipients",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := [...]string{rec.FromName, rec.FromEmail, rec.ToName, rec.ToEmail, rec.ToGroups, rec.FromRaw, rec.ToRaw}
+			want := [...]string{tt.fromName, tt.fromEmail, tt.toName, tt.toEmail, tt.toGroups, tt.fromRaw, tt.toRaw}
+			names := [...]string{"FromName", "FromEmail", "ToName", "ToEmail", "ToGroups", "FromRaw", "ToRaw"}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s = %q, want %q", names[i], got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAddressTokens(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantNames []string
+		wantAddrs []string
+		wantBad   []string
+	}{
+		{"Acme, Inc <no-reply@acme>", []string{"Acme, Inc"}, []string{"no-reply@acme"}, nil},
+		{"a@example.com; b@example.com", []string{"", ""}, []string{"a@example.com", "b@example.com"}, nil},
+		{"a@example.com,, b@example.com,", []string{"", ""}, []string{"a@example.com", "b@example.com"}, nil},
+		{"Team: a@example.com, b@example.com;", []string{"", ""}, []string{"a@example.com", "b@example.com"}, nil},
+		{"nobody, a@example.com", []string{""}, []string{"a@example.com"}, []string{"nobody"}},
+		{"a@example.com, broken@@x", []string{""}, []string{"a@example.com"}, []string{"broken@@x"}},
+		{"a@example.com, trailing name", []string{""}, []string{"a@example.com"}, []string{"trailing name"}},
+	}
+	for _, tt := range tests {
+		list, bad := parseAddressTokens(tt.value)
+		var names, addrs []string
+		for _, a := range list {
+			names = append(names, a.Name)
+			addrs = append(addrs, a.Address)
+		}
+		if !equalStrings(names, tt.wantNames) || !equalStrings(addrs, tt.wantAddrs) || !equalStrings(bad, tt.wantBad) {
+			t.Errorf("parseAddressTokens(%q) = %q %q bad %q, want %q %q bad %q", tt.value, names, addrs, bad, tt.wantNames, tt.wantAddrs, tt.wantBad)
+		}
+	}
+}
+
+// equalStrings는 nil과 빈 슬라이스를 같게 보는 문자열 슬라이스 비교입니다.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestURLDomain(t *testing.T) {
+	tests := []struct {
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{"https://Example.COM/path", "example.com", true},
+		{"https://example.com:443/", "example.com", true},
+		{"http://example.com:80/", "example.com", true},
+		{"http://example.com:443/", "example.com:443", true},
+		{"https://example.com:8443/", "example.com:8443", true},
+		{"https://example.com./x", "example.com", true},
+		{"ftp://files.example.com:21/a", "files.example.com", true},
+		{"https://[2001:db8::1]:443/", "2001:db8::1", true},
+		{"https://[2001:db8::1]:8443/", "[2001:db8::1]:8443", true},
+		{"mailto:Someone@Example.ORG?subject=hi", "example.org", true},
+		{"mailto:nobody", "", false},
+		{"https://bad host/", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := urlDomain(tt.url)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("urlDomain(%q) = %q, %v, want %q, %v", tt.url, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// 그룹 구성원은 모두 받는 사람 열에 들어가고, 표시 이름의 주석은 지워져야 함
+func TestGroupAndCommentFixture(t *testing.T) {
+	rec, _, err := processEmlFile(filepath.Join("testdata", "to-groups-comments.eml"), parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ field, got, want string }{
+		{"FromName", rec.FromName, "John Smith"},
+		{"FromEmail", rec.FromEmail, "john@example.com"},
+		// 주소 뒤의 주석만 있는 경우는 그 주석이 표시 이름 (RFC 5322 obs 형식)
+		{"ToName", rec.ToName, "\nBob Lee\nCarol, sales\nEmpty Group"},
+		{"ToEmail", rec.ToEmail, "alice@example.org\nbob@example.org\ncarol@example.org\n"},
+		{"ToGroups", rec.ToGroups, "Team\nEmpty Group"},
+		{"ToRaw", rec.ToRaw, ""},
+		{"CcName", rec.CcName, ""},
+		{"CcEmail", rec.CcEmail, "dave@example.org"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestStripHeaderComments(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"John (the boss) Smith <john@example.com>", "John Smith <john@example.com>"},
+		{"a@example.com (nested (comment) here)", "a@example.com"},
+		{`"Quoted (not a comment)" <q@example.com>`, `"Quoted (not a comment)" <q@example.com>`},
+		{`(escaped \) paren) b@example.com`, "b@example.com"},
+		{"no comments <c@example.com>", "no comments <c@example.com>"},
+	}
+	for _, tt := range tests {
+		if got := stripHeaderComments(tt.value); got != tt.want {
+			t.Errorf("stripHeaderComments(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseAddressGroups(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantNames    []string
+		wantEmpty    []bool
+		withoutEmpty string
+	}{
+		{"undisclosed-recipients:;", []string{"undisclosed-recipients"}, []bool{true}, ""},
+		{"Team: a@x.example, b@y.example;", []string{"Team"}, []bool{false}, "Team: a@x.example, b@y.example;"},
+		{"a@x.example, Empty:;, b@y.example", []string{"Empty"}, []bool{true}, "a@x.example,, b@y.example"},
+		{`"Not: a group" <q@x.example>`, nil, nil, `"Not: a group" <q@x.example>`},
+		{"=?UTF-8?B?7YyA?=: a@x.example;", []string{"팀"}, []bool{false}, "=?UTF-8?B?7YyA?=: a@x.example;"},
+	}
+	for _, tt := range tests {
+		groups, rest := parseAddressGroups(tt.value)
+		var names []string
+		var empty []bool
+		for _, g := range groups {
+			names = append(names, g.name)
+			empty = append(empty, g.empty)
+		}
+		if !equalStrings(names, tt.wantNames) || fmt.Sprint(empty) != fmt.Sprint(tt.wantEmpty) || rest != tt.withoutEmpty {
+			t.Errorf("parseAddressGroups(%q) = %q %v %q, want %q %v %q", tt.value, names, empty, rest, tt.wantNames, tt.wantEmpty, tt.withoutEmpty)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "fmt"
+
+// outputMode는 출력 옵션 이름과 사용 여부입니다.
+type outputMode struct {
+	flag string
+	on   bool
+}
+
+// checkHeadTail은 -head/-tail을 집계 출력(-group-by 등)과 함께 쓰는지 확인합니다.
+// 집계 출력은 레코드 대신 집계 행을 내므로, 레코드 수를 자르면 출력이 아닌 집계 대상이 일부 메일로 줄어듭니다.
+func checkHeadTail(head, tail int, aggregates []outputMode) error {
+	if head == 0 && tail == 0 {
+		return nil
+	}
+	for _, m := range aggregates {
+		if m.on {
+			return fmt.Errorf("-head/-tail은 집계 출력 %s와 함께 사용할 수 없습니다 (일부 메일만 집계됨)", m.flag)
+		}
+	}
+	return nil
+}
+
+// headRecordWriter는 처음 n개의 레코드만 out에 기록하고 나머지는 버립니다 (-head).
+// 출력만 줄이며, 파일은 모두 처리하므로 처리 요약과 부가 출력(-attachment-report 등)은 전체 기준입니다.
+type headRecordWriter struct {
+	out recordWriter
+	n   int
+}
+
+func (w *headRecordWriter) WriteRecord(r EmailRecord) error {
+	if w.n <= 0 {
+		return nil
+	}
+	w.n--
+	return w.out.WriteRecord(r)
+}
+
+func (w *headRecordWriter) Close() error { return w.out.Close() }
+
+// tailRecordWriter는 마지막 n개의 레코드만 모아 두었다가, 닫을 때 들어온 순서대로 out에 기록합니다 (-tail).
+// n개 크기의 원형 버퍼만 사용하므로 메모리 사용량은 n에 비례합니다.
+type tailRecordWriter struct {
+	out     recordWriter
+	records []EmailRecord
+	next    int // 다음에 덮어쓸 위치 (버퍼가 찬 뒤에는 가장 오래된 레코드의 위치)
+	full    bool
+}
+
+func newTailRecordWriter(out recordWriter, n int) *tailRecordWriter {
+	return &tailRecordWriter{out: out, records: make([]EmailRecord, n)}
+}
+
+func (w *tailRecordWriter) WriteRecord(r EmailRecord) error {
+	w.records[w.next] = r
+	w.next++
+	if w.next == len(w.records) {
+		w.next, w.full = 0, true
+	}
+	return nil
+}
+
+func (w *tailRecordWriter) Close() error {
+	ordered := w.records[:w.next]
+	if w.full {
+		ordered = append(w.records[w.next:], w.records[:w.next]...)
+	}
+	for _, r := range ordered {
+		if err := w.out.WriteRecord(r); err != nil {
+			return err
+		}
+	}
+	return w.out.Close()
+}
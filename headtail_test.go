@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHeadTailRecordWriters(t *testing.T) {
+	records := func(n int) []EmailRecord {
+		var rs []EmailRecord
+		for i := 1; i <= n; i++ {
+			rs = append(rs, EmailRecord{Subject: fmt.Sprint(i), MessageSize: int64(i % 4)})
+		}
+		return rs
+	}
+	tests := []struct {
+		name  string
+		wrap  func(out recordWriter) recordWriter
+		input int
+		want  string
+	}{
+		{"head 3", func(out recordWriter) recordWriter { return &headRecordWriter{out: out, n: 3} }, 7, "1 2 3"},
+		{"head 입력보다 큼", func(out recordWriter) recordWriter { return &headRecordWriter{out: out, n: 10} }, 2, "1 2"},
+		{"tail 3", func(out recordWriter) recordWriter { return newTailRecordWriter(out, 3) }, 7, "5 6 7"},
+		{"tail 입력과 같음", func(out recordWriter) recordWriter { return newTailRecordWriter(out, 3) }, 3, "1 2 3"},
+		{"tail 입력보다 큼", func(out recordWriter) recordWriter { return newTailRecordWriter(out, 5) }, 2, "1 2"},
+		{"tail 입력 없음", func(out recordWriter) recordWriter { return newTailRecordWriter(out, 2) }, 0, ""},
+		// main과 같이 -largest 정렬이 끝난 출력에 -head 적용
+		{"largest 뒤 head", func(out recordWriter) recordWriter {
+			return newLargestRecordWriter(&headRecordWriter{out: out, n: 2}, 5)
+		}, 7, "3 7"},
+		{"largest 뒤 tail", func(out recordWriter) recordWriter {
+			return newLargestRecordWriter(newTailRecordWriter(out, 2), 5)
+		}, 7, "6 1"},
+	}
+	for _, tt := range tests {
+		out := &recordingWriter{}
+		w := tt.wrap(out)
+		for _, r := range records(tt.input) {
+			if err := w.WriteRecord(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range out.written {
+			got = append(got, r.Subject)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: 출력 = %q, want %q", tt.name, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestCheckHeadTail(t *testing.T) {
+	aggregates := []outputMode{{"-group-by", false}, {"-iocs", true}}
+	if err := checkHeadTail(0, 0, aggregates); err != nil {
+		t.Errorf("-head/-tail 없음: %v", err)
+	}
+	for _, ht := range [][2]int{{5, 0}, {0, 5}} {
+		err := checkHeadTail(ht[0], ht[1], aggregates)
+		if err == nil || !strings.Contains(err.Error(), "-iocs") {
+			t.Errorf("head=%d tail=%d: 오류 = %v, want -iocs 오류", ht[0], ht[1], err)
+		}
+	}
+	if err := checkHeadTail(5, 0, []outputMode{{"-group-by", false}}); err != nil {
+		t.Errorf("집계 출력 없음: %v", err)
+	}
+}
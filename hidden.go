@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+)
+
+// 숨겨진 HTML을 찾기 위해 본문 외 파트에서 읽을 최대 크기
+const maxHiddenHTMLScan = 4 << 20
+
+// 선언된 HTML 본문이 이 길이보다 짧으면 내용이 없는 것으로 보고 숨겨진 HTML을 찾습니다.
+const trivialHTMLLength = 64
+
+// scanHiddenHTML은 본문 외 파트(application/octet-stream 등)의 내용이 HTML이거나,
+// base64로 한 번 더 인코딩된 HTML이면 디코딩한 HTML을 반환합니다. 아니면 빈 문자열입니다.
+// 실제 HTML을 첨부처럼 위장해 URL을 숨기는 기법을 잡기 위한 휴리스틱입니다.
+func scanHiddenHTML(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, maxHiddenHTMLScan))
+	io.Copy(io.Discard, r)
+	if looksLikeHTML(data) {
+		return string(trimBodyPrefix(data))
+	}
+	if decoded, ok := decodeBase64Loose(data); ok && looksLikeHTML(decoded) {
+		return string(trimBodyPrefix(decoded))
+	}
+	return ""
+}
+
+// looksLikeHTML은 앞부분에 HTML 문서나 링크 태그가 있는지 확인합니다.
+func looksLikeHTML(data []byte) bool {
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	lower := bytes.ToLower(head)
+	for _, marker := range []string{"<!doctype html", "<html", "<body", "<a href", "<form", "<meta http-equiv"} {
+		if bytes.Contains(lower, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64Loose는 공백/줄바꿈이 섞인 base64를 디코딩합니다. base64 문자 외의 것이 있으면 실패합니다.
+func decodeBase64Loose(data []byte) ([]byte, bool) {
+	compact := make([]byte, 0, len(data))
+	for _, b := range data {
+		switch {
+		case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '+', b == '/', b == '=':
+			compact = append(compact, b)
+		default:
+			return nil, false
+		}
+	}
+	if len(compact) < 16 {
+		return nil, false
+	}
+	s := strings.TrimRight(string(compact), "=")
+	decoded, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// findHiddenHTML은 MIME 트리에서 처음 발견한 숨겨진 HTML을 반환합니다.
+func findHiddenHTML(p *mimePart) string {
+	if p == nil {
+		return ""
+	}
+	if p.hiddenHTML != "" {
+		return p.hiddenHTML
+	}
+	for _, c := range p.children {
+		if h := findHiddenHTML(c); h != "" {
+			return h
+		}
+	}
+	return ""
+}
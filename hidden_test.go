@@ -0,0 +1,33 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHiddenHTMLOnlyReplacesDeclaredHTMLBody(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		wantHidden bool
+		wantURLs   string
+	}{
+		// 선언된 HTML 본문이 비어 있으면 octet-stream 파트의 HTML을 본문으로 씀
+		{"hidden-html.eml", true, "https://attached.example.net/form"},
+		// 텍스트 전용 메일의 HTML 첨부는 본문으로 승격하지 않음
+		{"text-with-html-attachment.eml", false, "https://dash.example.com/weekly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.HiddenHTMLUsed != tt.wantHidden {
+				t.Errorf("HiddenHTMLUsed = %v, want %v", rec.HiddenHTMLUsed, tt.wantHidden)
+			}
+			if rec.URLs != tt.wantURLs {
+				t.Errorf("URLs = %q, want %q", rec.URLs, tt.wantURLs)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 발송 시각 히스토그램 구간 (-histogram)
+const (
+	histogramHour    = "hour"
+	histogramWeekday = "weekday"
+	histogramDate    = "date"
+)
+
+// histogramUnknown은 날짜를 알 수 없는 메일의 구간 이름입니다.
+const histogramUnknown = "unknown"
+
+func validHistogram(by string) bool {
+	switch by {
+	case histogramHour, histogramWeekday, histogramDate:
+		return true
+	}
+	return false
+}
+
+// histogramBarWidth는 터미널 막대 그래프에서 가장 긴 막대의 글자 수입니다.
+const histogramBarWidth = 50
+
+var fixedOffsetRegex = regexp.MustCompile(`^[+-]\d{4}$`)
+
+// parseTZ는 -tz 값("Asia/Seoul" 같은 IANA 이름, "UTC", "+0900" 같은 고정 오프셋)을 시간대로 바꿉니다.
+func parseTZ(name string) (*time.Location, error) {
+	if fixedOffsetRegex.MatchString(name) {
+		t, err := time.Parse("-0700", name)
+		if err != nil {
+			return nil, err
+		}
+		_, offset := t.Zone()
+		return time.FixedZone(name, offset), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// histogramRow는 -histogram 출력의 한 행입니다.
+type histogramRow struct {
+	Bucket string
+	Count  int
+}
+
+// histogramRecordWriter는 메일별 행 대신 발송 시각의 시(0-23), 요일, 날짜별 메일 수를 출력합니다 (-histogram).
+// 기본적으로 Date 헤더의 원래 UTC 오프셋 기준 시각으로 나누고, loc가 있으면 그 시간대로 바꿔 나눕니다.
+// 날짜를 알 수 없는 메일은 "unknown" 구간에 셉니다.
+type histogramRecordWriter struct {
+	w      io.Writer
+	by     string
+	format string
+	loc    *time.Location
+	// chart가 설정되면 CSV 대신 막대 그래프를 출력합니다 (터미널 출력).
+	chart  bool
+	counts map[string]int
+}
+
+func newHistogramRecordWriter(w io.Writer, by, format string, loc *time.Location, chart bool) *histogramRecordWriter {
+	return &histogramRecordWriter{w: w, by: by, format: format, loc: loc, chart: chart, counts: make(map[string]int)}
+}
+
+// sentTime은 레코드의 발송 시각을 원래 오프셋(loc가 있으면 그 시간대) 기준으로 반환합니다.
+func (h *histogramRecordWriter) sentTime(r EmailRecord) (time.Time, bool) {
+	if r.SentDate == "" {
+		return time.Time{}, false
+	}
+	offset := r.SentDateOffset
+	if offset == "" {
+		offset = "+0000"
+	}
+	t, err := time.Parse("2006-01-02 15:04:05 -0700", r.SentDate+" "+offset)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if h.loc != nil {
+		t = t.In(h.loc)
+	}
+	return t, true
+}
+
+func (h *histogramRecordWriter) WriteRecord(r EmailRecord) error {
+	t, ok := h.sentTime(r)
+	if !ok {
+		h.counts[histogramUnknown]++
+		return nil
+	}
+	switch h.by {
+	case histogramHour:
+		h.counts[fmt.Sprintf("%02d", t.Hour())]++
+	case histogramWeekday:
+		h.counts[t.Weekday().String()[:3]]++
+	case histogramDate:
+		h.counts[t.Format("2006-01-02")]++
+	}
+	return nil
+}
+
+// rows는 구간 순서(시는 00-23, 요일은 월-일로 메일이 없는 구간도 포함, 날짜는 날짜 순)대로 행을 반환합니다.
+// "unknown" 구간은 메일이 있을 때만 마지막에 붙입니다.
+func (h *histogramRecordWriter) rows() []histogramRow {
+	var buckets []string
+	switch h.by {
+	case histogramHour:
+		for i := 0; i < 24; i++ {
+			buckets = append(buckets, fmt.Sprintf("%02d", i))
+		}
+	case histogramWeekday:
+		for i := 1; i <= 7; i++ {
+			buckets = append(buckets, time.Weekday(i % 7).String()[:3])
+		}
+	case histogramDate:
+		for b := range h.counts {
+			if b != histogramUnknown {
+				buckets = append(buckets, b)
+			}
+		}
+		sort.Strings(buckets)
+	}
+	if h.counts[histogramUnknown] > 0 {
+		buckets = append(buckets, histogramUnknown)
+	}
+	rows := make([]histogramRow, len(buckets))
+	for i, b := range buckets {
+		rows[i] = histogramRow{Bucket: b, Count: h.counts[b]}
+	}
+	return rows
+}
+
+func (h *histogramRecordWriter) Close() error {
+	rows := h.rows()
+	bw := bufio.NewWriter(h.w)
+	switch {
+	case h.format == formatJSON:
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	case h.format == formatNDJSON:
+		enc := json.NewEncoder(bw)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	case h.chart:
+		writeHistogramChart(bw, rows)
+	default:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"구간", "메일 수"})
+		for _, row := range rows {
+			cw.Write([]string{row.Bucket, strconv.Itoa(row.Count)})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeHistogramChart는 구간마다 "구간 | ##### 수" 형태의 막대를 출력합니다. 가장 많은 구간의 막대가 histogramBarWidth 글자입니다.
+func writeHistogramChart(w io.Writer, rows []histogramRow) {
+	peak, labelWidth := 0, 0
+	for _, row := range rows {
+		peak = max(peak, row.Count)
+		labelWidth = max(labelWidth, len(row.Bucket))
+	}
+	for _, row := range rows {
+		bar := 0
+		if peak > 0 {
+			bar = (row.Count*histogramBarWidth + peak - 1) / peak
+		}
+		fmt.Fprintf(w, "%-*s | %s %d\n", labelWidth, row.Bucket, strings.Repeat("#", bar), row.Count)
+	}
+}
+
+// checkHistogramFormat은 -histogram을 사용할 수 있는 출력 형식인지 확인합니다.
+func checkHistogramFormat(format string) error {
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("-histogram은 CSV, -json, -ndjson 출력에서만 사용할 수 있습니다")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var histogramInput = []EmailRecord{
+	{SentDate: "2024-03-01 23:30:00", SentDateOffset: "+0900"}, // 금 23시 (UTC 금 14시)
+	{SentDate: "2024-03-02 01:00:00", SentDateOffset: "+0900"}, // 토 01시 (UTC 금 16시)
+	{SentDate: "2024-03-04 10:00:00"},                          // 오프셋이 없으면 +0000
+	{},
+	{SentDate: "garbage"},
+}
+
+func writeHistogram(t *testing.T, by, format string, loc *time.Location, chart bool, records []EmailRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newHistogramRecordWriter(&buf, by, format, loc, chart)
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// histogramCounts는 CSV 출력을 구간 → 메일 수로 바꾸고 구간 순서를 함께 반환합니다.
+func histogramCounts(t *testing.T, out string) (map[string]string, []string) {
+	t.Helper()
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 || !equalStrings(rows[0], []string{"구간", "메일 수"}) {
+		t.Fatalf("CSV 헤더 = %q", rows)
+	}
+	counts := make(map[string]string)
+	var order []string
+	for _, row := range rows[1:] {
+		counts[row[0]] = row[1]
+		order = append(order, row[0])
+	}
+	return counts, order
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	tests := []struct {
+		name  string
+		by    string
+		loc   *time.Location
+		order []string // nil이면 순서를 확인하지 않음
+		want  map[string]string
+	}{
+		{"시, 원래 오프셋", histogramHour, nil, nil,
+			map[string]string{"23": "1", "01": "1", "10": "1", "00": "0", "unknown": "2"}},
+		{"시, UTC", histogramHour, time.UTC, nil,
+			map[string]string{"14": "1", "16": "1", "10": "1", "23": "0", "unknown": "2"}},
+		{"요일, 원래 오프셋", histogramWeekday, nil,
+			[]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun", "unknown"},
+			map[string]string{"Mon": "1", "Fri": "1", "Sat": "1", "Sun": "0", "unknown": "2"}},
+		{"요일, UTC", histogramWeekday, time.UTC, nil,
+			map[string]string{"Mon": "1", "Fri": "2", "Sat": "0"}},
+		{"날짜, 원래 오프셋", histogramDate, nil,
+			[]string{"2024-03-01", "2024-03-02", "2024-03-04", "unknown"},
+			map[string]string{"2024-03-01": "1", "2024-03-02": "1", "2024-03-04": "1", "unknown": "2"}},
+		{"날짜, UTC", histogramDate, time.UTC,
+			[]string{"2024-03-01", "2024-03-04", "unknown"},
+			map[string]string{"2024-03-01": "2", "2024-03-04": "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, order := histogramCounts(t, writeHistogram(t, tt.by, formatCSV, tt.loc, false, histogramInput))
+			for b, want := range tt.want {
+				if got := counts[b]; got != want {
+					t.Errorf("%s 구간 = %q, want %q", b, got, want)
+				}
+			}
+			if tt.order != nil && !equalStrings(order, tt.order) {
+				t.Errorf("구간 순서 = %q, want %q", order, tt.order)
+			}
+			if tt.by == histogramHour && len(order) != 25 {
+				t.Errorf("시 구간 %d개, want 24개 + unknown", len(order))
+			}
+		})
+	}
+}
+
+// 날짜를 모르는 메일이 없으면 "unknown" 구간을 붙이지 않음
+func TestHistogramWithoutUnknown(t *testing.T) {
+	_, order := histogramCounts(t, writeHistogram(t, histogramWeekday, formatCSV, nil, false, histogramInput[:3]))
+	if len(order) != 7 || order[len(order)-1] != "Sun" {
+		t.Errorf("구간 = %q", order)
+	}
+}
+
+func TestHistogramFormats(t *testing.T) {
+	var rows []histogramRow
+	if err := json.Unmarshal([]byte(writeHistogram(t, histogramDate, formatJSON, nil, false, histogramInput)), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0] != (histogramRow{Bucket: "2024-03-01", Count: 1}) || rows[3] != (histogramRow{Bucket: "unknown", Count: 2}) {
+		t.Errorf("JSON 행 = %+v", rows)
+	}
+	if got := writeHistogram(t, histogramDate, formatJSON, nil, false, nil); got != "[]\n" {
+		t.Errorf("빈 JSON = %q", got)
+	}
+	lines := strings.Split(strings.TrimSuffix(writeHistogram(t, histogramWeekday, formatNDJSON, nil, false, nil), "\n"), "\n")
+	if len(lines) != 7 || lines[0] != `{"Bucket":"Mon","Count":0}` {
+		t.Errorf("NDJSON 줄 = %q", lines)
+	}
+	// JSON 형식에서는 터미널 출력이어도 막대 그래프를 쓰지 않음
+	if got := writeHistogram(t, histogramDate, formatJSON, nil, true, nil); got != "[]\n" {
+		t.Errorf("터미널 JSON = %q", got)
+	}
+}
+
+func TestHistogramChart(t *testing.T) {
+	records := []EmailRecord{
+		{SentDate: "2024-03-01 10:00:00"},
+		{SentDate: "2024-03-01 11:00:00"},
+		{SentDate: "2024-03-01 12:00:00"},
+		{SentDate: "2024-03-02 10:00:00"},
+		{},
+	}
+	want := "2024-03-01 | " + strings.Repeat("#", histogramBarWidth) + " 3\n" +
+		"2024-03-02 | " + strings.Repeat("#", 17) + " 1\n" + // 50/3을 올림
+		"unknown    | " + strings.Repeat("#", 17) + " 1\n"
+	if got := writeHistogram(t, histogramDate, formatCSV, nil, true, records); got != want {
+		t.Errorf("막대 그래프 =\n%s\nwant\n%s", got, want)
+	}
+	if got := writeHistogram(t, histogramDate, formatCSV, nil, true, nil); got != "" {
+		t.Errorf("빈 막대 그래프 = %q", got)
+	}
+}
+
+func TestParseTZ(t *testing.T) {
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name   string
+		offset int
+	}{
+		{"UTC", 0},
+		{"+0900", 9 * 3600},
+		{"-0530", -(5*3600 + 30*60)},
+		{"Asia/Seoul", 9 * 3600},
+	} {
+		loc, err := parseTZ(tt.name)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if _, got := at.In(loc).Zone(); got != tt.offset {
+			t.Errorf("%s: 오프셋 = %d, want %d", tt.name, got, tt.offset)
+		}
+	}
+	for _, name := range []string{"+09", "Mars/Olympus", "+9999"} {
+		if _, err := parseTZ(name); err == nil {
+			t.Errorf("%s: 오류가 없음", name)
+		}
+	}
+}
+
+func TestCheckHistogramFormat(t *testing.T) {
+	if !validHistogram(histogramHour) || validHistogram("month") {
+		t.Error("validHistogram")
+	}
+	for _, f := range []string{formatCSV, formatJSON, formatNDJSON} {
+		if err := checkHistogramFormat(f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	for _, f := range []string{formatTable, formatURLs, formatTemplate, formatXML} {
+		if err := checkHistogramFormat(f); err == nil {
+			t.Errorf("%s: 오류가 없음", f)
+		}
+	}
+}
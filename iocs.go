@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 지표 유형 (-iocs 출력의 Type 열). 출력은 이 순서대로 묶습니다.
+var iocTypes = []string{"url", "domain", "ip", "email", "sha256"}
+
+// iocRow는 -iocs 출력의 한 행으로, 지표와 그 지표가 나온 메일 수입니다.
+type iocRow struct {
+	Type  string
+	Value string
+	Count int
+}
+
+// iocRecordWriter는 메일별 행 대신 전체 메일에서 모은 지표(IOC) 목록을 출력합니다 (-iocs).
+// 값은 레코드 열(URLs, URLDomains, IP, ReceivedIPs, FromEmail, AttachmentHashes)을 그대로 사용하므로
+// 정규화와 중복 제거 규칙이 메일별 출력과 같고, 한 메일에 여러 번 나온 지표는 한 번만 셉니다.
+type iocRecordWriter struct {
+	w      io.Writer
+	format string
+	defang bool
+	counts map[string]map[string]int // 유형 → 값 → 메일 수
+}
+
+func newIOCRecordWriter(w io.Writer, format string, defang bool) *iocRecordWriter {
+	counts := make(map[string]map[string]int, len(iocTypes))
+	for _, t := range iocTypes {
+		counts[t] = make(map[string]int)
+	}
+	return &iocRecordWriter{w: w, format: format, defang: defang, counts: counts}
+}
+
+// recordIndicators는 레코드의 지표를 유형별로 반환합니다.
+func recordIndicators(r EmailRecord) map[string][]string {
+	lines := func(fields ...string) []string {
+		var values []string
+		for _, f := range fields {
+			for _, v := range strings.Split(f, "\n") {
+				if v != "" {
+					values = appendUnique(values, v)
+				}
+			}
+		}
+		return values
+	}
+	return map[string][]string{
+		"url":    lines(r.URLs),
+		"domain": lines(r.URLDomains),
+		"ip":     lines(r.IP, r.ReceivedIPs),
+		"email":  lines(strings.ToLower(r.FromEmail)),
+		"sha256": lines(r.AttachmentHashes),
+	}
+}
+
+func (c *iocRecordWriter) WriteRecord(r EmailRecord) error {
+	for t, values := range recordIndicators(r) {
+		for _, v := range values {
+			c.counts[t][v]++
+		}
+	}
+	return nil
+}
+
+// rows는 유형 순서대로, 같은 유형 안에서는 메일 수가 많은 순(같으면 값 순)으로 정렬한 행을 반환합니다.
+func (c *iocRecordWriter) rows() []iocRow {
+	var rows []iocRow
+	for _, t := range iocTypes {
+		start := len(rows)
+		for v, n := range c.counts[t] {
+			rows = append(rows, iocRow{Type: t, Value: v, Count: n})
+		}
+		group := rows[start:]
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Count != group[j].Count {
+				return group[i].Count > group[j].Count
+			}
+			return group[i].Value < group[j].Value
+		})
+	}
+	if c.defang {
+		for i := range rows {
+			rows[i].Value = defang(rows[i].Type, rows[i].Value)
+		}
+	}
+	return rows
+}
+
+func (c *iocRecordWriter) Close() error {
+	rows := c.rows()
+	bw := bufio.NewWriter(c.w)
+	switch c.format {
+	case formatJSON:
+		if rows == nil {
+			rows = []iocRow{}
+		}
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	case formatNDJSON:
+		enc := json.NewEncoder(bw)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	default:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"유형", "값", "메일 수"})
+		for _, row := range rows {
+			cw.Write([]string{row.Type, row.Value, strconv.Itoa(row.Count)})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// defang은 지표를 실수로 클릭하거나 자동으로 링크되지 않도록 바꿉니다.
+// 예: "http://evil.com/a" → "hxxp://evil[.]com/a", "1.2.3.4" → "1[.]2[.]3[.]4", "a@evil.com" → "a[@]evil[.]com"
+func defang(typ, v string) string {
+	switch typ {
+	case "url":
+		scheme, rest, ok := strings.Cut(v, "://")
+		if !ok {
+			// mailto: 등
+			return strings.NewReplacer("@", "[@]", ".", "[.]").Replace(v)
+		}
+		host, path, _ := strings.Cut(rest, "/")
+		scheme = strings.NewReplacer("http", "hxxp", "ftp", "fxp").Replace(strings.ToLower(scheme))
+		out := scheme + "://" + strings.ReplaceAll(host, ".", "[.]")
+		if strings.Contains(rest, "/") {
+			out += "/" + path
+		}
+		return out
+	case "domain", "ip":
+		return strings.ReplaceAll(v, ".", "[.]")
+	case "email":
+		return strings.NewReplacer("@", "[@]", ".", "[.]").Replace(v)
+	}
+	return v
+}
+
+// checkIOCFormat은 -iocs를 사용할 수 있는 출력 형식인지 확인합니다.
+func checkIOCFormat(format string) error {
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("-iocs는 CSV, -json, -ndjson 출력에서만 사용할 수 있습니다")
+}
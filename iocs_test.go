@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+var iocInput = []EmailRecord{
+	{
+		URLs:             "https://evil.example/login\nhttps://evil.example/login\nhttp://cdn.example.net/a.png",
+		URLDomains:       "evil.example\ncdn.example.net",
+		IP:               "203.0.113.5",
+		ReceivedIPs:      "203.0.113.5\n198.51.100.7",
+		FromEmail:        "Billing@Evil.example",
+		AttachmentHashes: "aa11",
+	},
+	{
+		URLs:       "https://evil.example/login",
+		URLDomains: "evil.example",
+		FromEmail:  "billing@evil.example\nother@example.org",
+	},
+	{},
+}
+
+func writeIOCs(t *testing.T, format string, defang bool, records []EmailRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newIOCRecordWriter(&buf, format, defang)
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// 유형 순서대로, 유형 안에서는 메일 수가 많은 순(같으면 값 순)으로 출력하고, 한 메일에 여러 번 나온 지표는 한 번만 셈
+func TestIOCRows(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewBufferString(writeIOCs(t, formatCSV, false, iocInput))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"유형", "값", "메일 수"},
+		{"url", "https://evil.example/login", "2"},
+		{"url", "http://cdn.example.net/a.png", "1"},
+		{"domain", "evil.example", "2"},
+		{"domain", "cdn.example.net", "1"},
+		{"ip", "198.51.100.7", "1"},
+		{"ip", "203.0.113.5", "1"},
+		{"email", "billing@evil.example", "2"},
+		{"email", "other@example.org", "1"},
+		{"sha256", "aa11", "1"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("행 %d개, want %d개: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !equalStrings(rows[i], want[i]) {
+			t.Errorf("%d번째 행 = %q, want %q", i+1, rows[i], want[i])
+		}
+	}
+}
+
+func TestIOCDefangAndFormats(t *testing.T) {
+	var rows []iocRow
+	if err := json.Unmarshal([]byte(writeIOCs(t, formatJSON, true, iocInput)), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 9 || rows[0] != (iocRow{Type: "url", Value: "hxxps://evil[.]example/login", Count: 2}) {
+		t.Errorf("JSON 행 = %+v", rows)
+	}
+	if got := writeIOCs(t, formatJSON, false, nil); got != "[]\n" {
+		t.Errorf("빈 JSON = %q", got)
+	}
+	if got := writeIOCs(t, formatNDJSON, false, nil); got != "" {
+		t.Errorf("빈 NDJSON = %q", got)
+	}
+	if got := writeIOCs(t, formatNDJSON, false, iocInput[1:2]); got != `{"Type":"url","Value":"https://evil.example/login","Count":1}`+"\n"+
+		`{"Type":"domain","Value":"evil.example","Count":1}`+"\n"+
+		`{"Type":"email","Value":"billing@evil.example","Count":1}`+"\n"+
+		`{"Type":"email","Value":"other@example.org","Count":1}`+"\n" {
+		t.Errorf("NDJSON = %q", got)
+	}
+}
+
+func TestDefang(t *testing.T) {
+	tests := []struct {
+		typ, in, want string
+	}{
+		{"url", "http://evil.com/a.b/c", "hxxp://evil[.]com/a.b/c"},
+		{"url", "HTTPS://Evil.com", "hxxps://Evil[.]com"},
+		{"url", "ftp://files.example.org/x", "fxp://files[.]example[.]org/x"},
+		{"url", "https://evil.com/", "hxxps://evil[.]com/"},
+		{"url", "mailto:a@evil.com", "mailto:a[@]evil[.]com"},
+		{"domain", "evil.co.kr", "evil[.]co[.]kr"},
+		{"ip", "1.2.3.4", "1[.]2[.]3[.]4"},
+		{"ip", "2001:db8::1", "2001:db8::1"},
+		{"email", "a.b@evil.com", "a[.]b[@]evil[.]com"},
+		{"sha256", "ab.cd", "ab.cd"},
+	}
+	for _, tt := range tests {
+		if got := defang(tt.typ, tt.in); got != tt.want {
+			t.Errorf("defang(%s, %q) = %q, want %q", tt.typ, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckIOCFormat(t *testing.T) {
+	for format, ok := range map[string]bool{formatCSV: true, formatJSON: true, formatNDJSON: true, formatTable: false, formatXML: false} {
+		if err := checkIOCFormat(format); (err == nil) != ok {
+			t.Errorf("checkIOCFormat(%s) = %v", format, err)
+		}
+	}
+}
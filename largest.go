@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// largestRecordWriter는 크기가 가장 큰 n개의 메일만 모아 두었다가, 닫을 때 큰 순서로 out에 기록합니다 (-largest).
+// 전체 레코드 대신 n개만 보관하므로 메모리 사용량은 n에 비례합니다.
+type largestRecordWriter struct {
+	out     recordWriter
+	n       int
+	records []EmailRecord // 큰 순서로 정렬된 상태를 유지
+}
+
+func newLargestRecordWriter(out recordWriter, n int) *largestRecordWriter {
+	return &largestRecordWriter{out: out, n: n}
+}
+
+// largerRecord는 a가 b보다 앞에 와야 하는지 반환합니다. 크기가 같으면 RecordID 순으로 정렬하여 결과를 고정합니다.
+// Folder/OriginalFile은 여러 입력 루트에서 겹칠 수 있으므로 RecordID가 같을 때(내용과 파일명이 같은 메일)만 씁니다.
+func largerRecord(a, b EmailRecord) bool {
+	if a.MessageSize != b.MessageSize {
+		return a.MessageSize > b.MessageSize
+	}
+	if a.RecordID != b.RecordID {
+		return a.RecordID < b.RecordID
+	}
+	return filepath.Join(a.Folder, a.OriginalFile) < filepath.Join(b.Folder, b.OriginalFile)
+}
+
+func (w *largestRecordWriter) WriteRecord(r EmailRecord) error {
+	i := sort.Search(len(w.records), func(i int) bool { return largerRecord(r, w.records[i]) })
+	if i >= w.n {
+		return nil
+	}
+	if len(w.records) < w.n {
+		w.records = append(w.records, EmailRecord{})
+	}
+	copy(w.records[i+1:], w.records[i:])
+	w.records[i] = r
+	return nil
+}
+
+func (w *largestRecordWriter) Close() error {
+	for _, r := range w.records {
+		if err := w.out.WriteRecord(r); err != nil {
+			return err
+		}
+	}
+	return w.out.Close()
+}
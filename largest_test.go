@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestLargestRecordWriter(t *testing.T) {
+	rec := func(id string, size int64) EmailRecord {
+		// 여러 입력 루트에서 온 것처럼 Folder/OriginalFile은 모두 같게 둠
+		return EmailRecord{RecordID: id, MessageSize: size, Folder: "inbox", OriginalFile: "mail.eml"}
+	}
+	input := []EmailRecord{
+		rec("a", 10), rec("b", 500), rec("c", 30), rec("d", 500), rec("e", 1000), rec("f", 30), rec("g", 1),
+	}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"e"}},
+		{3, []string{"e", "b", "d"}},
+		{5, []string{"e", "b", "d", "c", "f"}},
+		{20, []string{"e", "b", "d", "c", "f", "a", "g"}},
+	}
+	for _, tt := range tests {
+		// 입력 순서와 관계없이 같은 결과여야 함
+		for _, order := range [][]EmailRecord{input, reversed(input)} {
+			out := &recordingWriter{}
+			w := newLargestRecordWriter(out, tt.n)
+			for _, r := range order {
+				if err := w.WriteRecord(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range out.written {
+				got = append(got, r.RecordID)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("-largest %d = %q, want %q", tt.n, got, tt.want)
+			}
+		}
+	}
+}
+
+func reversed(records []EmailRecord) []EmailRecord {
+	out := make([]EmailRecord, len(records))
+	for i, r := range records {
+		out[len(records)-1-i] = r
+	}
+	return out
+}
+
+func TestLargerRecord(t *testing.T) {
+	tests := []struct {
+		a, b EmailRecord
+		want bool
+	}{
+		{EmailRecord{MessageSize: 2}, EmailRecord{MessageSize: 1}, true},
+		{EmailRecord{MessageSize: 1}, EmailRecord{MessageSize: 2}, false},
+		{EmailRecord{MessageSize: 1, RecordID: "a"}, EmailRecord{MessageSize: 1, RecordID: "b"}, true},
+		{EmailRecord{MessageSize: 1, RecordID: "b", Folder: "a"}, EmailRecord{MessageSize: 1, RecordID: "a", Folder: "b"}, false},
+		{EmailRecord{MessageSize: 1, RecordID: "a", Folder: "a"}, EmailRecord{MessageSize: 1, RecordID: "a", Folder: "b"}, true},
+	}
+	for i, tt := range tests {
+		if got := largerRecord(tt.a, tt.b); got != tt.want {
+			t.Errorf("%d: largerRecord = %v, want %v", i, got, tt.want)
+		}
+	}
+}
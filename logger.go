@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 로그 레벨 접두어
+const (
+	levelDebug = "DEBUG"
+	levelInfo  = "INFO"
+	levelWarn  = "WARN"
+	levelError = "ERROR"
+)
+
+// lineLogger는 여러 워커가 동시에 로그를 남겨도 한 줄이 섞이지 않도록
+// 한 줄 전체를 만든 뒤 뮤텍스 안에서 단 한 번의 Write로 출력합니다.
+type lineLogger struct {
+	mu  sync.Mutex
+	out io.Writer
+	now func() time.Time
+}
+
+// logger는 프로그램 전체에서 공유하는 로거입니다. 모든 진단 메시지는 이 로거를 거칩니다.
+var logger = &lineLogger{out: os.Stderr, now: time.Now}
+
+func (l *lineLogger) logf(level, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	// 메시지 안의 개행은 공백으로 바꿔 한 로그가 항상 한 줄이 되도록 함
+	msg = strings.ReplaceAll(strings.TrimRight(msg, "\n"), "\n", " ")
+	line := fmt.Sprintf("%s [%s] %s\n", l.now().Format("2006/01/02 15:04:05"), level, msg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, line)
+}
+
+func debugf(format string, args ...interface{}) { logger.logf(levelDebug, format, args...) }
+func infof(format string, args ...interface{})  { logger.logf(levelInfo, format, args...) }
+func warnf(format string, args ...interface{})  { logger.logf(levelWarn, format, args...) }
+func errorf(format string, args ...interface{}) { logger.logf(levelError, format, args...) }
+
+// fatalf는 ERROR 로그를 남긴 뒤 프로그램을 종료합니다.
+func fatalf(format string, args ...interface{}) {
+	errorf(format, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// byteWriter는 Write 한 번을 한 바이트씩 나눠 기록하여, 잠금 없이 동시에 쓰면 줄이 섞이게 만듭니다.
+type byteWriter struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.mu.Lock()
+		w.buf.WriteByte(b)
+		w.mu.Unlock()
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestLoggerConcurrentLinesDoNotTear(t *testing.T) {
+	const goroutines, perGoroutine = 16, 50
+	w := &byteWriter{}
+	fixed := time.Date(2024, 3, 5, 14, 22, 10, 0, time.UTC)
+	l := &lineLogger{out: w, now: func() time.Time { return fixed }}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				l.logf(levelWarn, "worker %02d message %03d: %s", g, i, strings.Repeat("x", 40))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*perGoroutine {
+		t.Fatalf("줄 수 = %d, want %d", len(lines), goroutines*perGoroutine)
+	}
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		var g, i int
+		var rest string
+		if _, err := fmt.Sscanf(line, "2024/03/05 14:22:10 [WARN] worker %02d message %03d: %s", &g, &i, &rest); err != nil || rest != strings.Repeat("x", 40) {
+			t.Fatalf("섞인 줄: %q", line)
+		}
+		seen[line] = true
+	}
+	if len(seen) != len(lines) {
+		t.Errorf("중복된 줄이 있음: 서로 다른 줄 %d개, 전체 %d줄", len(seen), len(lines))
+	}
+}
+
+func TestLoggerKeepsOneLinePerMessage(t *testing.T) {
+	fixed := time.Date(2024, 3, 5, 14, 22, 10, 0, time.UTC)
+	tests := []struct {
+		format string
+		args   []interface{}
+		want   string
+	}{
+		{"plain", nil, "2024/03/05 14:22:10 [INFO] plain\n"},
+		{"trailing newline\n", nil, "2024/03/05 14:22:10 [INFO] trailing newline\n"},
+		{"파싱 실패: %v", []interface{}{"line1\nline2"}, "2024/03/05 14:22:10 [INFO] 파싱 실패: line1 line2\n"},
+	}
+	for _, tt := range tests {
+		w := &byteWriter{}
+		l := &lineLogger{out: w, now: func() time.Time { return fixed }}
+		l.logf(levelInfo, tt.format, tt.args...)
+		if got := w.buf.String(); got != tt.want {
+			t.Errorf("logf(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
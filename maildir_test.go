@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMaildir는 root 아래에 maildir 파일들을 만듭니다 (경로는 "/" 구분).
+func writeMaildir(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("From: a@example.com\r\n\r\nbody\r\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// cur/, new/ 아래 파일은 확장자와 관계없이 수집하고, tmp/와 "."으로 시작하는 파일, 루트의 다른 파일은 제외.
+// -r이면 Maildir++ 하위 폴더도 수집하지만 cur/, new/ 아래의 디렉토리는 들어가지 않음
+func TestCollectMaildir(t *testing.T) {
+	root := t.TempDir()
+	writeMaildir(t, root,
+		"cur/1700000000.1.host:2,S",
+		"new/1700000001.2.host",
+		"new/message.eml",
+		"tmp/1700000002.3.host",
+		"cur/.hidden",
+		"dovecot-uidlist",
+		"cur/sub/nested",
+		".Sent/cur/1700000003.4.host:2,S",
+		".Sent/tmp/1700000004.5.host",
+	)
+	tests := []struct {
+		recursive   bool
+		want        []string
+		wantSkipped int
+	}{
+		{false, []string{"cur/1700000000.1.host:2,S", "new/1700000001.2.host", "new/message.eml"}, 2},
+		{true, []string{".Sent/cur/1700000003.4.host:2,S", "cur/1700000000.1.host:2,S", "new/1700000001.2.host", "new/message.eml"}, 2},
+	}
+	for _, tt := range tests {
+		files, stats := collectFiles([]string{root}, tt.recursive, true, 1)
+		var got []string
+		for _, f := range files {
+			rel, err := filepath.Rel(root, f.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, filepath.ToSlash(rel))
+			if f.root != root || f.size == 0 {
+				t.Errorf("-r=%v, %s: root = %q, size = %d", tt.recursive, rel, f.root, f.size)
+			}
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("-r=%v: 파일 = %q, want %q", tt.recursive, got, tt.want)
+		}
+		if stats.skipped != tt.wantSkipped || len(stats.failures) != 0 {
+			t.Errorf("-r=%v: 제외 %d개, 실패 %v, want 제외 %d개", tt.recursive, stats.skipped, stats.failures, tt.wantSkipped)
+		}
+	}
+}
+
+func TestCollectMaildirMissingRoot(t *testing.T) {
+	files, stats := collectFiles([]string{filepath.Join(t.TempDir(), "missing")}, false, true, 1)
+	if len(files) != 0 || len(stats.failures) != 1 {
+		t.Errorf("파일 %d개, 실패 %v", len(files), stats.failures)
+	}
+}
+
+func TestMaildirName(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(root, "INBOX", "cur", "1"), "INBOX"},
+		{filepath.Join(root, "INBOX", ".Sent", "new", "2"), "Sent"},
+		{filepath.Join(root, "INBOX", ".Work.Reports", "cur", "3"), "Work.Reports"},
+	}
+	for _, tt := range tests {
+		if got := maildirName(tt.path); got != tt.want {
+			t.Errorf("maildirName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	// 상대 경로는 현재 디렉토리 기준으로 이름을 정함
+	if err := os.MkdirAll(filepath.Join(root, "Archive", "cur"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, filepath.Join(root, "Archive"))
+	if got := maildirName(filepath.Join("cur", "4")); got != "Archive" {
+		t.Errorf("상대 경로 maildirName = %q, want Archive", got)
+	}
+}
@@ -1,66 +1,33 @@
 package main
 
 import (
-	"bufio"
-	"encoding/csv"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/emersion/go-message"
 	messageMail "github.com/emersion/go-message/mail"
-	"golang.org/x/net/html"
-	"golang.org/x/net/html/charset"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/encoding/korean"
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/encoding/traditionalchinese"
-	"golang.org/x/text/transform"
 )
 
-// 미리 컴파일한 정규식: HTML 파싱 실패 시 fallback 용도로 사용
-var urlRegex = regexp.MustCompile(`https?://[^\s"']+`)
-
-func init() {
-	// go-message의 CharsetReader를 설정하여 다양한 문자셋 지원
-	message.CharsetReader = func(cs string, input io.Reader) (io.Reader, error) {
-		switch strings.ToLower(cs) {
-		case "euc-kr", "ks_c_5601-1987":
-			return transform.NewReader(input, korean.EUCKR.NewDecoder()), nil
-		case "iso-8859-1":
-			return transform.NewReader(input, charmap.ISO8859_1.NewDecoder()), nil
-		case "iso-8859-2":
-			return transform.NewReader(input, charmap.ISO8859_2.NewDecoder()), nil
-		case "windows-1252":
-			return transform.NewReader(input, charmap.Windows1252.NewDecoder()), nil
-		case "windows-1251":
-			return transform.NewReader(input, charmap.Windows1251.NewDecoder()), nil
-		case "iso-2022-jp":
-			return transform.NewReader(input, japanese.ISO2022JP.NewDecoder()), nil
-		case "ascii":
-			return input, nil
-		case "gb2312":
-			return transform.NewReader(input, simplifiedchinese.GB18030.NewDecoder()), nil
-		case "big5":
-			return transform.NewReader(input, traditionalchinese.Big5.NewDecoder()), nil
-		default:
-			return charset.NewReaderLabel(cs, input)
-		}
-	}
-}
+// 미리 컴파일한 정규식: HTML 파싱 실패 시 fallback 용도로 사용.
+// "<", ">"에서 끊어 "a<br>https://b"처럼 태그로만 이어진 뒤 URL을 앞 URL에 붙여 잃지 않도록 함
+var urlRegex = regexp.MustCompile(`https?://[^\s"'<>]+`)
 
 // EmailRecord는 EML 파일에서 추출한 정보를 담는 구조체입니다.
 type EmailRecord struct {
+	RecordID     string
 	URLDomains   string
 	Folder       string
 	Subject      string
@@ -72,239 +39,1552 @@ type EmailRecord struct {
 	IP           string
 	URLs         string
 	OriginalFile string
+
+	ListID          string
+	ListUnsubscribe string
+	IsBulk          bool
+
+	AttachmentCount int
+	HasHTML         bool
+	HasText         bool
+	HTMLCharset     string
+
+	CleanSubject string
+	IsReply      bool
+	IsForward    bool
+
+	DateSource string
+
+	PrimaryFromName  string
+	PrimaryFromEmail string
+	Sender           string
+
+	ParseQuality string
+	AuthResults  string
+
+	SubjectDecodeError bool
+
+	Organization string
+	Priority     string
+
+	FromRaw string
+	ToRaw   string
+
+	MessageID string
+
+	MailtoLinks         string
+	TelLinks            string
+	SuspiciousHrefCount int
+
+	HiddenHTMLUsed bool
+
+	URLSources string
+
+	RenamedPath string
+
+	SubjectRaw string
+
+	CharsetFallback bool
+
+	AttachmentHashes string
+
+	WordCount   int
+	LinkCount   int
+	LinkDensity float64
+
+	NormalizedSubject string
+
+	HasCalendar  bool
+	CalOrganizer string
+	CalSummary   string
+
+	MessageSize int64
+
+	ToGroups string
+
+	BodyPreview string
+
+	ResolvedDomains string
+	DomainHasA      string
+	DomainHasMX     string
+	DomainIPs       string
+
+	WhoisDomains  string
+	DomainAgeDays string
+	YoungDomains  string
+
+	SentDateRaw string
+	DateLayout  string
+
+	CcName      string
+	CcEmail     string
+	DeliveredTo string
+
+	ReceivedIPs string
+
+	Matches    string
+	MatchCount int
+
+	URLCount         int
+	InternalURLCount int
+	URLCounts        string
+
+	ContentLanguage string
+
+	DateZone       string
+	SentDateOffset string
+
+	SubjectCharset string
+
+	AttachmentNames string
+	AttachmentTypes string
+
+	ReplyTo    string
+	ReturnPath string
+
+	LinkTextMismatches int
+
+	Score        int
+	ScoreSignals string
+
+	ThreadIndex string
+	ThreadTopic string
+
+	HasShortener bool
+	ShortURLs    string
+	ExpandedURLs string
 }
 
 func main() {
 	var jsonOutput bool
 	var csvOutput bool
+	var tableOutput bool
+	var xmlOutput bool
+	var yamlOutput bool
+	var noColor bool
 	var recursive bool
 	var htmlOutDir string
 	var renameByHeader bool
 	var renameByHeaderTo string
+	var onConflict string
 	var workerCount int
+	var bufferSize int
+	var errorReport string
+	var failFast bool
+	var hasAttachment bool
+	var hasHTML bool
+	var hasText bool
+	var hasURLs bool
+	var extraDateLayouts stringList
+	var sinceFile string
+	var flattenSep string
+	var htmlSelect string
+	var urlsOnly bool
+	var keepRaw bool
+	var normSubject bool
+	var attachmentReportPath string
+	var urlsPerMessage bool
+	var ndjsonOutput bool
+	var ordered bool
+	var anonymize bool
+	var headersOnly bool
+	var allowFTP bool
+	var stripWWW bool
+	var outputPath string
+	var rotateRecords int
+	var rotateSize string
+	var seenDB string
+	var dedupeByMsgID, skipDuplicateActions bool
+	var manifestPath string
+	var duplicatesReport string
+	var largest int
+	var head, tail int
+	var diffPath string
+	var maildir bool
+	var stripQuotes bool
+	var noDedupURLs bool
+	var since, until string
+	var undatedPolicy string
+	var langList, langNotList, langUnknownPolicy string
+	var resolveDomains bool
+	var fromPatterns, notFromPatterns stringList
+	var toPatterns, notToPatterns stringList
+	var hasHeaders, headerMatches stringList
+	var whois bool
+	var templatePath string
+	var templateAll bool
+	var subjectMatch, subjectNotMatch string
+	var subjectCaseSensitive bool
+	var whoisYoungDays int
+	var whoisInterval time.Duration
+	var rdapURL string
+	var resolveConcurrency int
+	var resetSeen bool
+	var outputEncoding string
+	var selfTest bool
+	var whereExpr string
+	var groupBy string
+	var sendersOnly bool
+	var histogram, histogramTZ string
+	var iocs, defangIOCs bool
+	var jsonOutDir string
+	var grepPatterns stringList
+	var grepIgnoreCase, grepCount bool
+	var grepContext int
+	var minURLs, maxURLs int
+	var score bool
+	var minScore int
+	var attachmentMatch, attachmentTypes stringList
+	var urlDomainDeny, urlDomainDenyFiles stringList
+	var shortenerLists stringList
+	var expandShorteners bool
+	var expandTimeout time.Duration
+	var matchURLDomain, matchURLDomainFiles stringList
+	var ownDomains string
 
 	flag.BoolVar(&jsonOutput, "json", false, "JSON 형식으로 출력")
+	flag.BoolVar(&ndjsonOutput, "ndjson", false, "한 줄에 레코드 하나씩 JSON으로 출력 (NDJSON)")
 	flag.BoolVar(&csvOutput, "csv", false, "CSV 형식으로 출력")
+	flag.BoolVar(&xmlOutput, "xml", false, "XML 형식으로 출력")
+	flag.BoolVar(&yamlOutput, "yaml", false, "YAML 형식으로 출력")
+	flag.BoolVar(&urlsOnly, "urls-only", false, "추출한 URL만 한 줄에 하나씩 출력 (실행 전체에서 중복 제거)")
+	flag.BoolVar(&urlsPerMessage, "urls-per-message", false, "-urls-only에서 실행 전체 대신 메일 단위로만 중복 제거")
+	flag.StringVar(&outputPath, "o", "", "결과를 표준 출력 대신 이 경로의 파일에 저장 (.gz로 끝나면 gzip으로 압축)")
+	flag.StringVar(&outputEncoding, "output-encoding", outputUTF8, "출력 인코딩: utf-8 또는 euc-kr (나타낼 수 없는 문자는 ?로 바꿈)")
+	flag.IntVar(&rotateRecords, "rotate-records", 0, "CSV/NDJSON 출력을 레코드 N개마다 새 번호 파일(예: out.0001.csv)로 분할 (-o 필요)")
+	flag.StringVar(&rotateSize, "rotate-size", "", "CSV/NDJSON 출력 파일이 이 크기(예: 100MB, 512K)에 이르면 새 번호 파일로 분할 (-o 필요)")
+	flag.StringVar(&diffPath, "diff", "", "이전 실행의 JSON/NDJSON 출력과 비교하여 추가/삭제된 메일만 NDJSON으로 출력 (Change 필드: added, removed)")
+	flag.BoolVar(&sendersOnly, "senders-only", false, "메일별 행 대신 서로 다른 보낸 사람 주소별 행(메일 수, 표시 이름 변형, 처음/마지막 날짜, URL 도메인 수) 출력 (CSV, -json, -ndjson)")
+	flag.StringVar(&histogram, "histogram", "", "메일별 행 대신 발송 시각의 hour(시), weekday(요일), date(날짜)별 메일 수 출력. 터미널이면 막대 그래프 (CSV, -json, -ndjson)")
+	flag.StringVar(&histogramTZ, "tz", "", "-histogram 구간을 나눌 시간대 (예: Asia/Seoul, UTC, +0900). 기본값: 각 메일 Date 헤더의 원래 오프셋")
+	flag.StringVar(&groupBy, "group-by", "", "메일별 행 대신 sender, sender-domain, url-domain, date별 집계 행(메일 수, 처음/마지막 날짜, 받는 사람 수, URL 도메인 수) 출력 (CSV, -json, -ndjson)")
+	flag.BoolVar(&iocs, "iocs", false, "메일별 행 대신 전체 메일의 지표(URL, 도메인, IP, 보낸 사람 주소, 첨부 SHA-256)와 각 지표가 나온 메일 수 출력 (CSV, -json, -ndjson)")
+	flag.BoolVar(&defangIOCs, "defang", false, "-iocs 출력의 지표를 defang 표기로 출력 (hxxp://, [.], [@])")
+	flag.IntVar(&largest, "largest", 0, "크기가 가장 큰 메일 N개만 큰 순서로 출력 (모든 파일을 처리한 뒤 출력)")
+	flag.StringVar(&templatePath, "template", "", "레코드마다 이 Go text/template 파일로 출력 (도우미 함수: lines, join, truncate)")
+	flag.BoolVar(&templateAll, "template-all", false, "-template을 레코드마다 대신 전체 레코드 목록([]EmailRecord)으로 한 번 실행")
+	flag.BoolVar(&tableOutput, "table", false, "터미널용 정렬된 표 형식으로 출력 (날짜, 보낸사람, 제목)")
+	flag.BoolVar(&noColor, "no-color", false, "-table 출력에서 의심 메일 색상 강조 끄기")
 	flag.BoolVar(&recursive, "r", false, "재귀적으로 디렉토리 탐색")
+	flag.BoolVar(&maildir, "maildir", false, "입력을 maildir로 보고 cur/, new/ 아래 파일을 확장자와 관계없이 처리 (tmp/ 제외, -r이면 하위 maildir 포함)")
 	flag.StringVar(&htmlOutDir, "eml2html-to", "", "지정한 경로에 EML 파일을 HTML로 변환하여 저장")
+	flag.StringVar(&jsonOutDir, "json-per-file", "", "지정한 경로에 메일마다 레코드를 JSON 파일(이름.<RecordID>.json)로 저장 (입력 폴더 구조 유지, 화면 출력 생략)")
 	flag.BoolVar(&renameByHeader, "rename-by-header", false, "EML 파일의 날짜-제목 기반으로 파일명을 변경")
 	flag.StringVar(&renameByHeaderTo, "rename-by-header-to", "", "지정한 경로에 EML 파일을 복사한 후 날짜-제목 기반으로 파일명을 변경")
+	flag.StringVar(&onConflict, "on-conflict", conflictSuffix, "재명명/복사 대상 파일이 이미 있을 때: suffix(번호 붙이기), skip(건너뛰기), error(실패 처리)")
 	// 동시 처리할 워커 수 (기본값: CPU 코어 수)
 	flag.IntVar(&workerCount, "workers", runtime.NumCPU(), "동시 처리 워커 수")
+	// 결과 채널 깊이: 출력이 느리면 이 이상 쌓이지 않고 워커가 대기함
+	flag.IntVar(&bufferSize, "buffer", 64, "결과 채널 버퍼 크기 (출력이 느릴 때 메모리 상한)")
+	flag.IntVar(&head, "head", 0, "처음 N개의 레코드만 출력 (-ordered/-largest 정렬 뒤 기준, 파일은 모두 처리)")
+	flag.IntVar(&tail, "tail", 0, "마지막 N개의 레코드만 출력 (-ordered/-largest 정렬 뒤 기준, 파일은 모두 처리)")
+	flag.BoolVar(&ordered, "ordered", false, "결과를 처리 완료 순서 대신 입력 순서(정렬된 경로 순)대로 출력")
+	flag.StringVar(&errorReport, "error-report", "", "실패한 파일 목록을 저장할 경로 (.json이면 JSON, 그 외 CSV)")
+	flag.StringVar(&attachmentReportPath, "attachment-report", "", "첨부 SHA-256별 등장 메일 수와 목록을 저장할 경로 (.json이면 JSON, 그 외 CSV)")
+	flag.BoolVar(&failFast, "fail-fast", false, "첫 파일 처리 실패 시 즉시 중단 (기본값: 실패 파일을 건너뛰고 계속 진행)")
+	// 보낸 사람/받는 사람 필터: 같은 옵션을 여러 번 지정하면 OR, 서로 다른 옵션은 AND
+	flag.Var(&fromPatterns, "from", "보낸 사람 주소/이름에 이 문자열(\"~\"로 시작하면 정규식)이 들어간 메일만 처리, 대소문자 무시 (여러 번 지정하면 OR)")
+	flag.Var(&notFromPatterns, "not-from", "보낸 사람 주소/이름이 이 문자열(\"~\"로 시작하면 정규식)에 맞는 메일은 제외 (여러 번 지정 가능)")
+	flag.Var(&toPatterns, "to", "받는 사람(To/Cc/Delivered-To) 주소/이름에 이 문자열(\"~\"로 시작하면 정규식)이 들어간 메일만 처리, 대소문자 무시 (여러 번 지정하면 OR)")
+	flag.Var(&notToPatterns, "not-to", "받는 사람(To/Cc/Delivered-To) 주소/이름이 이 문자열(\"~\"로 시작하면 정규식)에 맞는 메일은 제외 (여러 번 지정 가능)")
+	// 제목 필터
+	flag.StringVar(&subjectMatch, "subject-match", "", "디코딩한 제목이 이 정규식(RE2)에 맞는 메일만 처리 (기본값: 대소문자 무시)")
+	flag.StringVar(&subjectNotMatch, "subject-not-match", "", "디코딩한 제목이 이 정규식(RE2)에 맞는 메일은 제외")
+	flag.BoolVar(&subjectCaseSensitive, "subject-case-sensitive", false, "-subject-match/-subject-not-match에서 대소문자 구분")
+	flag.Var(&matchURLDomain, "match-url-domain", "이 도메인(\"*.example.com\" 형식 가능, 하위 도메인 포함)의 URL이 하나 이상 있는 메일만 처리 (여러 번 지정 가능)")
+	flag.Var(&matchURLDomainFiles, "match-url-domain-file", "-match-url-domain 도메인 목록 파일 (한 줄에 하나, \"#\" 주석)")
+	flag.StringVar(&ownDomains, "own-domains", "", "URLDomains에서 뺄 자사 도메인 (쉼표 목록 또는 @파일, 하위 도메인 포함). 해당 URL 수는 InternalURLCount에 기록하고 URLs는 그대로 둠")
+	flag.Var(&hasHeaders, "has-header", "이 이름의 헤더가 있는 메일만 처리, 이름은 대소문자 무시 (여러 번 지정하면 AND)")
+	flag.Var(&headerMatches, "header-match", "\"이름=정규식\": 디코딩한 헤더 값이 정규식에 맞는 메일만 처리, 같은 헤더가 여러 개면 하나만 맞아도 됨 (여러 번 지정하면 AND)")
+	flag.StringVar(&whereExpr, "where", "", "레코드 필드 조건식으로 필터 (예: 'FromEmail contains \"@gmail.com\" and SentDate >= \"2024-01-01\"')")
+	flag.Var(&grepPatterns, "grep", "디코딩한 본문(텍스트, 태그를 뺀 HTML)이 이 정규식에 맞는 메일만 처리하고 일치 부분을 Matches 열에 기록 (여러 번 지정하면 OR)")
+	flag.BoolVar(&grepIgnoreCase, "grep-ignore-case", false, "-grep에서 대소문자 무시")
+	flag.IntVar(&grepContext, "grep-context", 40, "-grep의 Matches 열에 일치 부분 앞뒤로 붙일 글자 수")
+	flag.BoolVar(&grepCount, "grep-count", false, "-grep에 맞는 메일 수만 출력")
+	// MIME 구조 필터: 함께 지정하면 모두 만족(AND)하는 메일만 처리
+	flag.BoolVar(&hasAttachment, "has-attachment", false, "첨부 파일이 있는 메일만 처리")
+	flag.BoolVar(&hasHTML, "has-html", false, "HTML 본문이 있는 메일만 처리")
+	flag.BoolVar(&hasText, "has-text", false, "텍스트(text/plain) 본문이 있는 메일만 처리")
+	flag.BoolVar(&hasURLs, "has-urls", false, "URL이 하나 이상 있는 메일만 처리 (-url-domain-deny로 제거한 뒤 기준)")
+	flag.Var(&attachmentMatch, "attachment-match", "디코딩한 첨부 파일명이 정규식(대소문자 무시)에 맞는 첨부가 있는 메일만 처리 (여러 번 지정하면 OR, 예: '\\.html?$')")
+	flag.Var(&attachmentTypes, "attachment-type", "선언된 MIME 형식이 TYPE(예: application/x-msdownload, image/*)인 첨부가 있는 메일만 처리 (여러 번 지정하면 OR)")
+	flag.IntVar(&minURLs, "min-urls", 0, "서로 다른 URL이 N개 이상인 메일만 처리 (-url-domain-deny로 제거한 뒤 기준)")
+	flag.IntVar(&maxURLs, "max-urls", -1, "서로 다른 URL이 N개 이하인 메일만 처리 (기본값 -1: 제한 없음)")
+	flag.BoolVar(&hasURLs, "only-with-urls", false, "-has-urls와 같음")
+	flag.BoolVar(&headersOnly, "headers-only", false, "헤더만 읽는 빠른 모드 (MIME 파트를 읽지 않으므로 URL/도메인 열은 비어 있음)")
+	flag.StringVar(&htmlSelect, "html-select", "first", "URL 추출에 사용할 HTML 파트: first(대표 본문 하나) 또는 all(모든 text/html 파트)")
+	flag.BoolVar(&keepRaw, "keep-raw", false, "디코딩하지 않은 Subject 헤더 원문을 SubjectRaw 열에 함께 기록")
+	flag.BoolVar(&normSubject, "normalize-subject", false, "폭 없는 문자와 동형 문자(전각, 키릴 등)를 정리한 제목을 NormalizedSubject 열에 기록")
+	flag.BoolVar(&noDedupURLs, "no-dedup-urls", false, "같은 URL도 중복 제거 없이 나온 순서대로 모두 기록하고, URL별 출현 횟수를 URLCounts에 \"횟수 URL\" 줄로 기록")
+	flag.BoolVar(&stripQuotes, "strip-quotes", false, "본문 미리보기와 단어 수에서 \">\" 인용 줄, 인용 블록, \"-- \" 이후 서명을 제외")
+	flag.BoolVar(&allowFTP, "allow-ftp", false, "ftp: 링크도 URL로 추출 (기본값: http/https만)")
+	flag.BoolVar(&stripWWW, "strip-www", false, "URL 도메인 열에서 www. 접두어 제거")
+	flag.Var(&urlDomainDeny, "url-domain-deny", "이 도메인(\"*.example.com\" 형식 가능, 하위 도메인 포함)의 URL을 URL/URL 도메인 열에서 제거 (여러 번 지정 가능)")
+	flag.Var(&urlDomainDenyFiles, "url-domain-deny-file", "-url-domain-deny 도메인 목록 파일 (한 줄에 하나, \"#\" 주석)")
+	flag.Var(&shortenerLists, "shortener-list", "기본 목록에 더할 단축 URL 서비스 도메인 목록 파일 (한 줄에 하나, \"#\" 주석, 여러 번 지정 가능)")
+	flag.BoolVar(&expandShorteners, "expand-shorteners", false, "단축 URL의 리디렉션(최대 5번)을 따라가 최종 주소를 ExpandedURLs에, 그 도메인을 URLDomains에 기록 (네트워크 필요, URL별 결과 캐시)")
+	flag.DurationVar(&expandTimeout, "expand-timeout", 10*time.Second, "-expand-shorteners의 단축 URL 하나에 허용하는 시간 (리디렉션 포함)")
+	flag.BoolVar(&resolveDomains, "resolve-domains", false, "보낸 사람/URL 도메인의 A·MX 레코드를 조회하여 기록 (네트워크 필요, 도메인별 결과 캐시)")
+	flag.IntVar(&resolveConcurrency, "resolve-concurrency", 8, "-resolve-domains의 동시 DNS 조회 수")
+	flag.BoolVar(&whois, "whois", false, "URL 도메인의 등록일을 RDAP로 조회하여 DomainAgeDays에 기록 (네트워크 필요, 등록 도메인별 결과 캐시)")
+	flag.BoolVar(&score, "score", false, "피싱 의심 신호(Reply-To/Return-Path 도메인 불일치, 링크 텍스트 불일치, IDN 도메인, 단축 URL, 의심 첨부, 많은 URL, 무료 메일의 회사 이름)로 Score와 ScoreSignals 기록")
+	flag.IntVar(&minScore, "min-score", 0, "Score가 N 이상인 메일만 처리 (-score를 함께 켬)")
+	flag.IntVar(&whoisYoungDays, "whois-young-days", 30, "-whois에서 등록된 지 이 일수 미만인 도메인을 YoungDomains에 기록")
+	flag.DurationVar(&whoisInterval, "whois-interval", time.Second, "-whois의 RDAP 요청 사이 최소 간격")
+	flag.StringVar(&rdapURL, "rdap-url", defaultRDAPURL, "-whois에서 도메인 이름을 붙여 조회할 RDAP 주소")
+	flag.BoolVar(&anonymize, "anonymize-ips", false, "출력 전 IP 주소 익명화 (IPv4 마지막 옥텟, IPv6 하위 80비트를 0으로)")
+	flag.StringVar(&manifestPath, "sha256-manifest", "", "모든 입력 파일의 SHA-256을 sha256sum -c로 검증할 수 있는 \"<해시>  <경로>\" 형식으로 저장할 경로 (경로는 현재 디렉토리 기준)")
+	flag.BoolVar(&dedupeByMsgID, "dedupe-by-msgid", false, "같은 Message-ID의 메일은 경로 순으로 첫 파일만 출력 (Message-ID가 없는 파일은 제외하지 않음, 처리 전에 헤더를 한 번 더 읽음)")
+	flag.BoolVar(&skipDuplicateActions, "skip-duplicate-actions", false, "-dedupe-by-msgid로 건너뛴 중복 파일은 HTML 변환/재명명/JSON 파일 생성도 하지 않음")
+	flag.StringVar(&duplicatesReport, "duplicates-report", "", "-dedupe-by-msgid의 Message-ID별 유지/중복 파일 목록을 저장할 경로 (.json이면 JSON, 그 외 CSV)")
+	flag.StringVar(&seenDB, "seen-db", "", "처리한 메일(Message-ID, 없으면 파일 SHA-256)을 기록하는 DB 경로. 이전 실행에서 처리한 메일은 건너뜀")
+	flag.BoolVar(&resetSeen, "reset-seen", false, "-seen-db에 기록된 메일 목록을 비우고 시작")
+	flag.StringVar(&since, "since", "", "이 날짜(2024-03-01) 또는 RFC 3339 시각 이후에 보낸 메일만 처리")
+	flag.StringVar(&until, "until", "", "이 날짜(해당 날짜 포함) 또는 RFC 3339 시각 이전에 보낸 메일만 처리")
+	flag.StringVar(&langList, "lang", "", "Content-Language에 선언된 언어가 목록(쉼표로 구분, 예: ko,en)에 있는 메일만 처리 (ko는 ko-KR도 포함)")
+	flag.StringVar(&langNotList, "lang-not", "", "Content-Language에 선언된 언어가 목록에 있는 메일은 제외")
+	flag.StringVar(&langUnknownPolicy, "lang-unknown-policy", langUnknownInclude, "-lang/-lang-not 사용 시 언어를 알 수 없는(Content-Language 없는) 메일 처리: include(포함) 또는 exclude(제외)")
+	flag.StringVar(&undatedPolicy, "undated-policy", undatedInclude, "-since/-until 사용 시 날짜를 알 수 없는 메일 처리: include(포함) 또는 exclude(제외)")
+	flag.StringVar(&sinceFile, "since-file", "", "이 파일의 수정 시각보다 오래된 입력 파일은 건너뜀 (증분 처리용)")
+	flag.StringVar(&flattenSep, "flatten-multiline", "", "CSV 출력에서 여러 줄 값(URL 목록 등)을 이 구분자로 이어 한 줄로 출력 (예: \" | \")")
+	flag.BoolVar(&selfTest, "selftest", false, "내장 예제로 지원 문자셋(EUC-KR, Big5 등)의 디코딩을 확인하고 결과를 출력한 뒤 종료 (실패가 있으면 종료 코드 1)")
+	flag.Var(&extraDateLayouts, "date-layout", "비표준 Date 헤더에 추가로 시도할 Go 시간 형식 (여러 번 지정 가능)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "사용법:\n")
-		fmt.Fprintf(os.Stderr, "  %s [-r] [-json|-csv] [-eml2html-to PATH] [-rename-by-header] [-rename-by-header-to PATH] [디렉토리 경로]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [-r] [-json|-ndjson|-csv|-xml|-yaml|-table|-urls-only] [-ordered] [-eml2html-to PATH] [-rename-by-header] [-rename-by-header-to PATH] <디렉토리 경로>...\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
+	if selfTest {
+		if !runSelfTest(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if flag.NArg() == 0 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	dateLayouts = append(extraDateLayouts, dateLayouts...)
+
+	if headersOnly && (hasAttachment || hasHTML || hasText || hasURLs || minURLs > 0 || maxURLs >= 0 || len(attachmentMatch) > 0 || len(attachmentTypes) > 0 || htmlOutDir != "" || urlsOnly || attachmentReportPath != "") {
+		fatalf("-headers-only는 MIME 파트를 읽지 않으므로 -has-attachment/-has-html/-has-text/-has-urls/-min-urls/-max-urls/-attachment-match/-attachment-type/-eml2html-to/-urls-only/-attachment-report와 함께 사용할 수 없습니다")
+	}
+
+	if !validConflictPolicy(onConflict) {
+		fatalf("-on-conflict 값은 suffix, skip, error 중 하나여야 합니다: %q", onConflict)
+	}
+	if htmlSelect != "first" && htmlSelect != "all" {
+		fatalf("-html-select 값은 first 또는 all이어야 합니다: %q", htmlSelect)
+	}
+
 	// 기본 출력은 CSV
-	if !jsonOutput && !csvOutput {
-		csvOutput = true
+	format := formatCSV
+	if urlsOnly {
+		format = formatURLs
+	} else if templatePath != "" {
+		format = formatTemplate
+	} else if jsonOutput {
+		format = formatJSON
+	} else if ndjsonOutput {
+		format = formatNDJSON
+	} else if xmlOutput {
+		format = formatXML
+	} else if yamlOutput {
+		format = formatYAML
+	} else if tableOutput && !csvOutput {
+		format = formatTable
 	}
 
-	inputRoot := flag.Arg(0)
-	var filePaths []string
-	var err error
-	if recursive {
-		filePaths, err = collectFilePathsRecursive(inputRoot)
-	} else {
-		filePaths, err = collectFilePathsNonRecursive(inputRoot)
+	var rotateBytes int64
+	if rotateSize != "" {
+		n, err := parseSize(rotateSize)
+		if err != nil {
+			fatalf("-rotate-size: %v", err)
+		}
+		rotateBytes = n
 	}
-	if err != nil {
-		log.Fatalf("[ERROR] 파일 경로 수집 실패: %v", err)
+	rotating := rotateRecords > 0 || rotateBytes > 0
+	if rotateRecords < 0 {
+		fatalf("-rotate-records 값은 0 이상이어야 합니다: %d", rotateRecords)
+	}
+	if rotating && outputPath == "" {
+		fatalf("-rotate-records/-rotate-size는 -o로 출력 경로를 지정해야 합니다")
+	}
+	var previous []EmailRecord
+	if diffPath != "" {
+		if rotating || largest > 0 {
+			fatalf("-diff는 -rotate-records/-rotate-size/-largest와 함께 사용할 수 없습니다")
+		}
+		records, err := loadPreviousRecords(diffPath)
+		if err != nil {
+			fatalf("-diff 이전 출력 읽기 실패: %v", err)
+		}
+		previous = records
+	}
+	if rotating && format != formatCSV && format != formatNDJSON {
+		fatalf("-rotate-records/-rotate-size는 CSV 또는 NDJSON 출력에서만 사용할 수 있습니다")
 	}
 
-	// 동시 처리로 EML 파일을 파싱하고 필요 시 HTML 변환 및 재명명 수행
-	records := processFilesConcurrently(filePaths, workerCount, htmlOutDir, renameByHeader, renameByHeaderTo, inputRoot)
+	if groupBy != "" {
+		if !validGroupBy(groupBy) {
+			fatalf("-group-by 값은 sender, sender-domain, url-domain, date 중 하나여야 합니다: %q", groupBy)
+		}
+		if err := checkGroupByFormat(format); err != nil {
+			fatalf("%v", err)
+		}
+		if rotating || diffPath != "" {
+			fatalf("-group-by는 -rotate-records/-rotate-size/-diff와 함께 사용할 수 없습니다")
+		}
+	}
 
-	// 출력 옵션에 따라 결과를 화면에 출력
-	if htmlOutDir != "" || renameByHeader || renameByHeaderTo != "" {
-		fmt.Fprintf(os.Stderr, "[DEBUG] 파일 변환 및 재명명 작업 완료. 화면 출력 생략.\n")
-	} else {
-		printOutput(records, jsonOutput, csvOutput)
+	if sendersOnly {
+		if err := checkSendersFormat(format); err != nil {
+			fatalf("%v", err)
+		}
+		if rotating || diffPath != "" || groupBy != "" {
+			fatalf("-senders-only는 -rotate-records/-rotate-size/-diff/-group-by와 함께 사용할 수 없습니다")
+		}
 	}
-}
 
-// collectFilePathsRecursive는 주어진 디렉토리를 재귀적으로 탐색하여 .eml 파일 경로 목록을 반환합니다.
-func collectFilePathsRecursive(root string) ([]string, error) {
-	var paths []string
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+	var histogramLoc *time.Location
+	if histogram != "" {
+		if !validHistogram(histogram) {
+			fatalf("-histogram 값은 hour, weekday, date 중 하나여야 합니다: %q", histogram)
+		}
+		if err := checkHistogramFormat(format); err != nil {
+			fatalf("%v", err)
+		}
+		if rotating || diffPath != "" || groupBy != "" || sendersOnly {
+			fatalf("-histogram은 -rotate-records/-rotate-size/-diff/-group-by/-senders-only와 함께 사용할 수 없습니다")
+		}
+	}
+	if histogramTZ != "" {
+		if histogram == "" {
+			fatalf("-tz는 -histogram과 함께 사용해야 합니다")
+		}
+		loc, err := parseTZ(histogramTZ)
 		if err != nil {
-			return err
+			fatalf("-tz: %v", err)
+		}
+		histogramLoc = loc
+	}
+
+	if iocs {
+		if err := checkIOCFormat(format); err != nil {
+			fatalf("%v", err)
 		}
-		if !d.IsDir() && shouldProcessFile(d.Name()) {
-			paths = append(paths, path)
+		if rotating || diffPath != "" || groupBy != "" || sendersOnly || histogram != "" {
+			fatalf("-iocs는 -rotate-records/-rotate-size/-diff/-group-by/-senders-only/-histogram과 함께 사용할 수 없습니다")
 		}
-		return nil
-	})
-	return paths, err
-}
+	} else if defangIOCs {
+		fatalf("-defang은 -iocs와 함께 사용해야 합니다")
+	}
 
-// collectFilePathsNonRecursive는 주어진 디렉토리에서 .eml 파일 경로만 반환합니다.
-func collectFilePathsNonRecursive(root string) ([]string, error) {
-	var paths []string
-	entries, err := os.ReadDir(root)
+	var tmpl *template.Template
+	if format == formatTemplate {
+		t, err := loadTemplate(templatePath)
+		if err != nil {
+			fatalf("-template 읽기 실패: %v", err)
+		}
+		tmpl = t
+	} else if templateAll {
+		fatalf("-template-all은 -template과 함께 사용해야 합니다")
+	}
+	if largest < 0 {
+		fatalf("-largest 값은 0 이상이어야 합니다: %d", largest)
+	}
+	if head < 0 || tail < 0 {
+		fatalf("-head/-tail 값은 0 이상이어야 합니다: %d, %d", head, tail)
+	}
+	if head > 0 && tail > 0 {
+		fatalf("-head와 -tail은 함께 사용할 수 없습니다")
+	}
+	if err := checkHeadTail(head, tail, []outputMode{
+		{"-group-by", groupBy != ""}, {"-senders-only", sendersOnly}, {"-histogram", histogram != ""},
+		{"-iocs", iocs}, {"-diff", diffPath != ""}, {"-grep-count", grepCount},
+	}); err != nil {
+		fatalf("%v", err)
+	}
+	var dates *dateRange
+	if since != "" || until != "" {
+		dates = &dateRange{}
+		var err error
+		if since != "" {
+			if dates.since, err = parseDateFlag(since, false); err != nil {
+				fatalf("-since: %v", err)
+			}
+		}
+		if until != "" {
+			if dates.until, err = parseDateFlag(until, true); err != nil {
+				fatalf("-until: %v", err)
+			}
+		}
+		switch undatedPolicy {
+		case undatedInclude:
+			dates.includeUndated = true
+		case undatedExclude:
+		default:
+			fatalf("-undated-policy 값은 include 또는 exclude여야 합니다: %q", undatedPolicy)
+		}
+	}
+	filters := structureFilters(hasAttachment, hasHTML, hasText, hasURLs)
+	filters = append(filters, urlCountFilters(minURLs, maxURLs)...)
+	attFilters, err := attachmentFilters(attachmentMatch, attachmentTypes)
 	if err != nil {
-		return nil, err
+		fatalf("%v", err)
+	}
+	filters = append(filters, attFilters...)
+	langs, notLangs := parseLanguageList(langList), parseLanguageList(langNotList)
+	switch langUnknownPolicy {
+	case langUnknownInclude, langUnknownExclude:
+	default:
+		fatalf("-lang-unknown-policy 값은 include 또는 exclude여야 합니다: %q", langUnknownPolicy)
 	}
-	for _, entry := range entries {
-		if !entry.IsDir() && shouldProcessFile(entry.Name()) {
-			paths = append(paths, filepath.Join(root, entry.Name()))
+	filters = append(filters, languageFilters(langs, notLangs, langUnknownPolicy == langUnknownInclude)...)
+	senders, err := senderFilters(fromPatterns, notFromPatterns)
+	if err != nil {
+		fatalf("-from/-not-from: %v", err)
+	}
+	filters = append(filters, senders...)
+	recipients, err := recipientFilters(toPatterns, notToPatterns)
+	if err != nil {
+		fatalf("-to/-not-to: %v", err)
+	}
+	filters = append(filters, recipients...)
+	subjects, err := subjectFilters(subjectMatch, subjectNotMatch, subjectCaseSensitive)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	filters = append(filters, subjects...)
+	where, err := whereFilters(whereExpr)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	filters = append(filters, where...)
+	filters = append(filters, scoreFilters(minScore)...)
+	var grep *bodyGrep
+	if len(grepPatterns) > 0 {
+		if headersOnly {
+			fatalf("-grep은 본문을 읽어야 하므로 -headers-only와 함께 사용할 수 없습니다")
 		}
+		if grep, err = newBodyGrep(grepPatterns, grepIgnoreCase, grepContext); err != nil {
+			fatalf("%v", err)
+		}
+	} else if grepCount {
+		fatalf("-grep-count는 -grep과 함께 사용해야 합니다")
+	}
+	filters = append(filters, grepFilters(grep)...)
+	hdrFilters, err := newHeaderFilters(hasHeaders, headerMatches)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	urlAllow, err := loadDomainList(matchURLDomain, matchURLDomainFiles)
+	if err != nil {
+		fatalf("-match-url-domain 목록 읽기 실패: %v", err)
+	}
+	filters = append(filters, urlDomainFilters(urlAllow)...)
+	urlDeny, err := loadDomainList(urlDomainDeny, urlDomainDenyFiles)
+	if err != nil {
+		fatalf("-url-domain-deny 목록 읽기 실패: %v", err)
+	}
+	var own domainList
+	if ownDomains != "" {
+		if own, err = parseDomainArg(ownDomains); err != nil {
+			fatalf("-own-domains 목록 읽기 실패: %v", err)
+		}
+	}
+
+	outEnc, err := outputEncoder(outputEncoding)
+	if err != nil {
+		fatalf("-output-encoding: %v", err)
+	}
+
+	if resetSeen && seenDB == "" {
+		fatalf("-reset-seen은 -seen-db와 함께 사용해야 합니다")
+	}
+	if (skipDuplicateActions || duplicatesReport != "") && !dedupeByMsgID {
+		fatalf("-skip-duplicate-actions/-duplicates-report는 -dedupe-by-msgid와 함께 사용해야 합니다")
+	}
+
+	inputRoots := flag.Args()
+	files, collected := collectFiles(inputRoots, recursive, maildir, workerCount)
+	if len(files) == 0 && len(collected.failures) > 0 {
+		fatalf("파일 경로 수집 실패: %s (%s)", collected.failures[0].File, collected.failures[0].Error)
+	}
+	if sinceFile != "" {
+		info, err := os.Stat(sinceFile)
+		if err != nil {
+			fatalf("-since-file 기준 파일 확인 실패: %v", err)
+		}
+		var tooOld int
+		files, tooOld = filterSince(files, info.ModTime())
+		infof("기준 파일(%s)보다 오래되어 건너뛴 파일: %d개", sinceFile, tooOld)
+	}
+
+	opts := processOptions{
+		workerCount:      workerCount,
+		bufferSize:       bufferSize,
+		htmlOutDir:       htmlOutDir,
+		jsonOutDir:       jsonOutDir,
+		renameByHeader:   renameByHeader,
+		renameByHeaderTo: renameByHeaderTo,
+		onConflict:       onConflict,
+		failFast:         failFast,
+		filters:          filters,
+		anonymizeIPs:     anonymize,
+		urlDeny:          urlDeny,
+		ownDomains:       own,
+		parse:            parseOptions{headersOnly: headersOnly, allowFTP: allowFTP, stripWWW: stripWWW, htmlSelectAll: htmlSelect == "all", keepRaw: keepRaw, normalizeSubject: normSubject, stripQuotes: stripQuotes, noDedupURLs: noDedupURLs, dateRange: dates, headerFilters: hdrFilters, grep: grep},
+		ordered:          ordered,
+		maildir:          maildir,
+		rootLabels:       outputRootLabels(inputRoots),
+		score:            score || minScore > 0,
+	}
+	opts.shorteners, err = loadShortenerDomains(shortenerLists)
+	if err != nil {
+		fatalf("-shortener-list 목록 읽기 실패: %v", err)
+	}
+	if expandShorteners {
+		opts.expander = newShortenerExpander(expandTimeout)
+	}
+	var msgIDs *msgIDIndex
+	if dedupeByMsgID {
+		msgIDs = scanMessageIDs(files, workerCount)
+		opts.msgIDs = msgIDs
+		opts.skipDuplicateActions = skipDuplicateActions
+	}
+	if resolveDomains {
+		opts.resolver = newDomainResolver(resolveConcurrency)
+	}
+	if whois {
+		opts.whois = newRDAPClient(rdapURL, whoisInterval)
+		opts.whoisYoungDays = whoisYoungDays
+	}
+	if seenDB != "" {
+		store, err := openSeenStore(seenDB, resetSeen)
+		if err != nil {
+			fatalf("중복 제거 DB 열기 실패: %v", err)
+		}
+		opts.seen = store
+	}
+	if manifestPath != "" {
+		m, err := newSHA256Manifest(manifestPath)
+		if err != nil {
+			fatalf("SHA-256 목록 파일 생성 실패: %v", err)
+		}
+		opts.manifest = m
+	}
+
+	// 출력 옵션에 따라 결과를 기록할 writer 선택
+	newFormatOut := func(w io.Writer) recordWriter {
+		if grepCount {
+			return &countRecordWriter{w: w}
+		}
+		if groupBy != "" {
+			return newGroupRecordWriter(w, groupBy, format)
+		}
+		if sendersOnly {
+			return newSendersRecordWriter(w, format)
+		}
+		if histogram != "" {
+			chart := w == os.Stdout && isTerminal(os.Stdout)
+			return newHistogramRecordWriter(w, histogram, format, histogramLoc, chart)
+		}
+		if iocs {
+			return newIOCRecordWriter(w, format, defangIOCs)
+		}
+		if diffPath != "" {
+			return newDiffRecordWriter(w, previous)
+		}
+		if tmpl != nil {
+			return newTemplateRecordWriter(w, tmpl, templateAll)
+		}
+		out := newRecordWriter(w, format)
+		if t, ok := out.(*tableRecordWriter); ok {
+			t.color = !noColor && os.Getenv("NO_COLOR") == "" && w == os.Stdout && isTerminal(os.Stdout)
+		}
+		if c, ok := out.(*csvRecordWriter); ok {
+			c.flattenSep = flattenSep
+		}
+		if u, ok := out.(*urlsRecordWriter); ok {
+			u.perMessage = urlsPerMessage
+		}
+		return out
+	}
+	newOut := func(w io.Writer) recordWriter {
+		return newEncodedRecordWriter(w, outEnc, newFormatOut)
+	}
+	if gzipOutput(outputPath) {
+		newPlainOut := newOut
+		newOut = func(w io.Writer) recordWriter { return newGzipRecordWriter(w, newPlainOut) }
+	}
+	var out recordWriter
+	var outFile *os.File
+	switch {
+	case htmlOutDir != "" || renameByHeader || renameByHeaderTo != "" || jsonOutDir != "":
+		out = discardRecordWriter{}
+	case rotating:
+		out = newRotatingRecordWriter(outputPath, rotateRecords, rotateBytes, newOut)
+	case outputPath != "":
+		f, err := os.Create(outputPath)
+		if err != nil {
+			fatalf("출력 파일 생성 실패: %v", err)
+		}
+		outFile = f
+		out = newOut(f)
+	default:
+		out = newOut(os.Stdout)
+	}
+	// -head/-tail은 정렬(-largest)이 끝난 출력에 적용
+	switch {
+	case head > 0:
+		out = &headRecordWriter{out: out, n: head}
+	case tail > 0:
+		out = newTailRecordWriter(out, tail)
+	}
+	if largest > 0 {
+		out = newLargestRecordWriter(out, largest)
+	}
+	var attReport *attachmentReport
+	if attachmentReportPath != "" {
+		attReport = newAttachmentReport()
+		out = &attachmentReportWriter{out: out, report: attReport}
 	}
-	return paths, nil
-}
 
-func shouldProcessFile(name string) bool {
-	matched, _ := filepath.Match("*.eml", name)
-	return matched
+	// 동시 처리로 EML 파일을 파싱하고 결과가 나오는 대로 바로 출력
+	summary, procErr := processFilesConcurrently(files, opts, out)
+	if opts.manifest != nil {
+		if err := opts.manifest.Close(); err != nil {
+			fatalf("SHA-256 목록 저장 실패: %v", err)
+		}
+	}
+	if procErr != nil && !errors.Is(procErr, errFailFast) {
+		fatalf("결과 출력 실패: %v", procErr)
+	}
+	if err := out.Close(); err != nil {
+		fatalf("결과 출력 실패: %v", err)
+	}
+	if outFile != nil {
+		if err := outFile.Close(); err != nil {
+			fatalf("결과 출력 실패: %v", err)
+		}
+	}
+	// 남은 키는 출력을 모두 마친 뒤에 저장하여, 출력에 실패한 실행의 메일이 처리된 것으로 남지 않게 함
+	if opts.seen != nil {
+		if err := opts.seen.Close(); err != nil {
+			fatalf("중복 제거 DB 저장 실패: %v", err)
+		}
+	}
+	if htmlOutDir != "" || renameByHeader || renameByHeaderTo != "" || jsonOutDir != "" {
+		debugf("파일 변환 및 재명명 작업 완료. 화면 출력 생략.")
+	}
+
+	failures := append(collected.failures, summary.failures...)
+	infof("처리 완료: 대상 %d개 (건너뜀 %d, 수집 오류 %d), 성공 %d, 실패 %d, 필터 제외 %d, 이미 처리됨 %d",
+		len(files), collected.skipped, len(collected.failures), summary.succeeded, summary.failed, summary.filtered, summary.seen)
+	// 필터별 제외 수 (여러 필터에 걸리는 메일은 처음 걸린 필터에만 셈)
+	if summary.filtered > 0 {
+		var byFilter []string
+		if dates != nil {
+			byFilter = append(byFilter, fmt.Sprintf("-since/-until %d", dates.excluded.Load()))
+		}
+		for _, part := range []string{hdrFilters.rejectedSummary(), filters.rejectedSummary()} {
+			if part != "" {
+				byFilter = append(byFilter, part)
+			}
+		}
+		infof("필터별 제외: %s", strings.Join(byFilter, ", "))
+	}
+
+	if dates != nil {
+		if n := dates.undated.Load(); n > 0 {
+			verb := "포함"
+			if !dates.includeUndated {
+				verb = "제외"
+			}
+			warnf("날짜를 알 수 없는 메일 %d개를 %s했습니다 (-undated-policy)", n, verb)
+		}
+	}
+
+	if msgIDs != nil {
+		groups := msgIDs.duplicates()
+		infof("Message-ID 중복: %d개 ID, 출력하지 않은 파일 %d개", len(groups), summary.duplicate)
+		if duplicatesReport != "" {
+			if err := writeDuplicatesReport(duplicatesReport, groups); err != nil {
+				fatalf("중복 보고서 저장 실패: %v", err)
+			}
+		}
+	}
+	if attReport != nil {
+		if err := attReport.write(attachmentReportPath); err != nil {
+			fatalf("첨부 보고서 저장 실패: %v", err)
+		}
+	}
+	if errorReport != "" {
+		if err := writeErrorReport(errorReport, failures); err != nil {
+			fatalf("오류 보고서 저장 실패: %v", err)
+		}
+	}
+	if procErr != nil {
+		fatalf("%v", procErr)
+	}
+	// 필터로 모든 메일이 제외되면 빈 결과임을 알리고 별도 종료 코드로 끝냄
+	if summary.succeeded == 0 && summary.filtered > 0 {
+		warnf("필터 조건에 맞는 메일이 없습니다 (필터 제외 %d개)", summary.filtered)
+		os.Exit(exitNoResults)
+	}
 }
 
+// exitNoResults는 필터로 모든 메일이 제외되어 출력할 레코드가 없을 때의 종료 코드입니다.
+// 1은 오류, 2는 잘못된 옵션(flag 패키지)에 쓰이므로 구분합니다.
+const exitNoResults = 3
+
+// stringList는 여러 번 지정할 수 있는 문자열 옵션입니다.
+type stringList []string
+
+func (s *stringList) String() string     { return strings.Join(*s, ",") }
+func (s *stringList) Set(v string) error { *s = append(*s, v); return nil }
+
 type task struct {
+	seq  int
+	root string
 	path string
+	size int64
 }
 
 type result struct {
-	record EmailRecord
-	err    error
+	seq      int
+	path     string
+	record   EmailRecord
+	err      error
+	warnings []fileFailure
+	filtered bool
+	// seenKey는 -seen-db의 중복 판단 키, alreadySeen은 이전 실행에서 처리된 메일인지 여부입니다.
+	seenKey     string
+	alreadySeen bool
+	// sha256은 -sha256-manifest에 기록할 입력 파일의 SHA-256입니다 (파싱/필터 결과와 관계없이 계산).
+	sha256 string
+	// duplicate는 -dedupe-by-msgid에서 같은 Message-ID의 첫 파일이 아니라 출력하지 않는 메일인지 여부입니다.
+	duplicate bool
+}
+
+// processSummary는 파일 처리 결과 통계와 실패 목록입니다.
+type processSummary struct {
+	succeeded int
+	failed    int
+	filtered  int
+	seen      int
+	duplicate int
+	failures  []fileFailure
+}
+
+// processOptions는 파일 처리 파이프라인의 설정값을 담습니다.
+type processOptions struct {
+	workerCount      int
+	bufferSize       int
+	htmlOutDir       string
+	jsonOutDir       string
+	renameByHeader   bool
+	renameByHeaderTo string
+	onConflict       string
+	failFast         bool
+	filters          recordFilters
+	anonymizeIPs     bool
+	urlDeny          domainList
+	ownDomains       domainList
+	parse            parseOptions
+	ordered          bool
+	maildir          bool
+	resolver         *domainResolver
+	whois            *rdapClient
+	whoisYoungDays   int
+	score            bool
+	shorteners       domainList
+	expander         *shortenerExpander
+	seen             *seenStore
+	// manifest가 설정되면 모든 입력 파일의 SHA-256을 결과가 나오는 대로 기록합니다.
+	manifest *sha256Manifest
+	// rootLabels는 입력 루트가 여러 개일 때 루트별 출력 하위 디렉토리 이름입니다 (outputRootLabels).
+	rootLabels map[string]string
+	// msgIDs가 설정되면 같은 Message-ID의 파일 중 경로 순으로 처음이 아닌 파일은 출력하지 않습니다.
+	// skipDuplicateActions가 설정되면 그 파일의 HTML 변환/재명명/JSON 파일 생성도 하지 않습니다.
+	msgIDs               *msgIDIndex
+	skipDuplicateActions bool
 }
 
-// processFilesConcurrently는 파일 경로 목록을 받아 지정한 워커 수로 병렬 처리합니다.
-func processFilesConcurrently(paths []string, workerCount int, htmlOutDir string, renameByHeader bool, renameByHeaderTo string, inputRoot string) []EmailRecord {
-	tasks := make(chan task, len(paths))
-	results := make(chan result, len(paths))
+// processFilesConcurrently는 파일 경로 목록을 받아 지정한 워커 수로 병렬 처리하고,
+// 결과를 out에 순차적으로 기록합니다. 결과 채널은 opts.bufferSize 만큼만 버퍼링하므로
+// 출력이 느리면 워커가 대기하여 메모리 사용량이 일정하게 유지됩니다.
+// 실패한 파일은 기본적으로 건너뛰고 계속 진행하며, 처리 결과 요약을 반환합니다.
+// opts.failFast가 설정되면 첫 파싱 실패에서 중단하고 errFailFast를 반환합니다.
+//
+// 기본적으로 결과는 처리가 끝난 순서대로 기록됩니다. opts.ordered가 설정되면 작업마다 순번을 붙이고
+// 먼저 끝난 결과를 재정렬 버퍼에 보관했다가 입력 순서대로 기록합니다. 아직 기록되지 않은 작업 수를
+// workerCount+bufferSize로 제한하므로 재정렬 버퍼도 그 이상 커지지 않지만, 느린 파일 하나가
+// 그 뒤 작업의 출력과 새 작업 공급을 함께 붙잡아 처리량이 떨어질 수 있습니다.
+func processFilesConcurrently(files []collectedFile, opts processOptions, out recordWriter) (processSummary, error) {
+	workerCount := opts.workerCount
+	if workerCount < 1 {
+		workerCount = 1
+	}
+	bufferSize := opts.bufferSize
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	tasks := make(chan task, workerCount)
+	results := make(chan result, bufferSize)
+	done := make(chan struct{})
 	var wg sync.WaitGroup
+	// 순서 보장 모드에서 아직 기록되지 않은 작업 수를 제한하는 슬롯
+	var window chan struct{}
+	if opts.ordered {
+		window = make(chan struct{}, workerCount+bufferSize)
+	}
 
 	worker := func() {
+		defer wg.Done()
 		for t := range tasks {
-			rec, htmlContent, err := processEmlFile(t.path)
+			rec, htmlContent, contentSum, err := parseEmlFile(t.path, opts.parse, true)
+			// 증거 목록용 해시는 파싱 결과와 관계없이 모든 입력 파일에 대해 기록.
+			// 파싱하면서 구한 해시가 없을 때(필터 제외, 파싱 실패)만 파일을 다시 읽음
+			sum := contentSum
+			if opts.manifest != nil && sum == "" {
+				var herr error
+				if sum, herr = fileSHA256(t.path); herr != nil {
+					warnf("SHA-256 계산 실패: %s (%v)", t.path, herr)
+				}
+			}
+			// 날짜 범위 필터에서 날짜를 알 수 없었던 메일 수 (포함/제외 모두)
+			if dr := opts.parse.dateRange; dr != nil && (errors.Is(err, errUndatedExcluded) || (err == nil && rec.SentDate == "")) {
+				dr.undated.Add(1)
+			}
+			// 날짜 범위 밖이거나 헤더 필터에 맞지 않는 메일은 본문을 읽지 않고 필터 제외로 처리
+			if isHeaderExcluded(err) {
+				if isDateExcluded(err) {
+					opts.parse.dateRange.excluded.Add(1)
+				}
+				select {
+				case results <- result{seq: t.seq, path: t.path, sha256: sum, filtered: true}:
+				case <-done:
+					return
+				}
+				continue
+			}
 			if err != nil {
-				results <- result{err: err}
+				select {
+				case results <- result{seq: t.seq, path: t.path, sha256: sum, err: err}:
+				case <-done:
+					return
+				}
+				continue
+			}
+			if contentSum == "" {
+				// 읽기에 성공한 파일이므로 실패는 드묾. 실패하면 경로와 Message-ID로 대신함
+				warnf("RecordID에 경로 사용: %s", t.path)
+				contentSum = t.path + "\n" + rec.MessageID
+			}
+			rec.RecordID = recordID(t.path, contentSum)
+			rec.MessageSize = t.size
+			if opts.maildir {
+				rec.Folder = maildirName(t.path)
+			}
+			if opts.anonymizeIPs {
+				rec.IP = anonymizeIPs(rec.IP)
+				rec.ReceivedIPs = anonymizeIPs(rec.ReceivedIPs)
+			}
+			// 제외 목록 도메인의 URL은 필터와 DNS/RDAP 조회 전에 제거
+			if len(opts.urlDeny) > 0 {
+				scrubURLDomains(&rec, opts.urlDeny)
+			}
+			if len(opts.ownDomains) > 0 {
+				separateOwnDomains(&rec, opts.ownDomains)
+			}
+			markShorteners(&rec, opts.shorteners)
+			// 이전 실행에서 처리한 메일은 HTML 변환/재명명도 하지 않음
+			var key string
+			if opts.seen != nil {
+				var seen bool
+				key, err = seenKey(t.path, rec.MessageID)
+				if err == nil {
+					seen, err = opts.seen.has(key)
+				}
+				if err != nil {
+					err = withStage(stageSeenDB, err)
+				}
+				if err != nil || seen {
+					select {
+					case results <- result{seq: t.seq, path: t.path, sha256: sum, err: err, alreadySeen: seen}:
+					case <-done:
+						return
+					}
+					continue
+				}
+			}
+			// 같은 Message-ID의 첫 파일이 아닌 메일은 출력하지 않고, 지정하면 HTML 변환/재명명도 하지 않음
+			duplicate := opts.msgIDs != nil && opts.msgIDs.isDuplicate(rec.MessageID, t.path)
+			if duplicate && opts.skipDuplicateActions {
+				select {
+				case results <- result{seq: t.seq, path: t.path, sha256: sum, duplicate: true}:
+				case <-done:
+					return
+				}
+				continue
+			}
+			// 단축 URL의 최종 도메인은 필터와 점수에 반영되므로 필터 전에 확인 (이미 처리한 메일과 중복 메일은 요청하지 않음)
+			if opts.expander != nil {
+				expandRecordShorteners(opts.expander, &rec, append(append(domainList{}, opts.urlDeny...), opts.ownDomains...))
+			}
+			if opts.score {
+				scoreRecord(&rec)
+			}
+			// 필터에 맞지 않는 메일은 HTML 변환/재명명도 하지 않음
+			if !opts.filters.match(&rec) {
+				select {
+				case results <- result{seq: t.seq, path: t.path, sha256: sum, filtered: true}:
+				case <-done:
+					return
+				}
 				continue
 			}
+			if opts.resolver != nil {
+				resolveRecordDomains(opts.resolver, &rec)
+			}
+			if opts.whois != nil {
+				whoisRecordDomains(opts.whois, &rec, opts.whoisYoungDays, time.Now())
+			}
+			res := result{seq: t.seq, path: t.path, sha256: sum, record: rec, seenKey: key, duplicate: duplicate}
+			if err := charsetWarning(rec); err != nil {
+				res.warnings = append(res.warnings, newFileFailure(t.path, stageCharset, err))
+			}
+			// 출력 디렉토리 아래에 재현할 상대 경로 (입력 루트가 여러 개면 루트 이름 아래)
+			var relPath string
+			if opts.htmlOutDir != "" || opts.renameByHeaderTo != "" || opts.jsonOutDir != "" {
+				relPath = filepath.Join(opts.rootLabels[t.root], outputRelPath(t.root, t.path))
+			}
 			// HTML 파일 저장
-			if htmlOutDir != "" {
-				if err := writeHtmlFile(t.path, inputRoot, htmlOutDir, htmlContent); err != nil {
-					log.Printf("[WARN] HTML 파일 생성 실패: %s (%v)", t.path, err)
+			if opts.htmlOutDir != "" {
+				if err := writeHtmlFile(relPath, opts.htmlOutDir, rec.RecordID, htmlContent); err != nil {
+					res.warnings = append(res.warnings, newFileFailure(t.path, stageHTML, err))
 				}
 			}
 			// 파일 재명명 또는 복사
-			if renameByHeaderTo != "" {
-				if err := renameFileTo(t.path, inputRoot, renameByHeaderTo, rec); err != nil {
-					log.Printf("[WARN] 파일 복사 재명명 실패: %s (%v)", t.path, err)
+			if opts.renameByHeaderTo != "" {
+				dst, err := renameFileTo(t.path, relPath, opts.renameByHeaderTo, rec, opts.onConflict)
+				if err != nil {
+					res.warnings = append(res.warnings, newFileFailure(t.path, stageRenameTo, err))
+				}
+				res.record.RenamedPath = dst
+			} else if opts.renameByHeader {
+				dst, err := renameFile(t.path, rec, opts.onConflict)
+				if err != nil {
+					res.warnings = append(res.warnings, newFileFailure(t.path, stageRename, err))
 				}
-			} else if renameByHeader {
-				if err := renameFile(t.path, rec); err != nil {
-					log.Printf("[WARN] 파일 재명명 실패: %s (%v)", t.path, err)
+				res.record.RenamedPath = dst
+			}
+			// 메일별 JSON 파일 저장 (재명명 경로까지 반영한 레코드)
+			if opts.jsonOutDir != "" {
+				if err := writeJSONFile(relPath, opts.jsonOutDir, res.record); err != nil {
+					res.warnings = append(res.warnings, newFileFailure(t.path, stageJSONFile, err))
 				}
 			}
-			results <- result{record: rec}
+			select {
+			case results <- res:
+			case <-done:
+				return
+			}
 		}
-		wg.Done()
 	}
 
 	wg.Add(workerCount)
 	for i := 0; i < workerCount; i++ {
 		go worker()
 	}
-	for _, path := range paths {
-		tasks <- task{path: path}
+	go func() {
+		defer close(tasks)
+		for i, f := range files {
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-done:
+					return
+				}
+			}
+			select {
+			case tasks <- task{seq: i, root: f.root, path: f.path, size: f.size}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 출력 실패나 fail-fast 중단 시 done을 닫아 워커와 작업 공급을 중단시키고 남은 결과를 비웁니다.
+	var summary processSummary
+	var stopErr error
+	handle := func(res result) {
+		if stopErr != nil {
+			return
+		}
+		if opts.manifest != nil && res.sha256 != "" {
+			if err := opts.manifest.add(res.path, res.sha256); err != nil {
+				stopErr = fmt.Errorf("SHA-256 목록 기록 실패: %w", err)
+				close(done)
+				return
+			}
+		}
+		for _, w := range res.warnings {
+			warnf("%s 실패: %s (%s)", w.Stage, w.File, w.Error)
+			summary.failures = append(summary.failures, w)
+		}
+		if res.err != nil {
+			f := newFileFailure(res.path, stageHeaderParse, res.err)
+			warnf("%s 실패: %s (%s)", f.Stage, f.File, f.Error)
+			summary.failures = append(summary.failures, f)
+			summary.failed++
+			if opts.failFast {
+				stopErr = fmt.Errorf("%w: %s", errFailFast, res.path)
+				close(done)
+			}
+			return
+		}
+		if res.filtered {
+			summary.filtered++
+			return
+		}
+		if res.alreadySeen {
+			summary.seen++
+			return
+		}
+		if res.duplicate {
+			summary.duplicate++
+			return
+		}
+		if opts.seen != nil {
+			// 같은 실행 안에서 이미 기록한 메일도 건너뜀
+			dup, err := opts.seen.mark(res.seenKey)
+			if err != nil {
+				stopErr = fmt.Errorf("중복 제거 DB 기록 실패: %w", err)
+				close(done)
+				return
+			}
+			if dup {
+				summary.seen++
+				return
+			}
+		}
+		summary.succeeded++
+		if err := out.WriteRecord(res.record); err != nil {
+			stopErr = err
+			close(done)
+		}
 	}
-	close(tasks)
-	wg.Wait()
-	close(results)
 
-	var records []EmailRecord
+	// 재정렬 버퍼: 다음 순번이 도착할 때까지 먼저 끝난 결과를 보관
+	pending := make(map[int]result)
+	next := 0
 	for res := range results {
-		if res.err != nil {
-			log.Printf("[WARN] 파일 처리 실패: %v", res.err)
+		if !opts.ordered {
+			handle(res)
 			continue
 		}
-		records = append(records, res.record)
+		pending[res.seq] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			handle(r)
+			<-window
+		}
 	}
-	return records
+	return summary, stopErr
 }
 
 // processEmlFile는 버퍼링을 적용하여 EML 파일을 파싱합니다.
-func processEmlFile(filePath string) (EmailRecord, string, error) {
+// 정상 파싱에 실패하면 헤더를 보정한 재파싱(degraded), 직접 헤더를 읽는 최소 파싱(raw) 순으로
+// 시도하여 가능한 정보를 최대한 남기며, 어느 단계를 사용했는지 ParseQuality에 기록합니다.
+func processEmlFile(filePath string, popts parseOptions) (EmailRecord, string, error) {
+	rec, htmlContent, _, err := parseEmlFile(filePath, popts, false)
+	return rec, htmlContent, err
+}
+
+// parseEmlFile은 processEmlFile과 같으며, hashContent가 설정되면 파싱에 성공한 파일 내용의 SHA-256도 반환합니다.
+// 해시는 파싱하면서 읽은 바이트로 구하므로 파일을 다시 읽지 않으며, 구하지 못하면 빈 문자열입니다.
+func parseEmlFile(filePath string, popts parseOptions, hashContent bool) (EmailRecord, string, string, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return EmailRecord{}, "", err
+		return EmailRecord{}, "", "", withStage(stageOpen, err)
 	}
 	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return EmailRecord{}, "", "", withStage(stageOpen, err)
+	} else if info.Size() == 0 {
+		return EmailRecord{}, "", "", withStage(stageOpen, errEmptyFile)
+	}
+	// 다시 파싱할 때는 파일을 처음부터 읽으므로 해시도 새로 구함
+	hr := newHashingReader(f)
+	contentSum := func() string {
+		if !hashContent {
+			return ""
+		}
+		sum, err := hr.finish()
+		if err != nil {
+			warnf("SHA-256 계산 실패: %s (%v)", filePath, err)
+		}
+		return sum
+	}
 
-	br := bufio.NewReader(f)
-	mr, err := messageMail.CreateReader(br)
-	if err != nil {
-		return EmailRecord{}, "", err
+	// 지나치게 긴 헤더 필드는 잘라내고 경고 (나머지 헤더와 본문은 정상 처리)
+	var truncated []string
+	h, tree, err := parseMessage(newCappedHeaderReader(hr, &truncated), popts)
+	for _, key := range truncated {
+		warnf("헤더 필드가 %d바이트를 넘어 잘라냄: %s (%s)", maxHeaderFieldBytes, filePath, key)
+	}
+	if err == nil && h.Len() == 0 {
+		return EmailRecord{}, "", "", withStage(stageHeaderParse, errNoHeaders)
+	}
+	if err == nil {
+		rec, htmlContent := buildRecord(filePath, h, tree, "", parseQualityFull, popts)
+		return rec, htmlContent, contentSum(), nil
+	}
+	if isHeaderExcluded(err) {
+		return EmailRecord{}, "", "", err
+	}
+	parseErr := withStage(stageHeaderParse, describeParseError(err))
+
+	// 잘못된 헤더 줄을 보정하여 다시 파싱.
+	// 본문이 중간에 잘린 경우에도 읽은 헤더와 본문 일부로 레코드를 만듦
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return EmailRecord{}, "", "", parseErr
+	}
+	hr = newHashingReader(f)
+	h, tree, err = parseMessage(newLenientHeaderReader(newCappedHeaderReader(hr, new([]string))), popts)
+	if isHeaderExcluded(err) {
+		return EmailRecord{}, "", "", err
+	}
+	// 보정해도 헤더 필드가 하나도 없으면 메일이 아닌 파일로 보고 실패 처리
+	if (err == nil || tree != nil) && h.Len() > 0 {
+		if err != nil {
+			warnf("%s 실패, 읽은 부분까지 사용: %s (%v)", stageBodyParse, filePath, describeParseError(err))
+		}
+		rec, htmlContent := buildRecord(filePath, h, tree, "", parseQualityDegraded, popts)
+		return rec, htmlContent, contentSum(), nil
 	}
 
-	h := mr.Header
+	// 헤더를 직접 읽어 제목/보낸사람/받는사람/날짜와 본문 URL만 추출
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return EmailRecord{}, "", "", parseErr
+	}
+	hr = newHashingReader(f)
+	h, body, err := scanRawMessage(hr)
+	if err != nil || h.Len() == 0 {
+		return EmailRecord{}, "", "", parseErr
+	}
+	if err := popts.checkHeaders(h); err != nil {
+		return EmailRecord{}, "", "", err
+	}
+	body = string(trimBodyPrefix([]byte(decodeRawBody(h.Get("Content-Transfer-Encoding"), body))))
+	rec, htmlContent := buildRecord(filePath, h, nil, body, parseQualityRaw, popts)
+	return rec, htmlContent, contentSum(), nil
+}
+
+// isDateExcluded는 날짜 범위 필터로 제외되었음을 나타내는 오류인지 확인합니다.
+func isDateExcluded(err error) bool {
+	return errors.Is(err, errOutsideDateRange) || errors.Is(err, errUndatedExcluded)
+}
 
+// isHeaderExcluded는 헤더를 읽은 직후 확인하는 필터(날짜 범위, 헤더 필터)로 제외되었음을 나타내는 오류인지 확인합니다.
+func isHeaderExcluded(err error) bool {
+	return isDateExcluded(err) || errors.Is(err, errHeaderExcluded)
+}
+
+// describeParseError는 "EOF"처럼 원인을 알기 어려운 파싱 오류에 설명을 붙입니다.
+func describeParseError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("파일이 중간에 잘림: %w", err)
+	}
+	return err
+}
+
+// parseOptions는 개별 메시지 파싱 방식을 정하는 설정값입니다.
+type parseOptions struct {
+	// headersOnly가 설정되면 헤더 블록만 읽고 MIME 파트는 읽지 않습니다.
+	headersOnly bool
+	// allowFTP가 설정되면 ftp: 링크도 URL로 추출합니다.
+	allowFTP bool
+	// stripWWW가 설정되면 URL 도메인 열에서 "www." 접두어를 제거합니다.
+	stripWWW bool
+	// htmlSelectAll이 설정되면 첫 HTML 본문 대신 모든 text/html 파트에서 URL을 추출합니다.
+	htmlSelectAll bool
+	// keepRaw가 설정되면 디코딩하지 않은 Subject 헤더 원문을 SubjectRaw에 보관합니다.
+	keepRaw bool
+	// normalizeSubject가 설정되면 폭 없는 문자/동형 문자를 정리한 제목을 NormalizedSubject에 기록합니다.
+	normalizeSubject bool
+	// stripQuotes가 설정되면 본문 미리보기와 단어 수에서 인용 줄과 서명을 제외합니다.
+	stripQuotes bool
+	// dateRange가 설정되면 헤더를 읽은 직후 날짜 범위를 확인하고, 범위 밖이면 본문을 읽지 않고
+	// errOutsideDateRange/errUndatedExcluded를 반환합니다.
+	dateRange *dateRange
+	// noDedupURLs가 설정되면 URLs/URLSources에 같은 URL도 나온 순서대로 모두 기록하고, URL별 출현 횟수를 URLCounts에 기록합니다.
+	noDedupURLs bool
+	// grep이 설정되면 본문에서 패턴을 찾아 Matches/MatchCount에 기록합니다.
+	grep *bodyGrep
+	// headerFilters가 설정되면 날짜 범위와 같은 시점에 확인하고, 맞지 않으면 errHeaderExcluded를 반환합니다.
+	headerFilters headerFilters
+}
+
+// checkHeaders는 헤더만으로 판단하는 필터(날짜 범위, 헤더 필터)를 확인합니다.
+func (popts parseOptions) checkHeaders(h messageMail.Header) error {
+	if popts.dateRange != nil {
+		if err := popts.dateRange.check(h); err != nil {
+			return err
+		}
+	}
+	return popts.headerFilters.check(h)
+}
+
+// parseMessage는 메시지 헤더와 MIME 트리를 읽습니다.
+// MIME 트리를 읽다가 실패한 경우 그때까지 읽은 트리와 오류를 함께 반환합니다.
+// popts.headersOnly이면 트리 없이 헤더만 반환합니다.
+func parseMessage(r io.Reader, popts parseOptions) (messageMail.Header, *mimePart, error) {
+	e, err := message.Read(r)
+	// 알 수 없는 문자셋/전송 인코딩은 readMIMETree에서 가능한 범위로 처리
+	if err != nil && !message.IsUnknownCharset(err) && !message.IsUnknownEncoding(err) {
+		return messageMail.Header{}, nil, err
+	}
+	h := messageMail.Header{Header: e.Header}
+	if err := popts.checkHeaders(h); err != nil {
+		return h, nil, err
+	}
+	if popts.headersOnly {
+		return h, nil, nil
+	}
+
+	// MIME 트리를 구성한 뒤 중첩 구조(alternative/related 등)를 고려해 HTML 본문을 선택
+	tree, err := readMIMETree(e)
+	return h, tree, err
+}
+
+// buildRecord는 파싱한 헤더와 MIME 트리로 EmailRecord를 만듭니다.
+// tree가 nil이면(raw 파싱) rawBody 전체에서 정규식으로 URL을 추출합니다.
+// popts.headersOnly이면 URL 관련 필드는 비워 둡니다.
+func buildRecord(filePath string, h messageMail.Header, tree *mimePart, rawBody string, quality string, popts parseOptions) (EmailRecord, string) {
+	// 디코딩에 실패하면 원문 헤더 값에서 유효한 encoded-word만 디코딩하여 사용.
+	// mime.WordDecoder는 잘린 encoded-word를 오류 없이 그대로 남기므로 별도로 검사
 	subject, err := h.Subject()
+	var subjectDecodeError bool
 	if err != nil {
-		subject = ""
+		subject, _ = decodeHeaderLenient(h.Get("Subject"))
+		subjectDecodeError = true
+	} else if _, ok := decodeHeaderLenient(h.Get("Subject")); !ok {
+		subjectDecodeError = true
 	}
+	subject = cleanText(subject)
 
-	fromList, err := h.AddressList("From")
-	var fromName, fromEmail string
+	// From은 여러 주소를 가질 수 있으므로(RFC 5322 §3.6.2) 모두 줄바꿈으로 연결하고,
+	// 첫 번째 주소는 호환성을 위해 따로 보관. 일부만 파싱된 경우 파싱하지 못한 나머지는 FromRaw/ToRaw에 기록
+	fromList, fromRaw, err := parseAddressList(h, "From")
+	var fromName, fromEmail, primaryFromName, primaryFromEmail string
 	if err == nil && len(fromList) > 0 {
-		fromName = fromList[0].Name
-		fromEmail = fromList[0].Address
+		fromName, fromEmail = joinAddresses(fromList)
+		primaryFromName = cleanText(fromList[0].Name)
+		primaryFromEmail = fromList[0].Address
+	} else if fromRaw = headerText(h, "From"); fromRaw != "" {
+		// 파싱할 수 없는 값도 버리지 않고 원문과 주소처럼 보이는 부분을 보관
+		fromName, fromEmail = fallbackAddresses(fromRaw)
+		primaryFromName = fromName
+		primaryFromEmail, _, _ = strings.Cut(fromEmail, "\n")
+	}
+
+	senderList, _, err := parseAddressList(h, "Sender")
+	var sender string
+	if err == nil && len(senderList) > 0 {
+		sender = senderList[0].Address
 	}
 
-	toList, err := h.AddressList("To")
+	// 받는 사람도 그룹 구성원을 포함한 모든 주소를 줄바꿈으로 연결하고, 그룹 이름은 ToGroups에 기록.
+	// 구성원이 없는 그룹("undisclosed-recipients:;")은 이름만 ToName에 남기고 주소는 비워 둠
+	toList, toRaw, err := parseAddressList(h, "To")
+	toGroups, _ := parseAddressGroups(stripHeaderComments(h.Get("To")))
 	var toName, toEmail string
+	if err == nil {
+		toList = append(toList, emptyGroupAddresses(toGroups)...)
+	}
 	if err == nil && len(toList) > 0 {
-		toName = toList[0].Name
-		toEmail = toList[0].Address
+		toName, toEmail = joinAddresses(toList)
+	} else if toRaw = headerText(h, "To"); toRaw != "" {
+		toName, toEmail = fallbackAddresses(toRaw)
 	}
 
-	date, err := h.Date()
+	ccName, ccEmail := addressHeader(h, "Cc")
+	_, replyTo := addressHeader(h, "Reply-To")
+
+	// Date 헤더는 표준 형식 외에 비표준 형식도 시도하고,
+	// 그래도 실패하면 가장 위 Received 헤더의 타임스탬프를 사용
 	var sentDate string
-	if err == nil {
-		sentDate = date.Format("2006-01-02 15:04:05")
+	var sentDateOffset string
+	date, ok := messageDate(h)
+	if ok {
+		sentDate = date.time.Format("2006-01-02 15:04:05")
+		sentDateOffset = date.time.Format("-0700")
 	}
 
-	originIP := h.Get("X-Originating-IP")
+	// X-Originating-IP는 "[1.2.3.4]"처럼 대괄호로 감싸는 경우가 많으므로 IP만 정규화하여 기록 (IP가 없으면 원문)
+	originIP := strings.ReplaceAll(h.Get("X-Originating-IP"), ",", "\n")
+	if ips := extractIPs(originIP); len(ips) > 0 {
+		originIP = strings.Join(ips, "\n")
+	}
+
+	organization := headerText(h, "Organization")
 
 	var htmlContent string
-	for {
-		p, err := mr.NextPart()
-		if err == io.EOF {
-			break
+	var htmlCharset string
+	var htmlParts []*mimePart
+	htmlPart := selectBody(tree, "text/html")
+	if htmlPart != nil {
+		htmlContent = string(htmlPart.body)
+		htmlCharset = htmlPart.charset
+		htmlParts = []*mimePart{htmlPart}
+	}
+	if popts.htmlSelectAll {
+		if all := allBodies(tree, "text/html"); len(all) > 1 {
+			htmlParts = all
+			htmlContent = joinHTMLParts(all)
 		}
-		if err != nil {
-			return EmailRecord{}, "", err
+	}
+
+	// 스레드 분석용 회신/전달 여부: 제목 접두어 또는 In-Reply-To 헤더로 판단
+	var subjectRaw string
+	if popts.keepRaw {
+		subjectRaw = h.Get("Subject")
+	}
+	var normalizedSubject string
+	if popts.normalizeSubject {
+		normalizedSubject = normalizeSubject(subject)
+	}
+
+	cleanSubject, isReply, isForward := parseSubjectPrefix(subject)
+	if strings.TrimSpace(h.Get("In-Reply-To")) != "" {
+		isReply = true
+	}
+
+	// 대량 메일 분류용 메일링 리스트 헤더
+	listID := strings.TrimSpace(h.Get("List-Id"))
+	listUnsubscribe := strings.TrimSpace(h.Get("List-Unsubscribe"))
+
+	// 일정 초대(ICS) 파트의 주최자/제목/URL
+	var calInfo calendarInfo
+	cal := findCalendar(tree)
+	if cal != nil {
+		calInfo = parseCalendar(string(cal.body))
+	}
+
+	var links linkSet
+	var hiddenHTMLUsed bool
+	var bodyText string
+	if !popts.headersOnly {
+		if tree != nil {
+			// 선언된 HTML 본문이 있지만 비어 있으면 다른 파트에 숨겨진(base64 등) HTML을 사용.
+			// HTML 본문이 없는 텍스트 메일은 HTML 첨부를 본문으로 올리지 않음
+			if htmlPart != nil && len(strings.TrimSpace(htmlContent)) < trivialHTMLLength {
+				if hidden := findHiddenHTML(tree); hidden != "" {
+					htmlContent = hidden
+					hiddenHTMLUsed = true
+				}
+			}
+			if textPart := selectBody(tree, "text/plain"); htmlPart == nil && !hiddenHTMLUsed && textPart != nil {
+				// HTML 본문이 없는 텍스트 메일은 텍스트에서 URL을 찾고, HTML 저장에도 텍스트를 사용
+				bodyText = plainText(textPart)
+				links.addText(bodyText)
+				htmlContent = textToHTML(bodyText)
+			} else if len(htmlParts) > 1 && !hiddenHTMLUsed {
+				// 파트마다 따로 파싱해야 한 파트의 <base>가 다른 파트의 상대 경로에 적용되지 않음
+				for _, p := range htmlParts {
+					links.merge(extractLinks(string(p.body), popts.allowFTP))
+				}
+			} else {
+				links = extractLinks(htmlContent, popts.allowFTP)
+			}
+			if bodyText == "" {
+				bodyText = htmlVisibleText(htmlContent, popts.stripQuotes)
+			}
+		} else {
+			// raw 파싱은 본문 형식을 알 수 없으므로 HTML일 수 있다고 보고 엔티티를 디코딩
+			links.addHTMLText(rawBody)
+			bodyText = rawBody
 		}
-		ct := p.Header.Get("Content-Type")
-		if strings.HasPrefix(ct, "text/html") {
-			body, err := io.ReadAll(p.Body)
-			if err != nil {
-				continue
+		if cal != nil {
+			for _, u := range calInfo.urls {
+				links.addURL(u, urlSourceCalendar)
 			}
-			htmlContent = string(body)
-			break
 		}
+		for _, u := range parseListUnsubscribe(listUnsubscribe) {
+			links.addURL(u, urlSourceListUnsubscribe)
+		}
+		links.dedupe()
 	}
+	// -grep은 인용 제거 전의 전체 본문(텍스트 파트와 태그를 뺀 HTML 텍스트 모두)에서 찾음
+	var grepMatches []string
+	var grepCount int
+	if popts.grep != nil && !popts.headersOnly {
+		var bodies []string
+		if tree != nil {
+			if textPart := selectBody(tree, "text/plain"); textPart != nil {
+				bodies = append(bodies, plainText(textPart))
+			}
+			if htmlPart != nil || hiddenHTMLUsed {
+				bodies = append(bodies, htmlVisibleText(htmlContent, false))
+			}
+		} else {
+			bodies = append(bodies, rawBody)
+		}
+		grepMatches, grepCount = popts.grep.search(bodies...)
+	}
+	// 링크 수는 헤더(List-Unsubscribe)에서 온 URL을 빼고 본문 링크만 셈
+	linkCount := len(links.mailtos) + len(links.tels)
+	for _, src := range links.sources {
+		if src != urlSourceListUnsubscribe {
+			linkCount++
+		}
+	}
+	// 인용/서명 제거는 미리보기와 통계에만 적용 (URL 추출과 HTML 저장은 전체 본문 사용)
+	if popts.stripQuotes {
+		bodyText = stripQuotedText(bodyText)
+	}
+	wordCount, linkDensity := bodyStats(bodyText, linkCount)
 
-	urls := extractUrls(htmlContent)
+	urls, sources := links.urls, links.sources
+	var urlCounts string
+	if popts.noDedupURLs {
+		urls, sources = links.allURLs, links.allSources
+		urlCounts = urlOccurrences(urls)
+	}
 	urlList := strings.Join(urls, "\n")
+	// 도메인은 정규화 후 중복을 제거하여 처음 나온 순서대로 기록 (URL 열은 그대로 유지)
 	var urlDomains []string
 	for _, u := range urls {
-		if parsed, err := url.Parse(u); err == nil {
-			urlDomains = append(urlDomains, parsed.Host)
+		if domain, ok := urlDomain(u); ok {
+			if popts.stripWWW {
+				domain = strings.TrimPrefix(domain, "www.")
+			}
+			urlDomains = appendUnique(urlDomains, domain)
 		}
 	}
 
-	folder := filepath.Base(filepath.Dir(filePath))
+	attNames, attTypes := attachmentNames(tree)
+
+	folder := folderName(filePath)
 	originalFile := filepath.Base(filePath)
 
 	record := EmailRecord{
@@ -315,51 +1595,238 @@ func processEmlFile(filePath string) (EmailRecord, string, error) {
 		ToName:       toName,
 		ToEmail:      toEmail,
 		SentDate:     sentDate,
-		IP:           strings.ReplaceAll(originIP, ",", "\n"),
+		IP:           originIP,
 		URLs:         urlList,
 		URLDomains:   strings.Join(urlDomains, "\n"),
 		OriginalFile: originalFile,
+
+		ListID:          listID,
+		ListUnsubscribe: listUnsubscribe,
+		IsBulk:          listID != "" || listUnsubscribe != "",
+
+		AttachmentCount: countAttachments(tree),
+		HasHTML:         htmlPart != nil,
+		HasText:         selectBody(tree, "text/plain") != nil,
+		HTMLCharset:     htmlCharset,
+
+		CleanSubject: cleanSubject,
+		IsReply:      isReply,
+		IsForward:    isForward,
+
+		DateSource: date.source,
+
+		PrimaryFromName:  primaryFromName,
+		PrimaryFromEmail: primaryFromEmail,
+		Sender:           sender,
+
+		ParseQuality: quality,
+		AuthResults:  parseAuthResults(h.Values("Authentication-Results")),
+
+		SubjectDecodeError: subjectDecodeError,
+
+		Organization: organization,
+		Priority:     normalizePriority(h.Get("X-Priority"), h.Get("Importance")),
+
+		FromRaw: fromRaw,
+		ToRaw:   toRaw,
+
+		MessageID: strings.TrimSpace(h.Get("Message-Id")),
+
+		MailtoLinks:         strings.Join(links.mailtos, "\n"),
+		TelLinks:            strings.Join(links.tels, "\n"),
+		SuspiciousHrefCount: links.suspiciousHrefs,
+
+		HiddenHTMLUsed: hiddenHTMLUsed,
+
+		URLSources: strings.Join(sources, "\n"),
+
+		SubjectRaw: subjectRaw,
+
+		CharsetFallback: hasCharsetFallback(tree) ||
+			headerCharsetFallback(h.Get("Subject"), h.Get("From"), h.Get("To")),
+
+		AttachmentHashes: strings.Join(attachmentHashes(tree), "\n"),
+
+		WordCount:   wordCount,
+		LinkCount:   linkCount,
+		LinkDensity: linkDensity,
+
+		NormalizedSubject: normalizedSubject,
+		ToGroups:          groupNames(toGroups),
+		BodyPreview:       bodyPreview(bodyText),
+
+		HasCalendar:  cal != nil,
+		CalOrganizer: calInfo.organizer,
+		CalSummary:   calInfo.summary,
+
+		SentDateRaw: strings.TrimSpace(h.Get("Date")),
+		DateLayout:  date.layout,
+
+		CcName:      ccName,
+		CcEmail:     ccEmail,
+		DeliveredTo: deliveredTo(h),
+
+		ReceivedIPs: strings.Join(receivedIPs(h), "\n"),
+
+		Matches:    strings.Join(grepMatches, "\n"),
+		MatchCount: grepCount,
+
+		URLCount:  len(links.urls),
+		URLCounts: urlCounts,
+
+		ContentLanguage: contentLanguage(h),
+
+		DateZone:       date.zone,
+		SentDateOffset: sentDateOffset,
+
+		SubjectCharset: headerCharsets(h.Get("Subject")),
+
+		AttachmentNames: strings.Join(attNames, "\n"),
+		AttachmentTypes: strings.Join(attTypes, "\n"),
+
+		ReplyTo:    replyTo,
+		ReturnPath: returnPath(h),
+
+		LinkTextMismatches: links.textMismatches,
+
+		ThreadIndex: threadIndex(h),
+		ThreadTopic: cleanText(headerText(h, "Thread-Topic")),
 	}
 
-	return record, htmlContent, nil
+	return record, htmlContent
 }
 
-func renameFile(filePath string, record EmailRecord) error {
+// renameMu는 제자리 재명명의 "빈 이름 확인 후 이동"을 워커 사이에서 직렬화합니다.
+var renameMu sync.Mutex
+
+// renameFile은 원본 파일을 같은 디렉토리에서 새 이름으로 바꾸고 최종 경로를 반환합니다.
+// 같은 이름의 파일이 있으면 덮어쓰지 않고 onConflict 정책을 따르며, 건너뛰면 빈 경로를 반환합니다.
+func renameFile(filePath string, record EmailRecord, onConflict string) (string, error) {
 	dir := filepath.Dir(filePath)
-	newTime := formatTime(record.SentDate)
-	newName := sanitizeFilename(fmt.Sprintf("%s %s.eml", newTime, record.Subject))
-	newPath := filepath.Join(dir, newName)
-	return os.Rename(filePath, newPath)
+	newName, err := renameTarget(filePath, record)
+	if err != nil {
+		return "", err
+	}
+	renameMu.Lock()
+	defer renameMu.Unlock()
+	newPath, err := availablePath(filePath, filepath.Join(dir, newName), onConflict)
+	if err != nil || newPath == "" {
+		return "", err
+	}
+	if err := os.Rename(filePath, newPath); err != nil {
+		return "", err
+	}
+	return newPath, nil
 }
 
-func renameFileTo(filePath, inputRoot, outputDir string, record EmailRecord) error {
-	relPath, err := filepath.Rel(inputRoot, filePath)
+// renameFileTo는 원본을 outputDir 아래 relPath(원본의 출력 상대 경로)의 디렉토리에 새 이름으로 복사하고 실제로 쓴 경로를 반환합니다.
+// 대상은 O_EXCL로 만들어 기존 파일을 덮어쓰지 않으며, 이미 있으면 onConflict 정책을 따릅니다.
+func renameFileTo(filePath, relPath, outputDir string, record EmailRecord, onConflict string) (string, error) {
+	newName, err := renameTarget(filePath, record)
 	if err != nil {
-		return err
+		return "", err
 	}
-	newTime := formatTime(record.SentDate)
-	newName := sanitizeFilename(fmt.Sprintf("%s %s.eml", newTime, record.Subject))
 	newRelPath := filepath.Join(filepath.Dir(relPath), newName)
-	newPath := filepath.Join(outputDir, newRelPath)
+	newPath, err := joinWithin(outputDir, newRelPath)
+	if err != nil {
+		return "", err
+	}
 
 	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
-		return err
+		return "", err
 	}
 
 	srcFile, err := os.Open(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(newPath)
-	if err != nil {
-		return err
+	dstFile, newPath, err := createExclusive(newPath, onConflict)
+	if err != nil || dstFile == nil {
+		return "", err
 	}
 	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		// 일부만 복사된 파일은 남기지 않음
+		dstFile.Close()
+		os.Remove(newPath)
+		return "", err
+	}
+	return newPath, nil
+}
+
+// outputRootLabels는 입력 루트가 여러 개일 때 루트마다 출력 디렉토리 아래에 둘 하위 디렉토리 이름(루트의 이름)을 정합니다.
+// 루트마다 상대 경로를 따로 계산하므로, 서로 다른 루트의 같은 상대 경로(a/x.eml, b/x.eml)가 한 파일로 겹치지 않게 합니다.
+// 이름이 같은 루트(x/mail, y/mail)는 "mail", "mail-2"처럼 번호를 붙입니다. 루트가 하나면 nil을 반환하여 기존 구조를 유지합니다.
+func outputRootLabels(roots []string) map[string]string {
+	unique := appendUnique(nil, roots...)
+	if len(unique) < 2 {
+		return nil
+	}
+	labels := make(map[string]string, len(unique))
+	used := make(map[string]int)
+	for _, root := range unique {
+		name := filepath.Base(root)
+		if abs, err := filepath.Abs(root); err == nil {
+			name = filepath.Base(abs)
+		}
+		if name == string(filepath.Separator) || name == "." {
+			name = "root"
+		}
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+		labels[root] = name
+	}
+	return labels
+}
+
+// outputRelPath는 출력 디렉토리 아래에 재현할 입력 파일의 상대 경로를 반환합니다.
+// 루트와 파일 경로를 절대 경로로 맞춘 뒤 계산하므로 상대 경로 루트와 절대 경로 파일이 섞여도 됩니다.
+// 파일이 루트 밖에 있어 "../"가 생기거나 상대 경로를 계산할 수 없으면 경고 후 파일명만 사용합니다.
+func outputRelPath(inputRoot, filePath string) string {
+	base := filepath.Base(filePath)
+	absRoot, err := filepath.Abs(inputRoot)
+	if err != nil {
+		warnf("입력 루트 기준 경로 계산 실패, 파일명만 사용: %s (%v)", filePath, err)
+		return base
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		warnf("입력 루트 기준 경로 계산 실패, 파일명만 사용: %s (%v)", filePath, err)
+		return base
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		warnf("입력 루트(%s) 밖의 파일이므로 파일명만 사용: %s", inputRoot, filePath)
+		return base
+	}
+	return rel
+}
+
+// folderName은 Folder 열에 쓸, 파일이 들어 있는 디렉토리 이름을 반환합니다.
+// 절대 경로로 바꿔 계산하므로 입력 루트를 ".", "./dir", "dir/", 절대 경로 중 어느 것으로 지정해도 같은 값이 나오며,
+// 입력 루트 바로 아래 파일은 입력 루트 자체의 이름이 됩니다 (예: "."로 실행하면 현재 디렉토리 이름).
+func folderName(filePath string) string {
+	dir := filepath.Dir(filePath)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Base(dir)
+}
+
+// joinWithin은 dir 아래의 rel 경로를 반환합니다. 결과가 dir 밖을 가리키면 오류를 반환하여
+// 어떤 입력으로도 출력 디렉토리 밖에 파일을 만들지 않도록 합니다.
+func joinWithin(dir, rel string) (string, error) {
+	p := filepath.Join(dir, rel)
+	r, err := filepath.Rel(filepath.Clean(dir), p)
+	if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) || filepath.IsAbs(r) {
+		return "", fmt.Errorf("출력 경로가 %s 밖을 가리킴: %s", dir, rel)
+	}
+	return p, nil
 }
 
 // formatTime는 "2006-01-02 15:04:05" 형식을 "2006-01-02_150405" 형태로 변환합니다.
@@ -376,7 +1843,46 @@ func formatTime(sentDate string) string {
 	return sentDate
 }
 
+// noSubjectName은 제목이 비어 있을 때 파일명에 대신 쓰는 문구입니다.
+const noSubjectName = "(no subject)"
+
+// renameTarget은 "날짜_시각 제목.eml" 형식의 새 파일명을 만듭니다.
+// 제목이 비어 있으면 같은 시각의 메일끼리 겹치지 않도록 "(no subject)" 뒤에 Message-ID의 해시를,
+// Message-ID도 없으면 파일 내용의 해시를 8자리로 붙입니다. 충돌 시 붙이는 번호 접미사와는 형식이 다릅니다.
+func renameTarget(filePath string, record EmailRecord) (string, error) {
+	newTime := formatTime(record.SentDate)
+	subject := strings.TrimSpace(sanitizeFilename(record.Subject))
+	if subject == "" {
+		token, err := shortMessageToken(filePath, record.MessageID)
+		if err != nil {
+			return "", err
+		}
+		subject = noSubjectName + " " + token
+	}
+	return sanitizeFilename(fmt.Sprintf("%s %s.eml", newTime, subject)), nil
+}
+
+// shortMessageToken은 Message-ID(없으면 파일 내용)의 SHA-256 해시 앞 8자리를 반환합니다.
+func shortMessageToken(filePath, messageID string) (string, error) {
+	h := sha256.New()
+	if id := strings.TrimSpace(messageID); id != "" {
+		h.Write([]byte(id))
+	} else {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8], nil
+}
+
+// sanitizeFilename은 파일명에 쓸 수 없는 문자를 "_"로 바꾸고 제어 문자를 제거합니다.
 func sanitizeFilename(name string) string {
+	name = cleanText(name)
 	invalidChars := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
 	for _, char := range invalidChars {
 		name = strings.ReplaceAll(name, char, "_")
@@ -384,95 +1890,33 @@ func sanitizeFilename(name string) string {
 	return name
 }
 
-func writeHtmlFile(filePath, inputRoot, htmlOutDir, htmlContent string) error {
-	relPath, err := filepath.Rel(inputRoot, filePath)
+// writeJSONFile은 레코드를 writeHtmlFile과 같은 상대 경로 규칙으로 jsonOutDir 아래에 "이름.<RecordID>.json" 파일로 저장합니다.
+func writeJSONFile(relPath, jsonOutDir string, rec EmailRecord) error {
+	newRelPath := strings.TrimSuffix(relPath, filepath.Ext(relPath)) + "." + rec.RecordID + ".json"
+	outPath, err := joinWithin(jsonOutDir, newRelPath)
 	if err != nil {
 		return err
 	}
-	newRelPath := strings.TrimSuffix(relPath, filepath.Ext(relPath)) + ".html"
-	outPath := filepath.Join(htmlOutDir, newRelPath)
-
-	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-		return err
-	}
-	return os.WriteFile(outPath, []byte(htmlContent), 0644)
-}
-
-func extractUrls(htmlContent string) []string {
-	var urls []string
-	if strings.TrimSpace(htmlContent) == "" {
-		return urls
-	}
-	doc, err := html.Parse(strings.NewReader(htmlContent))
+	data, err := json.MarshalIndent(rec, "", "  ")
 	if err != nil {
-		return urlRegex.FindAllString(htmlContent, -1)
-	}
-	var crawler func(*html.Node)
-	crawler = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			for _, attr := range n.Attr {
-				if attr.Key == "href" && strings.HasPrefix(attr.Val, "http") {
-					urls = append(urls, attr.Val)
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			crawler(c)
-		}
-	}
-	crawler(doc)
-
-	unique := make(map[string]struct{})
-	var result []string
-	for _, u := range urls {
-		if _, exists := unique[u]; !exists {
-			unique[u] = struct{}{}
-			result = append(result, u)
-		}
+		return err
 	}
-	return result
-}
-
-func writeCsvToStdout(records []EmailRecord) {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
-
-	headers := []string{
-		"폴더", "제목", "보낸사람 이름", "보낸사람 이메일",
-		"받은사람 이름", "받은사람 이메일", "보낸 날짜", "X-Originating-IP",
-		"본문URL", "본문URL(도메인)", "원본",
-	}
-	writer.Write(headers)
-	for _, r := range records {
-		row := []string{
-			r.Folder,
-			r.Subject,
-			r.FromName,
-			r.FromEmail,
-			r.ToName,
-			r.ToEmail,
-			r.SentDate,
-			r.IP,
-			r.URLs,
-			r.URLDomains,
-			r.OriginalFile,
-		}
-		writer.Write(row)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
 	}
+	return os.WriteFile(outPath, append(data, '\n'), 0644)
 }
 
-func printJSON(records []EmailRecord) {
-	b, err := json.MarshalIndent(records, "", "  ")
+// writeHtmlFile은 HTML 본문을 relPath(원본의 출력 상대 경로) 기준 "원본이름.<RecordID>.html"로 저장하여 CSV/JSON 레코드와 연결할 수 있게 합니다.
+func writeHtmlFile(relPath, htmlOutDir, id, htmlContent string) error {
+	newRelPath := strings.TrimSuffix(relPath, filepath.Ext(relPath)) + "." + id + ".html"
+	outPath, err := joinWithin(htmlOutDir, newRelPath)
 	if err != nil {
-		log.Fatalf("[ERROR] JSON 변환 실패: %v", err)
+		return err
 	}
-	fmt.Println(string(b))
-}
 
-func printOutput(records []EmailRecord, jsonOutput bool, csvOutput bool) {
-	if jsonOutput {
-		printJSON(records)
-	} else if csvOutput {
-		writeCsvToStdout(records)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
 	}
+	return os.WriteFile(outPath, []byte(htmlContent), 0644)
 }
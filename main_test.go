@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// -update를 주면 testdata/*.golden.json을 현재 출력으로 다시 씁니다: go test -run TestGoldenRecords -update
+var updateGolden = flag.Bool("update", false, "testdata의 골든 파일 갱신")
+
+// goldenPath는 fixture에 대응하는 골든 파일 경로를 반환합니다.
+func goldenPath(fixture string) string {
+	return strings.TrimSuffix(fixture, filepath.Ext(fixture)) + ".golden.json"
+}
+
+// checkGolden은 rec을 JSON으로 바꿔 골든 파일과 비교합니다.
+func checkGolden(t *testing.T, fixture string, rec EmailRecord) {
+	t.Helper()
+	got, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	golden := goldenPath(fixture)
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("골든 파일 읽기 실패 (-update로 생성): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s 출력이 골든 파일과 다름\n--- got\n%s\n--- want\n%s", fixture, got, want)
+	}
+}
+
+func TestGoldenRecords(t *testing.T) {
+	tests := []struct {
+		fixture string
+		popts   parseOptions
+	}{
+		{fixture: "base64-html.eml"},
+		{fixture: "qp-soft-break.eml"},
+		{fixture: "7bit-text.eml"},
+		{fixture: "8bit-utf8.eml"},
+		{fixture: "from-unquoted-comma.eml"},
+		{fixture: "from-no-angle-brackets.eml"},
+		{fixture: "to-undisclosed-recipients.eml"},
+		{fixture: "base-href.eml"},
+		{fixture: "relative-no-base.eml"},
+		{fixture: "url-domains.eml"},
+		{fixture: "cp949.eml"},
+		{fixture: "shift_jis.eml"},
+		{fixture: "unknown-charset.eml"},
+		{fixture: "to-groups-comments.eml"},
+		{fixture: "bom-html.eml"},
+		{fixture: "phish-paypal.eml"},
+		{fixture: "many-urls.eml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.fixture)
+			rec, _, err := processEmlFile(path, tt.popts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, path, rec)
+		})
+	}
+}
+
+// 소프트 줄바꿈(=\n)이 href 한가운데에 있어도 URL이 이어 붙여져야 함
+func TestQuotedPrintableSoftBreakInHref(t *testing.T) {
+	rec, _, err := processEmlFile(filepath.Join("testdata", "qp-soft-break.eml"), parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"https://shop.example/deals/2024/week-36?utm_source=newsletter&utm_medium=email",
+		"https://cdn.shop.example/img/banner.png",
+	}
+	if got := strings.Split(rec.URLs, "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("URLs = %q, want %q", got, want)
+	}
+}
+
+// copyFixture는 testdata의 fixture를 임시 디렉토리에 n개 복사하고 처리할 파일 목록을 반환합니다.
+func copyFixture(t *testing.T, fixture string, n int) []collectedFile {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	files := make([]collectedFile, n)
+	for i := range files {
+		path := filepath.Join(root, fmt.Sprintf("%03d.eml", i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files[i] = collectedFile{root: root, path: path, size: int64(len(data))}
+	}
+	return files
+}
+
+// blockingWriter는 release가 닫힐 때까지 첫 레코드 기록에서 멈추는 느린 출력입니다.
+type blockingWriter struct {
+	release chan struct{}
+	written []EmailRecord
+}
+
+func (w *blockingWriter) WriteRecord(r EmailRecord) error {
+	<-w.release
+	w.written = append(w.written, r)
+	return nil
+}
+
+func (w *blockingWriter) Close() error { return nil }
+
+// 출력이 멈춰 있는 동안 처리되는 레코드 수는 기록 중인 1개 + 결과 채널 버퍼 + 워커 수를 넘지 않아야 함
+func TestSlowWriterBackpressure(t *testing.T) {
+	tests := []struct {
+		workers, buffer int
+		ordered         bool
+	}{
+		{workers: 1, buffer: 0},
+		{workers: 4, buffer: 0},
+		{workers: 4, buffer: 8},
+		{workers: 4, buffer: 8, ordered: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("workers=%d,buffer=%d,ordered=%v", tt.workers, tt.buffer, tt.ordered), func(t *testing.T) {
+			files := copyFixture(t, "7bit-text.eml", 60)
+			var processed atomic.Int64
+			opts := processOptions{
+				workerCount: tt.workers,
+				bufferSize:  tt.buffer,
+				ordered:     tt.ordered,
+				filters: recordFilters{newRecordFilter("count", func(*EmailRecord) bool {
+					processed.Add(1)
+					return true
+				})},
+			}
+			out := &blockingWriter{release: make(chan struct{})}
+			type runResult struct {
+				summary processSummary
+				err     error
+			}
+			finished := make(chan runResult, 1)
+			go func() {
+				summary, err := processFilesConcurrently(files, opts, out)
+				finished <- runResult{summary, err}
+			}()
+
+			time.Sleep(200 * time.Millisecond)
+			limit := int64(1 + tt.buffer + tt.workers)
+			if n := processed.Load(); n > limit {
+				t.Errorf("출력이 멈춘 동안 %d개 처리됨, 최대 %d개여야 함", n, limit)
+			}
+			close(out.release)
+
+			select {
+			case res := <-finished:
+				if res.err != nil {
+					t.Fatal(res.err)
+				}
+				if res.summary.succeeded != len(files) || len(out.written) != len(files) {
+					t.Errorf("성공 %d, 기록 %d, want %d", res.summary.succeeded, len(out.written), len(files))
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("출력 재개 후 처리가 끝나지 않음")
+			}
+		})
+	}
+}
+
+// writeEml은 content를 임시 디렉토리의 .eml 파일로 저장하고 경로를 반환합니다.
+// 헤더와 본문 구분을 눈으로 보기 쉽도록 content의 "\n"은 "\r\n"으로 바꾸지 않습니다.
+func writeEml(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "message.eml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// URLDomains는 호스트를 정규화(소문자, 기본 포트와 끝의 "." 제거)한 뒤 처음 나온 순서대로 중복을 제거하고,
+// URLs 열은 그대로 둬야 함. CSV의 도메인 열에도 같은 값이 나와야 함
+func TestURLDomainsNormalizedAndDeduped(t *testing.T) {
+	tests := []struct {
+		name        string
+		popts       parseOptions
+		wantDomains []string
+	}{
+		{"default", parseOptions{}, []string{
+			"track.example.net", "www.shop.example", "shop.example", "track.example.net:8443", "track.example.net:443",
+		}},
+		{"strip-www", parseOptions{stripWWW: true}, []string{
+			"track.example.net", "shop.example", "track.example.net:8443", "track.example.net:443",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", "url-domains.eml"), tt.popts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(rec.URLDomains, "\n"); !equalStrings(got, tt.wantDomains) {
+				t.Errorf("URLDomains = %q, want %q", got, tt.wantDomains)
+			}
+			if n := len(strings.Split(rec.URLs, "\n")); n != 10 {
+				t.Errorf("URLs에 %d개, want 10개 (URL 열은 중복 제거 안 함)", n)
+			}
+
+			var buf bytes.Buffer
+			w := newCSVRecordWriter(&buf)
+			if err := w.WriteRecord(rec); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			col := indexOf(rows[0], "본문URL(도메인)")
+			if col < 0 {
+				t.Fatal("CSV에 도메인 열이 없음")
+			}
+			if got := rows[1][col]; got != strings.Join(tt.wantDomains, "\n") {
+				t.Errorf("CSV 도메인 열 = %q", got)
+			}
+		})
+	}
+}
+
+// indexOf는 s에서 v의 위치를 반환합니다. 없으면 -1입니다.
+func indexOf(s []string, v string) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// chdir은 테스트 동안 작업 디렉토리를 dir로 바꾸고 끝나면 되돌립니다.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestOutputRelPath(t *testing.T) {
+	tmp := t.TempDir()
+	chdir(t, tmp)
+	for _, dir := range []string{"in/sub", "other"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := func(p string) string { return filepath.Join(tmp, p) }
+	tests := []struct {
+		name       string
+		root, file string
+		want       string
+	}{
+		{"relative root and file", "in", "in/sub/a.eml", "sub/a.eml"},
+		{"relative root with absolute file", "in", abs("in/sub/a.eml"), "sub/a.eml"},
+		{"absolute root with relative file", abs("in"), "in/sub/a.eml", "sub/a.eml"},
+		{"dot root", ".", "in/a.eml", "in/a.eml"},
+		{"trailing slash root", "in/", "in/a.eml", "a.eml"},
+		{"file outside root", "in", "other/b.eml", "b.eml"},
+		{"absolute file outside root", "in", abs("other/b.eml"), "b.eml"},
+		{"dot-dot in file path", "in", "in/../other/c.eml", "c.eml"},
+	}
+	for _, tt := range tests {
+		if got := outputRelPath(tt.root, tt.file); got != filepath.FromSlash(tt.want) {
+			t.Errorf("%s: outputRelPath(%q, %q) = %q, want %q", tt.name, tt.root, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestJoinWithin(t *testing.T) {
+	tests := []struct {
+		dir, rel string
+		want     string // 빈 문자열이면 오류
+	}{
+		{"out", "a/b.html", "out/a/b.html"},
+		{"out", "b.html", "out/b.html"},
+		{"out/", "./a/../b.html", "out/b.html"},
+		{"out", "../b.html", ""},
+		{"out", "a/../../b.html", ""},
+		{"out", "/etc/passwd", "out/etc/passwd"},
+		{"/tmp/out", "../../etc/x", ""},
+	}
+	for _, tt := range tests {
+		got, err := joinWithin(tt.dir, tt.rel)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("joinWithin(%q, %q) = %q, want 오류", tt.dir, tt.rel, got)
+			}
+			continue
+		}
+		if err != nil || got != filepath.FromSlash(tt.want) {
+			t.Errorf("joinWithin(%q, %q) = %q, %v, want %q", tt.dir, tt.rel, got, err, tt.want)
+		}
+	}
+}
+
+// 입력 루트 밖의 파일도 HTML 출력은 출력 디렉토리 안에만 만들어야 함
+func TestWriteHtmlFileOutsideRootStaysInOutputDir(t *testing.T) {
+	tmp := t.TempDir()
+	chdir(t, tmp)
+	out := filepath.Join(tmp, "html")
+	rel := outputRelPath("in", filepath.Join(tmp, "elsewhere", "x.eml"))
+	if err := writeHtmlFile(rel, out, "id", "<p>x</p>"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "x.id.html")); err != nil {
+		t.Errorf("출력 디렉토리 바로 아래에 파일명만으로 만들어야 함: %v", err)
+	}
+	if err := writeHtmlFile("../escape.eml", out, "id", "<p>x</p>"); err == nil {
+		t.Error("출력 디렉토리 밖을 가리키는 경로를 거부하지 않음")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "escape.id.html")); !os.IsNotExist(err) {
+		t.Error("출력 디렉토리 밖에 파일이 생김")
+	}
+}
+
+// 같은 파일은 입력 루트를 ".", "./dir", "dir/", 절대 경로 중 어느 것으로 지정해도 Folder가 같아야 함
+func TestFolderNameIndependentOfRootSpelling(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "mail", "inbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		cwd, root string
+		rel       string // 루트 아래 파일의 상대 경로
+		want      string
+	}{
+		{"dot", "mail", ".", "x.eml", "mail"},
+		{"dot-slash dir", "", "./mail", "x.eml", "mail"},
+		{"trailing slash", "", "mail/", "x.eml", "mail"},
+		{"absolute", "", filepath.Join(tmp, "mail"), "x.eml", "mail"},
+		{"dot nested", "mail", ".", "inbox/y.eml", "inbox"},
+		{"trailing slash nested", "", "mail/", "inbox/y.eml", "inbox"},
+		{"absolute nested", "", filepath.Join(tmp, "mail"), "inbox/y.eml", "inbox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdir(t, filepath.Join(tmp, tt.cwd))
+			// collect와 같이 루트와 상대 경로를 이어 파일 경로를 만듦
+			path := filepath.Join(tt.root, filepath.FromSlash(tt.rel))
+			if got := folderName(path); got != tt.want {
+				t.Errorf("folderName(%q) = %q, want %q", path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputRootLabels(t *testing.T) {
+	tests := []struct {
+		roots []string
+		want  map[string]string
+	}{
+		{[]string{"a"}, nil},
+		{[]string{"a", "a"}, nil},
+		{[]string{"in/a", "in/b"}, map[string]string{"in/a": "a", "in/b": "b"}},
+		{[]string{"x/mail", "y/mail", "z/mail"}, map[string]string{"x/mail": "mail", "y/mail": "mail-2", "z/mail": "mail-3"}},
+		{[]string{"x/mail/", "y/mail"}, map[string]string{"x/mail/": "mail", "y/mail": "mail-2"}},
+		{[]string{"/", "in/a"}, map[string]string{"/": "root", "in/a": "a"}},
+	}
+	for _, tt := range tests {
+		got := outputRootLabels(tt.roots)
+		if len(got) != len(tt.want) {
+			t.Errorf("outputRootLabels(%q) = %v, want %v", tt.roots, got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("outputRootLabels(%q) = %v, want %v", tt.roots, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// 입력 루트가 두 개이면 같은 상대 경로의 파일도 루트 이름 아래에 따로 저장되어야 함
+func TestTwoRootsKeepSeparateOutputTrees(t *testing.T) {
+	tmp := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("testdata", "7bit-text.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []collectedFile
+	var roots []string
+	for i, root := range []string{filepath.Join(tmp, "a", "mail"), filepath.Join(tmp, "b", "mail"), filepath.Join(tmp, "c")} {
+		path := filepath.Join(root, "sub", "x.eml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		// 내용이 다르면 RecordID도 달라야 함
+		if err := os.WriteFile(path, append([]byte(fmt.Sprintf("X-Copy: %d\n", i)), data...), 0644); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		files = append(files, collectedFile{root: root, path: path})
+	}
+	out := filepath.Join(tmp, "html")
+	opts := processOptions{workerCount: 2, ordered: true, htmlOutDir: out, rootLabels: outputRootLabels(roots)}
+	w := &recordingWriter{}
+	if _, err := processFilesConcurrently(files, opts, w); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.written) != len(files) {
+		t.Fatalf("기록 %d, want %d", len(w.written), len(files))
+	}
+	ids := make(map[string]bool)
+	for i, label := range []string{"mail", "mail-2", "c"} {
+		rec := w.written[i]
+		if ids[rec.RecordID] {
+			t.Errorf("RecordID가 겹침: %s", rec.RecordID)
+		}
+		ids[rec.RecordID] = true
+		want := filepath.Join(out, label, "sub", "x."+rec.RecordID+".html")
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("루트 %s의 HTML 파일이 없음: %v", files[i].root, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileSHA256은 파일 내용의 SHA-256을 16진수 문자열로 반환합니다.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashingReader는 읽은 바이트를 SHA-256에 함께 기록하여, 파싱하면서 읽은 내용으로 파일 해시를 구합니다.
+// 파싱 후 RecordID/증거 목록을 위해 파일을 처음부터 다시 읽지 않도록 합니다.
+type hashingReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func newHashingReader(r io.Reader) *hashingReader {
+	return &hashingReader{r: r, h: sha256.New()}
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	return n, err
+}
+
+// finish는 파싱에서 읽지 않은 나머지를 마저 읽어 전체 내용의 SHA-256을 반환합니다.
+func (hr *hashingReader) finish() (string, error) {
+	if _, err := io.Copy(hr.h, hr.r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hr.h.Sum(nil)), nil
+}
+
+// sha256Manifest는 입력 파일의 해시를 `sha256sum -c`로 검증할 수 있는 coreutils 형식("<해시>  <경로>")으로 기록합니다 (-sha256-manifest).
+// 결과가 나오는 대로 한 줄씩 기록하므로 파일 수와 관계없이 메모리를 쓰지 않습니다. 한 고루틴에서만 호출합니다.
+type sha256Manifest struct {
+	f   *os.File
+	w   *bufio.Writer
+	cwd string
+}
+
+func newSHA256Manifest(path string) (*sha256Manifest, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sha256Manifest{f: f, w: bufio.NewWriter(f), cwd: cwd}, nil
+}
+
+// add는 파일 한 줄을 기록합니다. 경로는 현재 디렉토리 기준 상대 경로이므로 같은 디렉토리에서 `sha256sum -c`로 검증합니다.
+// 경로에 역슬래시나 줄바꿈이 있으면 coreutils처럼 줄 앞에 "\"를 붙이고 이스케이프합니다.
+func (m *sha256Manifest) add(path, sum string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(m.cwd, abs); err == nil {
+			path = rel
+		}
+	}
+	prefix := ""
+	if strings.ContainsAny(path, "\\\n\r") {
+		prefix = "\\"
+		path = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+	}
+	_, err := m.w.WriteString(prefix + sum + "  " + path + "\n")
+	return err
+}
+
+func (m *sha256Manifest) Close() error {
+	err := m.w.Flush()
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const abcSHA256 = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abc.eml")
+	if err := os.WriteFile(path, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := fileSHA256(path); err != nil || got != abcSHA256 {
+		t.Errorf("fileSHA256 = %q, %v", got, err)
+	}
+	if _, err := fileSHA256(filepath.Join(t.TempDir(), "missing.eml")); err == nil {
+		t.Error("없는 파일: 오류가 없음")
+	}
+}
+
+// 파싱이 중간까지만 읽어도 finish는 전체 내용의 해시를 반환해야 함
+func TestHashingReaderFinish(t *testing.T) {
+	for _, n := range []int64{0, 1, 3} {
+		hr := newHashingReader(strings.NewReader("abc"))
+		if _, err := io.CopyN(io.Discard, hr, n); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := hr.finish(); err != nil || got != abcSHA256 {
+			t.Errorf("%d바이트 읽은 뒤 finish = %q, %v", n, got, err)
+		}
+	}
+}
+
+// 경로는 현재 디렉토리 기준 상대 경로로, 역슬래시/줄바꿈이 있는 경로는 coreutils처럼 "\"를 붙여 이스케이프
+func TestSHA256Manifest(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	m, err := newSHA256Manifest("manifest.sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []struct{ path, sum string }{
+		{filepath.Join(dir, "in", "a.eml"), "aa"},
+		{filepath.Join("in", "b.eml"), "bb"},
+		{"in/c\\d.eml", "cc"},
+		{"in/line\nbreak.eml", "dd"},
+	}
+	for _, e := range entries {
+		if err := m.add(e.path, e.sum); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "aa  " + filepath.Join("in", "a.eml") + "\n" +
+		"bb  " + filepath.Join("in", "b.eml") + "\n" +
+		"\\cc  in/c\\\\d.eml\n" +
+		"\\dd  in/line\\nbreak.eml\n"
+	if string(data) != want {
+		t.Errorf("목록 =\n%q\nwant\n%q", data, want)
+	}
+}
+
+func TestSHA256ManifestCreateError(t *testing.T) {
+	if _, err := newSHA256Manifest(filepath.Join(t.TempDir(), "missing", "manifest.sha256")); err == nil {
+		t.Error("없는 디렉토리: 오류가 없음")
+	}
+}
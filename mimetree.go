@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-message"
+)
+
+// mimePart는 MIME 트리의 한 노드입니다.
+// 첨부가 아닌 text/* 리프는 본문을 메모리에 읽어 두고, 그 외 리프의 본문은 버립니다.
+type mimePart struct {
+	mediaType   string
+	params      map[string]string
+	charset     string
+	disposition string
+	filename    string
+	contentID   string
+	body        []byte
+	hiddenHTML  string // 본문 외 파트에 숨겨진(base64 등) HTML
+	// charsetFallback은 알 수 없는 문자셋이라 본문을 Latin-1로 읽었음을 나타냅니다.
+	charsetFallback bool
+	sha256          string // 본문으로 읽지 않은 리프(첨부 등)의 디코딩된 내용 SHA-256
+	children        []*mimePart
+}
+
+func (p *mimePart) isMultipart() bool {
+	return strings.HasPrefix(p.mediaType, "multipart/")
+}
+
+func (p *mimePart) isAttachment() bool {
+	return p.disposition == "attachment"
+}
+
+// readMIMETree는 엔티티를 끝까지 읽어 MIME 트리를 구성합니다.
+// 알 수 없는 문자셋/인코딩을 가진 파트는 가능한 범위에서 읽고 계속 진행합니다.
+func readMIMETree(e *message.Entity) (*mimePart, error) {
+	p := &mimePart{}
+	p.mediaType, p.params = parseContentType(e.Header.Get("Content-Type"))
+	p.charset = p.params["charset"]
+	var dispParams map[string]string
+	p.disposition, dispParams, _ = e.Header.ContentDisposition()
+	p.filename = dispParams["filename"]
+	if p.filename == "" {
+		p.filename = p.params["name"]
+	}
+	p.contentID = strings.Trim(e.Header.Get("Content-Id"), "<> ")
+
+	if mr := e.MultipartReader(); mr != nil {
+		for {
+			child, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil && !message.IsUnknownCharset(err) && !message.IsUnknownEncoding(err) {
+				return p, err
+			}
+			c, err := readMIMETree(child)
+			if c != nil {
+				p.children = append(p.children, c)
+			}
+			if err != nil {
+				return p, err
+			}
+		}
+		return p, nil
+	}
+
+	if strings.HasPrefix(p.mediaType, "text/") && !p.isAttachment() {
+		body, err := io.ReadAll(entityBody(e))
+		p.body = trimBodyPrefix(body)
+		if p.charset != "" {
+			_, known := lookupCharset(p.charset)
+			p.charsetFallback = !known
+		}
+		return p, err
+	}
+	// 일정 초대(ICS)는 첨부로 와도 필드를 추출할 수 있도록 본문을 읽어 둠
+	h := sha256.New()
+	if isCalendarPart(p) {
+		r := io.TeeReader(entityBody(e), h)
+		body, err := io.ReadAll(io.LimitReader(r, maxCalendarBytes))
+		io.Copy(io.Discard, r)
+		p.body = trimBodyPrefix(body)
+		p.sha256 = hex.EncodeToString(h.Sum(nil))
+		return p, err
+	}
+	// 첨부 등 본문이 아닌 파트는 읽으면서 해시만 남김
+	body := io.TeeReader(entityBody(e), h)
+	var err error
+	if !strings.HasPrefix(p.mediaType, "text/html") {
+		p.hiddenHTML = scanHiddenHTML(body)
+	} else {
+		_, err = io.Copy(io.Discard, body)
+	}
+	p.sha256 = hex.EncodeToString(h.Sum(nil))
+	return p, err
+}
+
+// 파라미터 파싱에 실패한 Content-Type에서 charset만이라도 찾기 위한 정규식
+var charsetParamRegex = regexp.MustCompile(`(?i)charset\s*=\s*"?([^";\s]+)`)
+
+// parseContentType은 Content-Type 헤더 값을 mime.ParseMediaType으로 파싱하여
+// 소문자 미디어 타입과 파라미터를 반환합니다. 대소문자, BOM, 앞뒤 공백을 허용하며,
+// 형식이 잘못된 값도 파트를 버리지 않도록 ";" 앞부분을 미디어 타입으로, 읽을 수 있는 "이름=값"을 파라미터로 사용합니다.
+// 헤더가 없거나 미디어 타입을 알 수 없으면 RFC 2045 기본값인 text/plain으로 간주합니다.
+func parseContentType(value string) (string, map[string]string) {
+	value = strings.TrimSpace(strings.TrimPrefix(value, "\ufeff"))
+	if value == "" {
+		return "text/plain", map[string]string{}
+	}
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		// 파라미터만 잘못된 경우 mime.ParseMediaType은 미디어 타입은 반환함
+		if mediaType == "" {
+			mediaType = value
+			if i := strings.IndexAny(mediaType, "; \t"); i >= 0 {
+				mediaType = mediaType[:i]
+			}
+		}
+		params = lenientMediaParams(value)
+		if _, ok := params["charset"]; !ok {
+			if m := charsetParamRegex.FindStringSubmatch(value); m != nil {
+				params["charset"] = m[1]
+			}
+		}
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if !strings.Contains(mediaType, "/") {
+		mediaType = "text/plain"
+	}
+	return mediaType, params
+}
+
+// lenientMediaParams는 mime.ParseMediaType이 거부한 Content-Type 값에서 ";"로 나뉜 "이름=값" 파라미터를 가능한 만큼 읽습니다.
+// 따옴표가 닫히지 않았거나 값에 허용되지 않는 문자가 있어도 boundary 같은 파라미터를 잃지 않기 위한 것입니다.
+func lenientMediaParams(value string) map[string]string {
+	params := map[string]string{}
+	fields := strings.Split(value, ";")
+	for _, f := range fields[1:] {
+		key, val, ok := strings.Cut(f, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"") {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if strings.HasPrefix(val, `"`) {
+			val = strings.TrimPrefix(val, `"`)
+			if i := strings.Index(val, `"`); i >= 0 {
+				val = val[:i]
+			}
+		}
+		if _, dup := params[key]; !dup && val != "" {
+			params[key] = val
+		}
+	}
+	return params
+}
+
+// selectBody는 MIME 트리에서 mediaType에 해당하는 본문 파트를 선택합니다.
+//   - Content-Disposition: attachment 파트는 건너뜁니다.
+//   - multipart/alternative는 RFC 2046에 따라 가장 뒤(가장 풍부한) 대안부터 찾습니다.
+//   - multipart/related는 start 파라미터가 가리키는 루트 파트(없으면 첫 파트)를 먼저 찾습니다.
+//   - 그 외 multipart(mixed 등)는 앞에서부터 처음 찾은 파트를 사용합니다.
+func selectBody(p *mimePart, mediaType string) *mimePart {
+	if p == nil {
+		return nil
+	}
+	if !p.isMultipart() {
+		if p.isAttachment() || p.mediaType != mediaType {
+			return nil
+		}
+		return p
+	}
+
+	switch p.mediaType {
+	case "multipart/alternative":
+		for i := len(p.children) - 1; i >= 0; i-- {
+			if found := selectBody(p.children[i], mediaType); found != nil {
+				return found
+			}
+		}
+		return nil
+	case "multipart/related":
+		start := strings.Trim(p.params["start"], "<> ")
+		root := 0
+		for i, c := range p.children {
+			if start != "" && c.contentID == start {
+				root = i
+				break
+			}
+		}
+		if root < len(p.children) {
+			if found := selectBody(p.children[root], mediaType); found != nil {
+				return found
+			}
+		}
+		for i, c := range p.children {
+			if i == root {
+				continue
+			}
+			if found := selectBody(c, mediaType); found != nil {
+				return found
+			}
+		}
+		return nil
+	default:
+		for _, c := range p.children {
+			if found := selectBody(c, mediaType); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+}
+
+// utf8BOM은 UTF-8 바이트 순서 표시입니다.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// trimBodyPrefix는 본문 앞의 UTF-8 BOM과 NUL 바이트를 제거합니다.
+// Outlook이 만든 HTML 파트 등에서 나타나며, 남겨 두면 HTML 저장 파일 앞에 보이는 문자로 표시됩니다.
+func trimBodyPrefix(body []byte) []byte {
+	for {
+		switch {
+		case bytes.HasPrefix(body, utf8BOM):
+			body = body[len(utf8BOM):]
+		case len(body) > 0 && body[0] == 0:
+			body = body[1:]
+		default:
+			return body
+		}
+	}
+}
+
+// hasCharsetFallback은 트리에 알 수 없는 문자셋을 Latin-1로 읽은 본문이 있는지 확인합니다.
+func hasCharsetFallback(p *mimePart) bool {
+	if p == nil {
+		return false
+	}
+	if p.charsetFallback {
+		return true
+	}
+	for _, c := range p.children {
+		if hasCharsetFallback(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// countAttachments는 첨부 파일 수를 셉니다. Content-Disposition이 attachment인 파트와,
+// 파일명이 있는 본문 외 리프를 첨부로 봅니다. multipart/related 안의 인라인 리소스(본문 이미지 등)는 제외합니다.
+func countAttachments(p *mimePart) int {
+	return len(attachmentParts(p, ""))
+}
+
+// attachmentParts는 첨부로 보는 리프를 트리 순서대로 반환합니다.
+// Content-Disposition이 attachment이거나, 파일명이 있는 비텍스트 파트 중 인라인/related 리소스가 아닌 것입니다.
+func attachmentParts(p *mimePart, parentType string) []*mimePart {
+	if p == nil {
+		return nil
+	}
+	if p.isMultipart() {
+		var parts []*mimePart
+		for _, c := range p.children {
+			parts = append(parts, attachmentParts(c, p.mediaType)...)
+		}
+		return parts
+	}
+	if p.isAttachment() {
+		return []*mimePart{p}
+	}
+	if p.disposition != "inline" && parentType != "multipart/related" &&
+		p.filename != "" && !strings.HasPrefix(p.mediaType, "text/") {
+		return []*mimePart{p}
+	}
+	return nil
+}
+
+// attachmentHashes는 첨부 파트의 SHA-256을 트리 순서대로 반환합니다.
+func attachmentHashes(p *mimePart) []string {
+	var hashes []string
+	for _, a := range attachmentParts(p, "") {
+		if a.sha256 != "" {
+			hashes = append(hashes, a.sha256)
+		}
+	}
+	return hashes
+}
+
+// attachmentNames는 첨부 파트의 파일명(RFC 2047 encoded-word는 디코딩)과 선언된 MIME 형식(소문자)을 트리 순서대로 반환합니다.
+// 두 목록은 같은 순서이며, 파일명이 없는 첨부는 빈 문자열입니다.
+func attachmentNames(p *mimePart) (names, types []string) {
+	for _, a := range attachmentParts(p, "") {
+		name := a.filename
+		if strings.Contains(name, "=?") {
+			name, _ = decodeHeaderLenient(name)
+		}
+		names = append(names, name)
+		types = append(types, strings.ToLower(a.mediaType))
+	}
+	return names, types
+}
+
+// allBodies는 첨부가 아닌 mediaType 파트를 트리 순서대로 모두 반환합니다.
+func allBodies(p *mimePart, mediaType string) []*mimePart {
+	if p == nil {
+		return nil
+	}
+	if !p.isMultipart() {
+		if p.isAttachment() || p.mediaType != mediaType {
+			return nil
+		}
+		return []*mimePart{p}
+	}
+	var parts []*mimePart
+	for _, c := range p.children {
+		parts = append(parts, allBodies(c, mediaType)...)
+	}
+	return parts
+}
+
+// joinHTMLParts는 여러 HTML 파트를 구분 주석을 넣어 하나의 문서로 잇습니다.
+func joinHTMLParts(parts []*mimePart) string {
+	var b strings.Builder
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "<!-- emla: text/html part %d/%d -->\n", i+1, len(parts))
+		b.Write(p.body)
+	}
+	return b.String()
+}
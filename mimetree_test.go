@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseContentType(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantType   string
+		wantParams map[string]string
+	}{
+		{"text/html; charset=utf-8", "text/html", map[string]string{"charset": "utf-8"}},
+		{"Text/HTML; charset=UTF-8", "text/html", map[string]string{"charset": "UTF-8"}},
+		{"TEXT/PLAIN", "text/plain", map[string]string{}},
+		{"text/html; charset=\"euc-kr\"; format=flowed; delsp=yes", "text/html", map[string]string{"charset": "euc-kr", "format": "flowed", "delsp": "yes"}},
+		{"multipart/alternative; boundary=\"=_b1\"; type=text/html", "multipart/alternative", map[string]string{"boundary": "=_b1", "type": "text/html"}},
+		{"  text/html ; charset=utf-8  ", "text/html", map[string]string{"charset": "utf-8"}},
+		{"\ufefftext/html; charset=utf-8", "text/html", map[string]string{"charset": "utf-8"}},
+		{"", "text/plain", map[string]string{}},
+		// 잘못된 값: 파트를 버리지 않고 ";" 앞부분과 찾을 수 있는 charset을 사용
+		{"text/html; charset=utf-8; ;", "text/html", map[string]string{"charset": "utf-8"}},
+		{"text/html; charset=\"utf-8", "text/html", map[string]string{"charset": "utf-8"}},
+		{"text/html charset=utf-8", "text/html", map[string]string{"charset": "utf-8"}},
+		{"text/html;; name=a b.html", "text/html", map[string]string{"name": "a b.html"}},
+		{"html", "text/plain", map[string]string{}},
+		{"Text/HTML; Charset = \"ISO-8859-1\" foo", "text/html", map[string]string{"charset": "ISO-8859-1"}},
+	}
+	for _, tt := range tests {
+		gotType, gotParams := parseContentType(tt.value)
+		if gotType != tt.wantType || !reflect.DeepEqual(gotParams, tt.wantParams) {
+			t.Errorf("parseContentType(%q) = %q, %v, want %q, %v", tt.value, gotType, gotParams, tt.wantType, tt.wantParams)
+		}
+	}
+}
+
+// 대문자/잘못된 Content-Type의 HTML 파트도 본문으로 선택되어 URL을 추출해야 함
+func TestHTMLPartWithUnusualContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantCharset string
+	}{
+		{"uppercase", "Text/HTML; Charset=UTF-8", "UTF-8"},
+		{"extra params", "text/html; format=fixed; charset=utf-8; name=body.html", "utf-8"},
+		{"unterminated quote", "text/html; charset=\"utf-8", "utf-8"},
+		{"stray semicolons", "text/html;; charset=utf-8;", "utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeEml(t, "From: a@example.com\nTo: b@example.org\nSubject: ct\nDate: Mon, 2 Sep 2024 09:00:00 +0000\n"+
+				"MIME-Version: 1.0\nContent-Type: multipart/alternative; boundary=\"b\"\n\n"+
+				"--b\nContent-Type: text/plain\n\nplain body\n"+
+				"--b\nContent-Type: "+tt.contentType+"\n\n<a href=\"https://example.com/x\">x</a>\n"+
+				"--b--\n")
+			rec, htmlContent, err := processEmlFile(path, parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rec.HasHTML || htmlContent == "" {
+				t.Fatalf("HTML 파트를 찾지 못함: HasHTML=%v", rec.HasHTML)
+			}
+			if rec.URLs != "https://example.com/x" {
+				t.Errorf("URLs = %q", rec.URLs)
+			}
+			if rec.HTMLCharset != tt.wantCharset {
+				t.Errorf("HTMLCharset = %q, want %q", rec.HTMLCharset, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestTrimBodyPrefix(t *testing.T) {
+	tests := []struct{ body, want string }{
+		{"\xef\xbb\xbf<html>", "<html>"},
+		{"\x00\x00<html>", "<html>"},
+		{"\xef\xbb\xbf\x00\xef\xbb\xbf<html>", "<html>"},
+		{"<html>\x00", "<html>\x00"},
+		{"", ""},
+		{"\xef\xbb", "\xef\xbb"},
+	}
+	for _, tt := range tests {
+		if got := string(trimBodyPrefix([]byte(tt.body))); got != tt.want {
+			t.Errorf("trimBodyPrefix(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+// BOM과 NUL로 시작하는 HTML 파트도 URL을 추출하고, 저장한 HTML 파일은 BOM 없이 "<"로 시작해야 함
+func TestBOMPrefixedHTMLPart(t *testing.T) {
+	rec, htmlContent, err := processEmlFile(filepath.Join("testdata", "bom-html.eml"), parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.URLs != "https://portal.example.com/reset" {
+		t.Errorf("URLs = %q", rec.URLs)
+	}
+	if !strings.HasPrefix(htmlContent, "<html>") {
+		t.Errorf("HTML 본문이 %q로 시작함", htmlContent[:min(len(htmlContent), 8)])
+	}
+	out := t.TempDir()
+	if err := writeHtmlFile("bom-html.eml", out, "id", htmlContent); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "bom-html.id.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("<html>")) {
+		t.Errorf("저장한 HTML 파일이 %q로 시작함", data[:min(len(data), 8)])
+	}
+}
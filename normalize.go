@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// zeroWidthRunes는 화면에 보이지 않아 필터 우회에 쓰이는 문자입니다.
+var zeroWidthRunes = map[rune]bool{
+	'\u00ad': true, // soft hyphen
+	'\u034f': true, // combining grapheme joiner
+	'\u061c': true, // arabic letter mark
+	'\u115f': true, // hangul choseong filler
+	'\u1160': true, // hangul jungseong filler
+	'\u180e': true, // mongolian vowel separator
+	'\u200b': true, '\u200c': true, '\u200d': true, '\u200e': true, '\u200f': true,
+	'\u202a': true, '\u202b': true, '\u202c': true, '\u202d': true, '\u202e': true,
+	'\u2060': true, '\u2061': true, '\u2062': true, '\u2063': true, '\u2064': true,
+	'\u3164': true, // hangul filler
+	'\ufeff': true, // BOM / zero width no-break space
+}
+
+// confusableRunes는 라틴 문자와 모양이 같은 키릴/그리스 문자입니다.
+// 원래 문자인 단어(러시아어 등)를 바꾸지 않도록 ASCII 글자와 섞인 단어에서만 치환합니다.
+var confusableRunes = map[rune]rune{
+	// 키릴
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'і': 'i', 'ј': 'j', 'ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O',
+	'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X', 'І': 'I', 'Ј': 'J', 'Ѕ': 'S',
+	// 그리스
+	'ο': 'o', 'ν': 'v', 'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H',
+	'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T',
+	'Υ': 'Y', 'Χ': 'X',
+}
+
+// normalizeSubject는 제목에서 폭 없는 문자를 지우고, NFKC 정규화(전각/수학 기호 문자 → ASCII)와
+// 라틴 문자와 섞인 단어의 키릴/그리스 동형 문자 치환을 적용합니다. 캠페인 제목 묶기와 비교에 사용합니다.
+func normalizeSubject(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if zeroWidthRunes[r] || unicode.Is(unicode.Variation_Selector, r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = norm.NFKC.String(s)
+
+	words := strings.Fields(s)
+	for i, w := range words {
+		if !hasASCIILetter(w) {
+			continue
+		}
+		words[i] = strings.Map(func(r rune) rune {
+			if c, ok := confusableRunes[r]; ok {
+				return c
+			}
+			return r
+		}, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// bidiControlRunes는 표시 순서를 바꾸는 양방향 제어 문자입니다. "exe.pdf"처럼 확장자를 위장하는 데 쓰입니다.
+var bidiControlRunes = map[rune]bool{
+	'\u061c': true, '\u200e': true, '\u200f': true,
+	'\u202a': true, '\u202b': true, '\u202c': true, '\u202d': true, '\u202e': true,
+	'\u2066': true, '\u2067': true, '\u2068': true, '\u2069': true,
+}
+
+// cleanText는 제목/이름 같은 한 줄 필드에서 C0/C1 제어 문자와 양방향 제어 문자를 제거하고 NFC로 정규화합니다.
+// 깨진 메일 프로그램이 넣은 탭/CR/LF 등 공백류 제어 문자는 공백 하나로 바꿉니다.
+func cleanText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\v' || r == '\f' || r == '\r' || r == '\u0085':
+			return ' '
+		case unicode.IsControl(r) || bidiControlRunes[r]:
+			return -1
+		}
+		return r
+	}, s)
+	return norm.NFC.String(s)
+}
+
+func hasASCIILetter(s string) bool {
+	for _, r := range s {
+		if r < unicode.MaxASCII && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// 제목의 CR, TAB, U+202E(RLO)는 제목 필드, CSV 셀, 재명명 파일명 어디에도 남지 않아야 함
+func TestSubjectControlCharacters(t *testing.T) {
+	raw := "Invoice\r\tpaid \u202efdp.exe\u0007 cafe\u0301"
+	path := writeEml(t, "From: a@example.com\nTo: b@example.org\n"+
+		"Subject: =?UTF-8?B?"+base64.StdEncoding.EncodeToString([]byte(raw))+"?=\n"+
+		"Date: Mon, 2 Sep 2024 09:00:00 +0000\nMessage-ID: <ctl-1@example.com>\n\nbody\n")
+	rec, _, err := processEmlFile(path, parseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "Invoice  paid fdp.exe caf\u00e9"
+	if rec.Subject != want {
+		t.Errorf("Subject = %q, want %q", rec.Subject, want)
+	}
+
+	var buf bytes.Buffer
+	w := newCSVRecordWriter(&buf)
+	if err := w.WriteRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cell := rows[1][indexOf(rows[0], "제목")]; cell != want {
+		t.Errorf("CSV 제목 셀 = %q, want %q", cell, want)
+	}
+
+	name, err := renameTarget(path, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(name, " "+want+".eml") {
+		t.Errorf("파일명 = %q, want \"<날짜> %s.eml\"", name, want)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || r == '\u202e' {
+			t.Errorf("파일명에 제어 문자 %U가 남음: %q", r, name)
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a\tb", "a b"},
+		{"a\r\nb", "a  b"},
+		{"a\vb\fc", "a b c"},
+		{"a\x00b\x1bc\x7f", "abc"},
+		{"a\u0080b\u009fc", "abc"},
+		{"a\u0085b", "a b"},
+		{"\u202eevil\u202c \u200fx\u2066y\u2069", "evil xy"},
+		{"cafe\u0301", "caf\u00e9"},
+		{"가", "가"},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := cleanText(tt.in); got != tt.want {
+			t.Errorf("cleanText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`a/b\c:d*e?f"g<h>i|j`, "a_b_c_d_e_f_g_h_i_j"},
+		{"tab\there", "tab here"},
+		{"cr\rlf\n", "cr lf "},
+		{"\u202egpj.exe", "gpj.exe"},
+		{"bell\u0007", "bell"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.in); got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
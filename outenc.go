@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/transform"
+)
+
+// 출력 인코딩 (-output-encoding)
+const (
+	outputUTF8  = "utf-8"
+	outputEUCKR = "euc-kr"
+)
+
+// outputEncoder는 -output-encoding 값에 맞는 인코더를 반환합니다. UTF-8이면 nil을 반환합니다.
+func outputEncoder(name string) (*encoding.Encoder, error) {
+	switch strings.ToLower(name) {
+	case outputUTF8, "utf8":
+		return nil, nil
+	case outputEUCKR, "euckr", "cp949":
+		return korean.EUCKR.NewEncoder(), nil
+	default:
+		return nil, fmt.Errorf("지원하지 않는 출력 인코딩: %q (utf-8 또는 euc-kr)", name)
+	}
+}
+
+// unsupportedReplacement는 대상 인코딩으로 나타낼 수 없는 문자 대신 출력하는 문자입니다.
+const unsupportedReplacement = '?'
+
+// replacingEncoder는 대상 인코딩으로 나타낼 수 없는 문자를 오류 대신 '?'로 바꿔 출력합니다.
+// x/text의 ReplaceUnsupported는 제어 문자(0x1A)로 바꾸므로 스프레드시트에서 보이도록 따로 처리합니다.
+type replacingEncoder struct {
+	enc transform.Transformer
+}
+
+func (t replacingEncoder) Reset() { t.enc.Reset() }
+
+func (t replacingEncoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for {
+		dn, sn, err := t.enc.Transform(dst[nDst:], src[nSrc:], atEOF)
+		nDst += dn
+		nSrc += sn
+		// 인코딩할 수 없는 문자는 Replacement()를 가진 오류로 알려 줌
+		if _, unsupported := err.(interface{ Replacement() byte }); !unsupported {
+			return nDst, nSrc, err
+		}
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		_, size := utf8.DecodeRune(src[nSrc:])
+		dst[nDst] = unsupportedReplacement
+		nDst++
+		nSrc += size
+	}
+}
+
+// encodedRecordWriter는 출력을 인코딩 변환기에 통과시키고, 닫을 때 남은 변환 결과를 내보냅니다.
+type encodedRecordWriter struct {
+	recordWriter
+	enc io.Closer
+}
+
+// Flush는 크기 기준 분할(-rotate-size)이 변환된 출력 크기를 확인할 수 있도록 내부 writer의 버퍼를 내보냅니다.
+func (e encodedRecordWriter) Flush() error {
+	if f, ok := e.recordWriter.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (e encodedRecordWriter) Close() error {
+	if err := e.recordWriter.Close(); err != nil {
+		return err
+	}
+	return e.enc.Close()
+}
+
+// newEncodedRecordWriter는 w에 enc로 변환하여 쓰는 writer를 만들어 newOut에 넘깁니다. enc가 nil이면 그대로 씁니다.
+func newEncodedRecordWriter(w io.Writer, enc *encoding.Encoder, newOut func(io.Writer) recordWriter) recordWriter {
+	if enc == nil {
+		return newOut(w)
+	}
+	tw := transform.NewWriter(w, replacingEncoder{enc: enc})
+	return encodedRecordWriter{recordWriter: newOut(tw), enc: tw}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/transform"
+)
+
+func TestOutputEncoder(t *testing.T) {
+	for _, name := range []string{"utf-8", "UTF8"} {
+		if enc, err := outputEncoder(name); err != nil || enc != nil {
+			t.Errorf("%s: %v, %v, want nil 인코더", name, enc, err)
+		}
+	}
+	for _, name := range []string{"euc-kr", "EUCKR", "cp949"} {
+		if enc, err := outputEncoder(name); err != nil || enc == nil {
+			t.Errorf("%s: %v, %v, want EUC-KR 인코더", name, enc, err)
+		}
+	}
+	if _, err := outputEncoder("shift_jis"); err == nil {
+		t.Error("shift_jis: 오류 없음")
+	}
+}
+
+// EUC-KR로 나타낼 수 없는 문자는 제어 문자가 아닌 '?'로 바뀌어야 함
+func TestReplacingEncoder(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"안녕하세요 ASCII", "안녕하세요 ASCII"},
+		{"청구서 😀 확인", "청구서 ? 확인"},
+		{"Café ✓", "Caf? ?"},
+		{"", ""},
+		// 변환 버퍼보다 긴 입력에서도 빠짐없이 바꿈
+		{strings.Repeat("가😀", 5000), strings.Repeat("가?", 5000)},
+	}
+	for _, tt := range tests {
+		encoded, _, err := transform.String(replacingEncoder{enc: korean.EUCKR.NewEncoder()}, tt.in)
+		if err != nil {
+			t.Fatalf("인코딩 실패: %v", err)
+		}
+		if strings.ContainsRune(encoded, 0x1a) {
+			t.Errorf("%.20q: 출력에 0x1A가 있음", tt.in)
+		}
+		decoded, err := korean.EUCKR.NewDecoder().String(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != tt.want {
+			t.Errorf("%.20q: 디코딩 결과 = %.40q, want %.40q", tt.in, decoded, tt.want)
+		}
+	}
+}
+
+func TestEUCKRCSVOutput(t *testing.T) {
+	enc, err := outputEncoder(outputEUCKR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := newEncodedRecordWriter(&buf, enc, func(w io.Writer) recordWriter { return newRecordWriter(w, formatCSV) })
+	if err := w.WriteRecord(EmailRecord{Subject: "[공지] 계정 확인 🔒", FromName: "보안팀"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("공지")) {
+		t.Error("출력이 UTF-8 그대로임")
+	}
+	decoded, err := korean.EUCKR.NewDecoder().Bytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(decoded)).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("CSV 읽기: %d행, %v", len(rows), err)
+	}
+	if i := indexOf(rows[0], "제목"); i < 0 || rows[1][i] != "[공지] 계정 확인 ?" {
+		t.Errorf("제목 열 = %q", rows[1])
+	}
+	if i := indexOf(rows[0], "보낸사람 이름"); i < 0 || rows[1][i] != "보안팀" {
+		t.Errorf("보낸사람 이름 열 = %q", rows[1])
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// recordWriter는 처리된 EmailRecord를 도착하는 순서대로 출력하는 인터페이스입니다.
+// WriteRecord는 출력 대상이 느리면 블로킹되며, 그 대기가 워커까지 전파됩니다.
+type recordWriter interface {
+	WriteRecord(r EmailRecord) error
+	Close() error
+}
+
+// 출력 형식
+const (
+	formatCSV      = "csv"
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatURLs     = "urls"
+	formatTable    = "table"
+	formatXML      = "xml"
+	formatYAML     = "yaml"
+	formatTemplate = "template"
+)
+
+// multiValueFields는 줄바꿈으로 여러 값을 담는 EmailRecord 필드와,
+// 구조화된 출력(XML 등)에서 각 값을 나타낼 요소 이름입니다.
+var multiValueFields = map[string]string{
+	"FromName":         "name",
+	"FromEmail":        "email",
+	"ToName":           "name",
+	"ToEmail":          "email",
+	"ToGroups":         "group",
+	"CcName":           "name",
+	"CcEmail":          "email",
+	"DeliveredTo":      "email",
+	"ContentLanguage":  "lang",
+	"Matches":          "match",
+	"URLs":             "url",
+	"URLDomains":       "domain",
+	"IP":               "ip",
+	"MailtoLinks":      "mailto",
+	"TelLinks":         "tel",
+	"URLSources":       "source",
+	"AttachmentHashes": "sha256",
+	"ResolvedDomains":  "domain",
+	"DomainHasA":       "a",
+	"DomainHasMX":      "mx",
+	"DomainIPs":        "ips",
+	"WhoisDomains":     "domain",
+	"DomainAgeDays":    "days",
+	"YoungDomains":     "domain",
+	"ReceivedIPs":      "ip",
+	"URLCounts":        "count",
+	"SubjectCharset":   "charset",
+	"AttachmentNames":  "name",
+	"AttachmentTypes":  "type",
+	"ReplyTo":          "email",
+	"ScoreSignals":     "signal",
+	"ShortURLs":        "url",
+	"ExpandedURLs":     "url",
+}
+
+// newRecordWriter는 출력 형식에 맞는 recordWriter를 생성합니다.
+func newRecordWriter(w io.Writer, format string) recordWriter {
+	switch format {
+	case formatJSON:
+		return newJSONRecordWriter(w)
+	case formatNDJSON:
+		return newNDJSONRecordWriter(w)
+	case formatURLs:
+		return newURLsRecordWriter(w)
+	case formatTable:
+		return newTableRecordWriter(w)
+	case formatXML:
+		return newXMLRecordWriter(w)
+	case formatYAML:
+		return newYAMLRecordWriter(w)
+	default:
+		return newCSVRecordWriter(w)
+	}
+}
+
+// recordField는 EmailRecord의 필드 이름과 값입니다.
+type recordField struct {
+	name  string
+	value interface{}
+}
+
+// recordFields는 EmailRecord의 필드를 선언 순서대로 반환합니다.
+// XML/YAML처럼 필드별로 요소를 만드는 출력 형식이 같은 스키마를 쓰도록 공유합니다.
+func recordFields(r EmailRecord) []recordField {
+	v := reflect.ValueOf(r)
+	t := v.Type()
+	fields := make([]recordField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		fields = append(fields, recordField{name: t.Field(i).Name, value: v.Field(i).Interface()})
+	}
+	return fields
+}
+
+// discardRecordWriter는 화면 출력을 생략할 때 사용하는 writer입니다.
+type discardRecordWriter struct{}
+
+func (discardRecordWriter) WriteRecord(EmailRecord) error { return nil }
+func (discardRecordWriter) Close() error                  { return nil }
+
+var csvHeaders = []string{
+	"폴더", "제목", "보낸사람 이름", "보낸사람 이메일",
+	"받은사람 이름", "받은사람 이메일", "보낸 날짜", "X-Originating-IP",
+	"본문URL", "본문URL(도메인)", "원본",
+	"List-ID", "List-Unsubscribe", "대량메일",
+	"첨부파일 수", "HTML 본문", "텍스트 본문", "HTML 문자셋",
+	"정리된 제목", "회신", "전달",
+	"날짜 출처",
+	"첫 보낸사람 이름", "첫 보낸사람 이메일", "Sender",
+	"파싱 품질", "인증 결과",
+	"제목 디코딩 오류",
+	"Organization", "중요도",
+	"보낸사람 원문", "받은사람 원문",
+	"Message-ID", "레코드ID",
+	"mailto 링크", "tel 링크", "의심 href 수",
+	"숨겨진 HTML 사용",
+	"본문URL 출처",
+	"재명명 경로",
+	"제목 원문",
+	"문자셋 대체",
+	"첨부파일 SHA-256",
+	"단어 수", "링크 수", "링크 밀도",
+	"정규화된 제목",
+	"일정 초대", "일정 주최자", "일정 제목",
+	"메일 크기",
+	"받는사람 그룹",
+	"본문 미리보기",
+	"조회 도메인", "A 레코드", "MX 레코드", "조회 IP",
+	"등록 도메인", "도메인 나이(일)", "신규 도메인",
+	"Date 원문", "날짜 형식",
+	"참조 이름", "참조 이메일", "Delivered-To",
+	"Received IP",
+	"일치 내용", "일치 수",
+	"URL 수", "내부 URL 수", "URL 출현 횟수",
+	"Content-Language",
+	"시간대 약어", "UTC 오프셋",
+	"제목 문자셋",
+	"첨부 파일명", "첨부 형식",
+	"Reply-To", "Return-Path",
+	"링크 텍스트 불일치 수",
+	"점수", "점수 신호",
+	"Thread-Index", "Thread-Topic",
+	"단축 URL 여부", "단축 URL", "단축 URL 최종 주소",
+}
+
+func csvRow(r EmailRecord) []string {
+	return []string{
+		r.Folder,
+		r.Subject,
+		r.FromName,
+		r.FromEmail,
+		r.ToName,
+		r.ToEmail,
+		r.SentDate,
+		r.IP,
+		r.URLs,
+		r.URLDomains,
+		r.OriginalFile,
+		r.ListID,
+		r.ListUnsubscribe,
+		strconv.FormatBool(r.IsBulk),
+		strconv.Itoa(r.AttachmentCount),
+		strconv.FormatBool(r.HasHTML),
+		strconv.FormatBool(r.HasText),
+		r.HTMLCharset,
+		r.CleanSubject,
+		strconv.FormatBool(r.IsReply),
+		strconv.FormatBool(r.IsForward),
+		r.DateSource,
+		r.PrimaryFromName,
+		r.PrimaryFromEmail,
+		r.Sender,
+		r.ParseQuality,
+		r.AuthResults,
+		strconv.FormatBool(r.SubjectDecodeError),
+		r.Organization,
+		r.Priority,
+		r.FromRaw,
+		r.ToRaw,
+		r.MessageID,
+		r.RecordID,
+		r.MailtoLinks,
+		r.TelLinks,
+		strconv.Itoa(r.SuspiciousHrefCount),
+		strconv.FormatBool(r.HiddenHTMLUsed),
+		r.URLSources,
+		r.RenamedPath,
+		r.SubjectRaw,
+		strconv.FormatBool(r.CharsetFallback),
+		r.AttachmentHashes,
+		strconv.Itoa(r.WordCount),
+		strconv.Itoa(r.LinkCount),
+		strconv.FormatFloat(r.LinkDensity, 'f', -1, 64),
+		r.NormalizedSubject,
+		strconv.FormatBool(r.HasCalendar),
+		r.CalOrganizer,
+		r.CalSummary,
+		strconv.FormatInt(r.MessageSize, 10),
+		r.ToGroups,
+		r.BodyPreview,
+		r.ResolvedDomains,
+		r.DomainHasA,
+		r.DomainHasMX,
+		r.DomainIPs,
+		r.WhoisDomains,
+		r.DomainAgeDays,
+		r.YoungDomains,
+		r.SentDateRaw,
+		r.DateLayout,
+		r.CcName,
+		r.CcEmail,
+		r.DeliveredTo,
+		r.ReceivedIPs,
+		r.Matches,
+		strconv.Itoa(r.MatchCount),
+		strconv.Itoa(r.URLCount),
+		strconv.Itoa(r.InternalURLCount),
+		r.URLCounts,
+		r.ContentLanguage,
+		r.DateZone,
+		r.SentDateOffset,
+		r.SubjectCharset,
+		r.AttachmentNames,
+		r.AttachmentTypes,
+		r.ReplyTo,
+		r.ReturnPath,
+		strconv.Itoa(r.LinkTextMismatches),
+		strconv.Itoa(r.Score),
+		r.ScoreSignals,
+		r.ThreadIndex,
+		r.ThreadTopic,
+		strconv.FormatBool(r.HasShortener),
+		r.ShortURLs,
+		r.ExpandedURLs,
+	}
+}
+
+// csvRecordWriter는 레코드를 한 행씩 CSV로 출력합니다.
+// 헤더는 첫 레코드와 함께 쓰므로 레코드가 없으면(필터로 모두 제외 등) 헤더만 있는 출력을 만들지 않습니다.
+type csvRecordWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+	// flattenSep이 설정되면 여러 줄로 된 셀(URL 목록 등)을 이 구분자로 이어 한 줄로 만듭니다.
+	flattenSep string
+}
+
+func newCSVRecordWriter(w io.Writer) *csvRecordWriter {
+	return &csvRecordWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvRecordWriter) writeHeader() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	return c.w.Write(csvHeaders)
+}
+
+func (c *csvRecordWriter) WriteRecord(r EmailRecord) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	row := csvRow(r)
+	if c.flattenSep != "" {
+		for i, cell := range row {
+			row[i] = flattenCell(cell, c.flattenSep)
+		}
+	}
+	return c.w.Write(row)
+}
+
+// flattenCell은 줄바꿈으로 구분된 값들을 sep으로 잇습니다.
+// 값 안에 sep이 있으면 앞에 백슬래시를 붙이고, 원래 있던 백슬래시는 두 번 써서 구분합니다.
+func flattenCell(cell, sep string) string {
+	if !strings.ContainsAny(cell, "\r\n") {
+		return cell
+	}
+	values := strings.Split(strings.ReplaceAll(cell, "\r\n", "\n"), "\n")
+	for i, v := range values {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		values[i] = strings.ReplaceAll(v, sep, `\`+sep)
+	}
+	return strings.Join(values, sep)
+}
+
+func (c *csvRecordWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonRecordWriter는 레코드를 하나씩 JSON 배열의 원소로 출력합니다.
+// 출력 형식은 json.MarshalIndent(records, "", "  ")와 동일합니다.
+type jsonRecordWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONRecordWriter(w io.Writer) *jsonRecordWriter {
+	return &jsonRecordWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonRecordWriter) WriteRecord(r EmailRecord) error {
+	b, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if j.count == 0 {
+		sep = "[\n  "
+	}
+	j.count++
+	if _, err := j.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonRecordWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := j.w.WriteString(end); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
+
+// ndjsonRecordWriter는 레코드를 한 줄에 하나씩 JSON 객체로 출력합니다.
+// 배열로 감싸지 않으므로 출력 도중에도 줄 단위로 바로 소비할 수 있습니다.
+type ndjsonRecordWriter struct {
+	w *bufio.Writer
+}
+
+func newNDJSONRecordWriter(w io.Writer) *ndjsonRecordWriter {
+	return &ndjsonRecordWriter{w: bufio.NewWriter(w)}
+}
+
+func (n *ndjsonRecordWriter) WriteRecord(r EmailRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(b); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonRecordWriter) Flush() error {
+	return n.w.Flush()
+}
+
+func (n *ndjsonRecordWriter) Close() error {
+	return n.w.Flush()
+}
+
+// urlsRecordWriter는 추출한 URL만 한 줄에 하나씩 출력합니다.
+// 기본적으로 실행 전체에서 중복을 제거하며, perMessage가 설정되면 메일 단위로만 중복을 제거합니다.
+type urlsRecordWriter struct {
+	w          *bufio.Writer
+	perMessage bool
+	seen       map[string]bool
+}
+
+func newURLsRecordWriter(w io.Writer) *urlsRecordWriter {
+	return &urlsRecordWriter{w: bufio.NewWriter(w), seen: make(map[string]bool)}
+}
+
+func (u *urlsRecordWriter) WriteRecord(r EmailRecord) error {
+	if r.URLs == "" {
+		return nil
+	}
+	for _, url := range strings.Split(r.URLs, "\n") {
+		if !u.perMessage {
+			if u.seen[url] {
+				continue
+			}
+			u.seen[url] = true
+		}
+		if _, err := u.w.WriteString(url + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *urlsRecordWriter) Close() error {
+	return u.w.Flush()
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingWriter는 기록된 레코드를 순서대로 보관합니다.
+type recordingWriter struct {
+	written []EmailRecord
+}
+
+func (w *recordingWriter) WriteRecord(r EmailRecord) error {
+	w.written = append(w.written, r)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+// 순서 보장 모드는 앞 파일이 늦게 끝나도 입력 순서대로 기록해야 함
+func TestOrderedOutputFollowsInputOrder(t *testing.T) {
+	tests := []struct {
+		workers, buffer int
+	}{
+		{workers: 1, buffer: 0},
+		{workers: 4, buffer: 0},
+		{workers: 8, buffer: 2},
+		{workers: 3, buffer: 16},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("workers=%d,buffer=%d", tt.workers, tt.buffer), func(t *testing.T) {
+			const n = 24
+			files := copyFixture(t, "7bit-text.eml", n)
+			opts := processOptions{
+				workerCount: tt.workers,
+				bufferSize:  tt.buffer,
+				ordered:     true,
+				// 앞쪽 파일일수록 오래 걸리게 하여 완료 순서를 입력 순서와 반대로 만듦
+				filters: recordFilters{newRecordFilter("delay", func(r *EmailRecord) bool {
+					i, _ := strconv.Atoi(strings.TrimSuffix(r.OriginalFile, ".eml"))
+					time.Sleep(time.Duration(n-i) * time.Millisecond)
+					return true
+				})},
+			}
+			out := &recordingWriter{}
+			summary, err := processFilesConcurrently(files, opts, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.succeeded != n || len(out.written) != n {
+				t.Fatalf("성공 %d, 기록 %d, want %d", summary.succeeded, len(out.written), n)
+			}
+			for i, r := range out.written {
+				if want := fmt.Sprintf("%03d.eml", i); r.OriginalFile != want {
+					t.Fatalf("%d번째 레코드 = %s, want %s", i, r.OriginalFile, want)
+				}
+			}
+		})
+	}
+}
+
+// 순서 보장 모드에서 필터로 제외되거나 실패한 파일이 있어도 나머지는 입력 순서대로 기록해야 함
+func TestOrderedOutputSkipsGaps(t *testing.T) {
+	const n = 12
+	files := copyFixture(t, "7bit-text.eml", n)
+	opts := processOptions{
+		workerCount: 4,
+		ordered:     true,
+		filters: recordFilters{newRecordFilter("odd", func(r *EmailRecord) bool {
+			i, _ := strconv.Atoi(strings.TrimSuffix(r.OriginalFile, ".eml"))
+			time.Sleep(time.Duration(n-i) * time.Millisecond)
+			return i%2 == 1
+		})},
+	}
+	out := &recordingWriter{}
+	summary, err := processFilesConcurrently(files, opts, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.filtered != n/2 || len(out.written) != n/2 {
+		t.Fatalf("제외 %d, 기록 %d, want %d, %d", summary.filtered, len(out.written), n/2, n/2)
+	}
+	for i, r := range out.written {
+		if want := fmt.Sprintf("%03d.eml", 2*i+1); r.OriginalFile != want {
+			t.Fatalf("%d번째 레코드 = %s, want %s", i, r.OriginalFile, want)
+		}
+	}
+}
+
+// 레코드 수/크기 기준으로 번호 파일을 넘기며, CSV는 파일마다 헤더가 있어야 함
+func TestRotatingRecordWriter(t *testing.T) {
+	csvWriter := func(w io.Writer) recordWriter { return newCSVRecordWriter(w) }
+	ndjsonWriter := func(w io.Writer) recordWriter { return newNDJSONRecordWriter(w) }
+	rec := EmailRecord{Subject: strings.Repeat("s", 100), URLs: "https://example.com/a\nhttps://example.com/b"}
+	// twoRecordsSize는 레코드 두 개(CSV는 헤더 포함)를 쓴 파일 크기입니다.
+	twoRecordsSize := func(newWriter func(io.Writer) recordWriter) int64 {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		w.WriteRecord(rec)
+		w.WriteRecord(rec)
+		w.Close()
+		return int64(buf.Len())
+	}
+	tests := []struct {
+		name       string
+		base       string
+		newWriter  func(io.Writer) recordWriter
+		maxRecords int
+		maxBytes   int64
+		records    int
+		wantCounts []int // 파일별 레코드 수
+	}{
+		{"csv by records", "out.csv", csvWriter, 3, 0, 7, []int{3, 3, 1}},
+		{"csv exact multiple", "out.csv", csvWriter, 2, 0, 4, []int{2, 2}},
+		{"ndjson by records", "out.ndjson", ndjsonWriter, 4, 0, 9, []int{4, 4, 1}},
+		// 크기 기준은 레코드를 다 쓴 뒤 확인하므로 기준을 넘긴 레코드까지 같은 파일에 들어감
+		{"csv by size", "out.csv", csvWriter, 0, twoRecordsSize(csvWriter) + 1, 5, []int{3, 2}},
+		{"ndjson by size", "out.ndjson", ndjsonWriter, 0, twoRecordsSize(ndjsonWriter), 5, []int{2, 2, 1}},
+		{"no records", "out.csv", csvWriter, 2, 0, 0, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := filepath.Join(t.TempDir(), tt.base)
+			w := newRotatingRecordWriter(base, tt.maxRecords, tt.maxBytes, tt.newWriter)
+			for i := 0; i < tt.records; i++ {
+				if err := w.WriteRecord(rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.wantCounts {
+				data, err := os.ReadFile(rotatedPath(base, i+1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got int
+				if strings.HasSuffix(base, ".csv") {
+					rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(rows) > 0 {
+						if rows[0][0] != csvHeaders[0] {
+							t.Errorf("파일 %d의 첫 줄이 헤더가 아님: %q", i+1, rows[0])
+						}
+						got = len(rows) - 1
+					}
+				} else {
+					got = bytes.Count(data, []byte("\n"))
+				}
+				if got != want {
+					t.Errorf("파일 %d의 레코드 수 = %d, want %d", i+1, got, want)
+				}
+			}
+			if _, err := os.Stat(rotatedPath(base, len(tt.wantCounts)+1)); !os.IsNotExist(err) {
+				t.Errorf("파일이 %d개보다 많음", len(tt.wantCounts))
+			}
+		})
+	}
+}
+
+func TestRotatedPath(t *testing.T) {
+	tests := []struct {
+		base  string
+		index int
+		want  string
+	}{
+		{"out.csv", 1, "out.0001.csv"},
+		{"dir/out.ndjson", 12, "dir/out.0012.ndjson"},
+		{"out.csv.gz", 3, "out.0003.csv.gz"},
+		{"out", 2, "out.0002"},
+		{"my.report.csv", 1, "my.report.0001.csv"},
+	}
+	for _, tt := range tests {
+		if got := rotatedPath(tt.base, tt.index); got != tt.want {
+			t.Errorf("rotatedPath(%q, %d) = %q, want %q", tt.base, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"500000", 500000, false},
+		{"512K", 512 << 10, false},
+		{"512kb", 512 << 10, false},
+		{"10MB", 10 << 20, false},
+		{" 1G ", 1 << 30, false},
+		{"0", 0, true},
+		{"-5M", 0, true},
+		{"10X", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseSize(%q) = %d, %v, want %d (오류 %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// CSV 헤더는 첫 레코드와 함께 쓰므로, 모든 레코드가 제외된 실행은 헤더만 있는 파일을 만들지 않아야 함.
+// 헤더 열 수는 행의 열 수와 같아야 함
+func TestCSVHeaderWrittenWithFirstRecord(t *testing.T) {
+	tests := []struct {
+		records  int
+		wantRows int
+	}{
+		{0, 0},
+		{1, 2},
+		{3, 4},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := newCSVRecordWriter(&buf)
+		for i := 0; i < tt.records; i++ {
+			if err := w.WriteRecord(EmailRecord{Subject: fmt.Sprint(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != tt.wantRows {
+			t.Errorf("레코드 %d개: CSV 줄 수 = %d, want %d", tt.records, len(rows), tt.wantRows)
+		}
+	}
+	if len(csvHeaders) != len(csvRow(EmailRecord{})) {
+		t.Errorf("CSV 헤더 %d열, 행 %d열", len(csvHeaders), len(csvRow(EmailRecord{})))
+	}
+}
+
+func TestMultiValueFieldsInStructuredOutput(t *testing.T) {
+	rec := EmailRecord{URLs: "https://a.example/\nhttps://b.example/", ReplyTo: ""}
+	var y bytes.Buffer
+	yw := newYAMLRecordWriter(&y)
+	if err := yw.WriteRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := yw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"  URLs:\n    - https://a.example/\n    - https://b.example/\n", "  ReplyTo: \"\"\n"} {
+		if !strings.Contains(y.String(), want) {
+			t.Errorf("YAML 출력에 %q 없음:\n%s", want, y.String())
+		}
+	}
+
+	var x bytes.Buffer
+	xw := newXMLRecordWriter(&x)
+	if err := xw.WriteRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<url>https://a.example/</url>", "<url>https://b.example/</url>", "<ReplyTo></ReplyTo>"} {
+		if !strings.Contains(x.String(), want) {
+			t.Errorf("XML 출력에 %q 없음:\n%s", want, x.String())
+		}
+	}
+}
+
+// 줄바꿈으로 여러 값을 담는 필드는 모두 multiValueFields에 있어야 XML/YAML에서 목록으로 나옴
+func TestMultiValueFieldsCoverNewlineFields(t *testing.T) {
+	for _, fixture := range []string{"phish-paypal.eml", "to-groups-comments.eml", "url-domains.eml"} {
+		rec, _, err := processEmlFile(filepath.Join("testdata", fixture), parseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		markShorteners(&rec, defaultShortenerDomains)
+		scoreRecord(&rec)
+		for _, f := range recordFields(rec) {
+			if _, ok := multiValueFields[f.name]; !ok && strings.Contains(fmt.Sprint(f.value), "\n") {
+				t.Errorf("%s: %s 필드에 여러 값이 있지만 multiValueFields에 없음", fixture, f.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+)
+
+// recordIDNamespace는 RecordID(UUID v5) 생성에 쓰는 emla 고유 네임스페이스입니다.
+var recordIDNamespace = [16]byte{
+	0x6c, 0x1f, 0x3a, 0x52, 0x8e, 0x0b, 0x4d, 0x6e,
+	0x9a, 0x27, 0x51, 0xc4, 0x0e, 0x93, 0xb2, 0x7d,
+}
+
+// recordID는 파일 내용의 SHA-256(contentSum)과 파일명으로 결정적인 UUID v5(RFC 4122)를 만듭니다.
+// 같은 파일은 어느 입력 루트로 처리해도, 여러 루트를 함께 처리해도 항상 같은 값이 나오므로
+// CSV/JSON과 HTML 등 여러 산출물을 연결하고 실행 간에 비교(-diff)하는 키로 씁니다.
+// 내용과 파일명이 모두 같은 사본만 같은 값을 가지며, 내용이 다른 파일은 Message-ID가 같거나 없어도 값이 다릅니다.
+func recordID(path, contentSum string) string {
+	name := contentSum + "\n" + filepath.Base(path)
+
+	h := sha1.New()
+	h.Write(recordIDNamespace[:])
+	h.Write([]byte(name))
+	sum := h.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50 // 버전 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
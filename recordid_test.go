@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var uuidV5Regex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// 같은 파일은 어느 입력 루트로 처리해도 RecordID가 같고, 내용이나 파일명이 다르면 달라야 함
+func TestRecordIDStableAcrossRoots(t *testing.T) {
+	tmp := t.TempDir()
+	chdir(t, tmp)
+	if err := os.MkdirAll(filepath.Join("in", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join("in", "sub", "b.eml"), "Subject: same\n\nbody\n")
+	write(filepath.Join("in", "sub", "c.eml"), "Subject: same\n\nbody\n")
+	write(filepath.Join("in", "d.eml"), "Subject: other\n\nbody\n")
+
+	id := func(root, path string) string {
+		t.Helper()
+		files := []collectedFile{{root: root, path: path}}
+		w := &recordingWriter{}
+		if _, err := processFilesConcurrently(files, processOptions{workerCount: 1}, w); err != nil || len(w.written) != 1 {
+			t.Fatalf("처리 실패: %v", err)
+		}
+		return w.written[0].RecordID
+	}
+	fromIn := id("in", filepath.Join("in", "sub", "b.eml"))
+	fromSub := id(filepath.Join("in", "sub"), filepath.Join("in", "sub", "b.eml"))
+	fromAbs := id(filepath.Join(tmp, "in"), filepath.Join(tmp, "in", "sub", "b.eml"))
+	if !uuidV5Regex.MatchString(fromIn) {
+		t.Errorf("UUID v5 형식이 아님: %s", fromIn)
+	}
+	if fromIn != fromSub || fromIn != fromAbs {
+		t.Errorf("루트에 따라 RecordID가 다름: %s, %s, %s", fromIn, fromSub, fromAbs)
+	}
+	if other := id("in", filepath.Join("in", "sub", "c.eml")); other == fromIn {
+		t.Error("파일명이 다른 같은 내용의 파일이 같은 RecordID를 가짐")
+	}
+	if other := id("in", filepath.Join("in", "d.eml")); other == fromIn {
+		t.Error("내용이 다른 파일이 같은 RecordID를 가짐")
+	}
+}
+
+// 파싱하면서 구한 해시는 파일 전체를 다시 읽어 구한 해시와 같아야 함 (재파싱, 헤더만 읽기, 잘린 긴 헤더 포함)
+func TestParseContentHashMatchesFile(t *testing.T) {
+	tests := []struct {
+		path  string
+		popts parseOptions
+	}{
+		{filepath.Join("testdata", "base64-html.eml"), parseOptions{}},
+		{filepath.Join("testdata", "phish-paypal.eml"), parseOptions{}},
+		{filepath.Join("testdata", "truncated-base64.eml"), parseOptions{}},
+		{filepath.Join("testdata", "base64-html.eml"), parseOptions{headersOnly: true}},
+		{gunzipFixture(t, "long-header.eml.gz"), parseOptions{}},
+	}
+	for _, tt := range tests {
+		want, err := fileSHA256(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, got, err := parseEmlFile(tt.path, tt.popts, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s (headersOnly=%v): 해시 = %s, want %s", filepath.Base(tt.path), tt.popts.headersOnly, got, want)
+		}
+		if _, _, got, _ := parseEmlFile(tt.path, tt.popts, false); got != "" {
+			t.Errorf("%s: hashContent 없이 해시 %s를 반환", filepath.Base(tt.path), got)
+		}
+	}
+}
+
+// RecordID와 증거 목록의 해시는 같은 파일 내용에서 나와야 함
+func TestRecordIDUsesParsedContentHash(t *testing.T) {
+	path := filepath.Join("testdata", "to-groups-comments.eml")
+	sum, err := fileSHA256(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &recordingWriter{}
+	files := []collectedFile{{root: "testdata", path: path}}
+	if _, err := processFilesConcurrently(files, processOptions{workerCount: 1}, w); err != nil || len(w.written) != 1 {
+		t.Fatalf("처리 실패: %v", err)
+	}
+	if got, want := w.written[0].RecordID, recordID(path, sum); got != want {
+		t.Errorf("RecordID = %s, want %s", got, want)
+	}
+}
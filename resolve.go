@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dnsLookupTimeout은 도메인 하나의 A/MX 조회에 허용하는 시간입니다.
+const dnsLookupTimeout = 5 * time.Second
+
+// dnsResult는 도메인 하나의 조회 결과입니다.
+type dnsResult struct {
+	hasA  bool
+	hasMX bool
+	ips   []string
+}
+
+// dnsEntry는 캐시 항목으로, done이 닫힌 뒤에만 result를 읽을 수 있습니다.
+// 같은 도메인을 여러 워커가 동시에 요청해도 조회는 한 번만 합니다.
+type dnsEntry struct {
+	done   chan struct{}
+	result dnsResult
+}
+
+// domainResolver는 도메인별 A/MX 레코드를 조회하고 결과를 실행 동안 캐시합니다 (-resolve-domains).
+// 동시 조회 수는 sem 크기로 제한하여 DNS 서버에 부담을 주지 않게 합니다.
+type domainResolver struct {
+	resolver *net.Resolver
+	sem      chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]*dnsEntry
+}
+
+func newDomainResolver(concurrency int) *domainResolver {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &domainResolver{
+		resolver: net.DefaultResolver,
+		sem:      make(chan struct{}, concurrency),
+		cache:    make(map[string]*dnsEntry),
+	}
+}
+
+// lookup은 도메인의 조회 결과를 반환합니다. 캐시에 있으면 조회하지 않습니다.
+func (d *domainResolver) lookup(domain string) dnsResult {
+	d.mu.Lock()
+	e, ok := d.cache[domain]
+	if !ok {
+		e = &dnsEntry{done: make(chan struct{})}
+		d.cache[domain] = e
+	}
+	d.mu.Unlock()
+	if ok {
+		<-e.done
+		return e.result
+	}
+
+	d.sem <- struct{}{}
+	e.result = d.query(domain)
+	<-d.sem
+	close(e.done)
+	return e.result
+}
+
+// query는 A/AAAA와 MX 레코드를 조회합니다. 조회에 실패하면(NXDOMAIN, 시간 초과 등) 레코드가 없는 것으로 봅니다.
+func (d *domainResolver) query(domain string) dnsResult {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	var r dnsResult
+	if addrs, err := d.resolver.LookupIPAddr(ctx, domain); err == nil {
+		for _, a := range addrs {
+			r.ips = appendUnique(r.ips, a.IP.String())
+		}
+		sort.Strings(r.ips)
+		r.hasA = len(r.ips) > 0
+	} else {
+		debugf("A 레코드 조회 실패: %s (%v)", domain, err)
+	}
+	if mx, err := d.resolver.LookupMX(ctx, domain); err == nil {
+		r.hasMX = len(mx) > 0
+	} else {
+		debugf("MX 레코드 조회 실패: %s (%v)", domain, err)
+	}
+	return r
+}
+
+// resolvableDomain은 조회할 도메인 이름을 반환합니다. 포트는 제거하며, IP 주소는 조회 대상이 아닙니다.
+func resolvableDomain(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(strings.ToLower(host), "[]")
+	if host == "" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return "", false
+	}
+	return host, true
+}
+
+// resolveRecordDomains는 보낸 사람 도메인과 URL 도메인을 조회하여 레코드의 DNS 필드를 채웁니다.
+// 각 필드는 ResolvedDomains와 같은 줄 순서로 기록합니다.
+func resolveRecordDomains(d *domainResolver, r *EmailRecord) {
+	var domains []string
+	for _, email := range strings.Split(r.FromEmail, "\n") {
+		if at := strings.LastIndex(email, "@"); at >= 0 {
+			if domain, ok := resolvableDomain(email[at+1:]); ok {
+				domains = appendUnique(domains, domain)
+			}
+		}
+	}
+	for _, host := range strings.Split(r.URLDomains, "\n") {
+		if domain, ok := resolvableDomain(host); ok {
+			domains = appendUnique(domains, domain)
+		}
+	}
+
+	hasA := make([]string, len(domains))
+	hasMX := make([]string, len(domains))
+	ips := make([]string, len(domains))
+	for i, domain := range domains {
+		res := d.lookup(domain)
+		hasA[i] = strconv.FormatBool(res.hasA)
+		hasMX[i] = strconv.FormatBool(res.hasMX)
+		ips[i] = strings.Join(res.ips, ",")
+	}
+	r.ResolvedDomains = strings.Join(domains, "\n")
+	r.DomainHasA = strings.Join(hasA, "\n")
+	r.DomainHasMX = strings.Join(hasMX, "\n")
+	r.DomainIPs = strings.Join(ips, "\n")
+}
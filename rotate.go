@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// flusher는 버퍼에 쌓인 출력을 내보낼 수 있는 recordWriter입니다.
+// 크기 기준 분할에서 레코드마다 실제 파일 크기를 확인하는 데 사용합니다.
+type flusher interface {
+	Flush() error
+}
+
+// countingWriter는 기록한 바이트 수를 셉니다.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// rotatingRecordWriter는 레코드 수나 파일 크기가 기준에 이르면 새 번호의 파일로 넘어가며 기록합니다.
+// 파일마다 새 recordWriter를 만들므로 CSV는 파일마다 헤더가 붙습니다.
+// 크기 기준은 레코드를 다 쓴 뒤 확인하므로 파일은 기준보다 레코드 하나만큼 커질 수 있습니다.
+type rotatingRecordWriter struct {
+	base       string
+	newWriter  func(io.Writer) recordWriter
+	maxRecords int
+	maxBytes   int64
+
+	index   int
+	count   int
+	file    *os.File
+	counter *countingWriter
+	cur     recordWriter
+}
+
+func newRotatingRecordWriter(base string, maxRecords int, maxBytes int64, newWriter func(io.Writer) recordWriter) *rotatingRecordWriter {
+	return &rotatingRecordWriter{base: base, newWriter: newWriter, maxRecords: maxRecords, maxBytes: maxBytes}
+}
+
+// rotatedPath는 "out.csv"를 "out.0001.csv"처럼 번호를 붙인 경로로 만듭니다. "out.csv.gz"는 "out.0001.csv.gz"가 됩니다.
+func rotatedPath(base string, index int) string {
+	ext := filepath.Ext(base)
+	if gzipOutput(base) {
+		ext = filepath.Ext(strings.TrimSuffix(base, ext)) + ext
+	}
+	return fmt.Sprintf("%s.%04d%s", strings.TrimSuffix(base, ext), index, ext)
+}
+
+func (r *rotatingRecordWriter) open() error {
+	r.index++
+	f, err := os.Create(rotatedPath(r.base, r.index))
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.counter = &countingWriter{w: f}
+	r.cur = r.newWriter(r.counter)
+	r.count = 0
+	return nil
+}
+
+// closeCurrent는 현재 파일을 마무리합니다.
+func (r *rotatingRecordWriter) closeCurrent() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.cur, r.file, r.counter = nil, nil, nil
+	return err
+}
+
+func (r *rotatingRecordWriter) WriteRecord(rec EmailRecord) error {
+	if r.cur == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if err := r.cur.WriteRecord(rec); err != nil {
+		return err
+	}
+	r.count++
+
+	full := r.maxRecords > 0 && r.count >= r.maxRecords
+	if !full && r.maxBytes > 0 {
+		if f, ok := r.cur.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+		full = r.counter.n >= r.maxBytes
+	}
+	if full {
+		return r.closeCurrent()
+	}
+	return nil
+}
+
+// Close는 마지막 파일을 마무리합니다. 레코드가 하나도 없었으면 빈 파일 하나를 만듭니다.
+func (r *rotatingRecordWriter) Close() error {
+	if r.index == 0 {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	return r.closeCurrent()
+}
+
+// parseSize는 "500000", "512K", "10MB", "1G" 같은 크기를 바이트 수로 바꿉니다 (1K = 1024바이트).
+func parseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(v, "B")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(v, "K"):
+		mult, v = 1<<10, strings.TrimSuffix(v, "K")
+	case strings.HasSuffix(v, "M"):
+		mult, v = 1<<20, strings.TrimSuffix(v, "M")
+	case strings.HasSuffix(v, "G"):
+		mult, v = 1<<30, strings.TrimSuffix(v, "G")
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("잘못된 크기: %q", s)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// highURLCount는 "URL이 많음" 신호의 기준이 되는 서로 다른 URL 수입니다.
+const highURLCount = 20
+
+// scoreSignal은 -score의 신호 하나로, 레코드가 신호에 해당하면 weight를 점수에 더합니다.
+type scoreSignal struct {
+	name   string
+	weight int
+	match  func(r *EmailRecord) bool
+}
+
+// scoreSignals는 -score의 신호와 가중치 표입니다. 점수는 해당하는 신호의 가중치 합이고,
+// 신호 이름은 이 순서대로 ScoreSignals에 기록합니다. 가중치를 바꾸면 기존 -min-score 기준도 함께 달라집니다.
+var scoreSignals = []scoreSignal{
+	{"reply-to-mismatch", 3, func(r *EmailRecord) bool { return domainMismatch(r.PrimaryFromEmail, r.ReplyTo) }},
+	{"return-path-mismatch", 2, func(r *EmailRecord) bool { return domainMismatch(r.PrimaryFromEmail, r.ReturnPath) }},
+	{"link-text-mismatch", 3, func(r *EmailRecord) bool { return r.LinkTextMismatches > 0 }},
+	{"idn-domain", 2, func(r *EmailRecord) bool { return hasIDNDomain(r.URLDomains) }},
+	{"url-shortener", 2, func(r *EmailRecord) bool { return r.HasShortener }},
+	{"suspicious-attachment", 4, func(r *EmailRecord) bool { return hasSuspiciousAttachment(r.AttachmentNames, r.AttachmentTypes) }},
+	{"many-urls", 1, func(r *EmailRecord) bool { return r.URLCount >= highURLCount }},
+	{"freemail-corporate-name", 2, func(r *EmailRecord) bool { return freemailCorporateName(r.PrimaryFromName, r.PrimaryFromEmail) }},
+}
+
+// scoreRecord는 신호 표로 Score와 ScoreSignals(줄바꿈으로 연결)를 기록합니다.
+func scoreRecord(r *EmailRecord) {
+	var score int
+	var names []string
+	for _, s := range scoreSignals {
+		if s.match(r) {
+			score += s.weight
+			names = append(names, s.name)
+		}
+	}
+	r.Score = score
+	r.ScoreSignals = strings.Join(names, "\n")
+}
+
+// scoreFilters는 점수가 min 이상인 메일만 남기는 필터(-min-score)를 구성합니다.
+func scoreFilters(min int) recordFilters {
+	if min <= 0 {
+		return nil
+	}
+	return recordFilters{newRecordFilter("-min-score", func(r *EmailRecord) bool { return r.Score >= min })}
+}
+
+// emailDomain은 주소의 @ 뒤 도메인을 소문자로 반환합니다.
+func emailDomain(addr string) string {
+	_, domain, ok := strings.Cut(strings.TrimSpace(addr), "@")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// domainMismatch는 other(줄바꿈으로 나뉜 주소) 중 from과 등록 도메인(eTLD+1)이 다른 주소가 있는지 확인합니다.
+// 주소가 없으면(헤더 없음, Return-Path: <>) 불일치로 보지 않습니다.
+func domainMismatch(from, other string) bool {
+	a := orgDomain(from)
+	if a == "" {
+		return false
+	}
+	for _, addr := range strings.Split(other, "\n") {
+		if b := orgDomain(addr); b != "" && b != a {
+			return true
+		}
+	}
+	return false
+}
+
+// orgDomain은 주소 도메인의 등록 도메인을, 구할 수 없으면 도메인 자체를 반환합니다.
+func orgDomain(addr string) string {
+	domain := emailDomain(addr)
+	if registered, ok := registeredDomain(domain); ok {
+		return registered
+	}
+	return domain
+}
+
+// hasIDNDomain은 URL 도메인 중 퓨니코드("xn--") 레이블이나 ASCII가 아닌 문자가 있는지 확인합니다.
+func hasIDNDomain(domains string) bool {
+	for _, d := range strings.Split(domains, "\n") {
+		for _, label := range strings.Split(d, ".") {
+			if strings.HasPrefix(label, "xn--") {
+				return true
+			}
+		}
+		for _, c := range d {
+			if c > 0x7f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 실행 파일, 스크립트, 매크로 문서, 디스크 이미지처럼 피싱 메일에 자주 쓰이는 첨부 확장자와 형식
+var suspiciousAttachmentExts = []string{
+	".exe", ".scr", ".com", ".pif", ".bat", ".cmd", ".js", ".jse", ".vbs", ".vbe", ".wsf", ".hta", ".ps1",
+	".jar", ".msi", ".lnk", ".iso", ".img", ".vhd", ".docm", ".xlsm", ".pptm", ".html", ".htm", ".svg",
+}
+
+var suspiciousAttachmentTypes = []string{
+	"application/x-msdownload", "application/x-msdos-program", "application/x-dosexec",
+	"application/javascript", "application/hta", "application/x-iso9660-image",
+}
+
+// hasSuspiciousAttachment는 첨부 파일명의 확장자나 선언된 형식이 의심스러운 첨부가 있는지 확인합니다.
+func hasSuspiciousAttachment(names, types string) bool {
+	for _, name := range strings.Split(names, "\n") {
+		ext := strings.ToLower(path.Ext(strings.TrimSpace(name)))
+		for _, e := range suspiciousAttachmentExts {
+			if ext == e {
+				return true
+			}
+		}
+	}
+	for _, t := range strings.Split(types, "\n") {
+		for _, s := range suspiciousAttachmentTypes {
+			if t == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 무료 메일 서비스 도메인
+var freemailDomains = domainList{
+	"gmail.com", "googlemail.com", "yahoo.com", "yahoo.co.jp", "hotmail.com", "outlook.com", "live.com", "msn.com",
+	"aol.com", "icloud.com", "me.com", "mail.com", "gmx.com", "gmx.de", "proton.me", "protonmail.com", "yandex.ru",
+	"mail.ru", "qq.com", "163.com", "naver.com", "daum.net", "hanmail.net", "nate.com", "kakao.com",
+}
+
+// 회사나 기관을 사칭하는 표시 이름에 흔한 영어 단어(단어 단위로 비교)와 한국어 표현(부분 문자열로 비교)
+var corporateNameWords = []string{
+	"inc", "corp", "ltd", "llc", "bank", "support", "service", "security", "team", "admin", "helpdesk",
+	"billing", "account", "paypal", "microsoft", "apple", "amazon", "google", "dhl", "fedex",
+}
+
+var corporateNameParts = []string{"은행", "카드", "고객센터", "관리자", "보안", "택배", "주식회사", "(주)"}
+
+// freemailCorporateName은 무료 메일 주소에서 보낸 메일의 표시 이름이 회사나 기관처럼 보이는지 확인합니다.
+// 표시 이름에 회사 관련 단어나 도메인 형태(점이 있는 단어)가 있으면 회사 이름으로 봅니다.
+func freemailCorporateName(name, email string) bool {
+	if name == "" || !freemailDomains.match(emailDomain(email)) {
+		return false
+	}
+	lower := strings.ToLower(name)
+	for _, p := range corporateNameParts {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	for _, f := range strings.Fields(lower) {
+		if strings.Contains(strings.Trim(f, ".,"), ".") {
+			return true
+		}
+		word := strings.Trim(f, ".,()-")
+		for _, w := range corporateNameWords {
+			if word == w {
+				return true
+			}
+		}
+	}
+	return false
+}
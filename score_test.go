@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// 신호 가중치를 바꾸면 이 점수도 함께 바뀌므로, 의도한 변경인지 확인한 뒤 기대값을 고칩니다.
+func TestScoreFixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantScore   int
+		wantSignals string
+	}{
+		{"base64-html.eml", 0, ""},
+		{"7bit-text.eml", 0, ""},
+		{"many-urls.eml", 1, "many-urls"},
+		{"bom-html.eml", 0, ""},
+		{
+			"phish-paypal.eml", 18,
+			"reply-to-mismatch\nreturn-path-mismatch\nlink-text-mismatch\nidn-domain\nurl-shortener\nsuspicious-attachment\nfreemail-corporate-name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 워커와 같은 순서로 단축 URL을 표시한 뒤 점수를 계산
+			markShorteners(&rec, defaultShortenerDomains)
+			scoreRecord(&rec)
+			if rec.Score != tt.wantScore || rec.ScoreSignals != tt.wantSignals {
+				t.Errorf("Score = %d (%q), want %d (%q)", rec.Score, rec.ScoreSignals, tt.wantScore, tt.wantSignals)
+			}
+		})
+	}
+}
+
+func TestScoreSignalWeights(t *testing.T) {
+	want := map[string]int{
+		"reply-to-mismatch":       3,
+		"return-path-mismatch":    2,
+		"link-text-mismatch":      3,
+		"idn-domain":              2,
+		"url-shortener":           2,
+		"suspicious-attachment":   4,
+		"many-urls":               1,
+		"freemail-corporate-name": 2,
+	}
+	if len(scoreSignals) != len(want) {
+		t.Fatalf("신호 %d개, want %d개", len(scoreSignals), len(want))
+	}
+	for _, s := range scoreSignals {
+		if w, ok := want[s.name]; !ok || s.weight != w {
+			t.Errorf("신호 %s의 가중치 = %d, want %d", s.name, s.weight, w)
+		}
+	}
+}
+
+func TestDomainMismatch(t *testing.T) {
+	tests := []struct {
+		from, other string
+		want        bool
+	}{
+		{"a@example.com", "b@example.com", false},
+		{"a@example.com", "bounce@mail.example.com", false},
+		{"a@example.co.uk", "b@other.co.uk", true},
+		{"a@example.com", "b@evil.example", true},
+		{"a@example.com", "", false},
+		{"", "b@evil.example", false},
+		{"a@example.com", "b@example.com\nc@evil.example", true},
+		{"a@Example.COM", "b@example.com.", false},
+	}
+	for _, tt := range tests {
+		if got := domainMismatch(tt.from, tt.other); got != tt.want {
+			t.Errorf("domainMismatch(%q, %q) = %v, want %v", tt.from, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestFreemailCorporateName(t *testing.T) {
+	tests := []struct {
+		name, email string
+		want        bool
+	}{
+		{"PayPal Support", "x@gmail.com", true},
+		{"국민은행 고객센터", "x@naver.com", true},
+		{"Acme Inc.", "x@yahoo.com", true},
+		{"paypal.com", "x@hotmail.com", true},
+		{"John Smith", "x@gmail.com", false},
+		{"Teamster Joe", "x@gmail.com", false},
+		{"PayPal Support", "x@paypal.com", false},
+		{"", "x@gmail.com", false},
+	}
+	for _, tt := range tests {
+		if got := freemailCorporateName(tt.name, tt.email); got != tt.want {
+			t.Errorf("freemailCorporateName(%q, %q) = %v, want %v", tt.name, tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestScoreFilters(t *testing.T) {
+	if scoreFilters(0) != nil {
+		t.Error("-min-score 0은 필터가 없어야 함")
+	}
+	f := scoreFilters(5)
+	for _, tt := range []struct {
+		score int
+		want  bool
+	}{{4, false}, {5, true}, {18, true}} {
+		if got := f.match(&EmailRecord{Score: tt.score}); got != tt.want {
+			t.Errorf("점수 %d: match = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
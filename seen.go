@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// seenBucket은 처리한 메일 키를 보관하는 BoltDB 버킷 이름입니다.
+var seenBucket = []byte("seen")
+
+// seenFlushSize는 처리한 키를 모아 한 트랜잭션으로 기록하는 단위입니다.
+// 레코드마다 커밋하면 매번 fsync가 일어나 대량 처리에서 느려집니다.
+const seenFlushSize = 1000
+
+// seenStore는 실행을 넘어 유지되는 중복 제거 저장소입니다 (-seen-db).
+// 메일은 Message-ID로, Message-ID가 없으면 파일 내용의 SHA-256으로 식별합니다.
+// has는 워커에서 동시에 호출되며, mark/flush는 결과를 기록하는 고루틴에서만 호출됩니다.
+type seenStore struct {
+	db *bolt.DB
+	// run은 이번 실행에서 기록한 키, pending은 그중 아직 저장하지 않은 키입니다.
+	run     map[string]bool
+	pending []string
+}
+
+// openSeenStore는 path의 저장소를 열고, reset이면 기록된 키를 모두 지웁니다.
+func openSeenStore(path string, reset bool) (*seenStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if reset {
+			if err := tx.DeleteBucket(seenBucket); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		_, err := tx.CreateBucketIfNotExists(seenBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &seenStore{db: db, run: make(map[string]bool)}, nil
+}
+
+// seenKey는 메일의 중복 판단 키를 만듭니다.
+func seenKey(filePath, messageID string) (string, error) {
+	if id := strings.Trim(strings.TrimSpace(messageID), "<>"); id != "" {
+		return "mid:" + id, nil
+	}
+	sum, err := fileSHA256(filePath)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + sum, nil
+}
+
+// has는 이전 실행에서 기록된 키인지 확인합니다.
+func (s *seenStore) has(key string) (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(seenBucket).Get([]byte(key)) != nil
+		return nil
+	})
+	return found, err
+}
+
+// mark는 키를 처리한 것으로 기록합니다. 이번 실행에서 이미 기록한 키이면 dup을 반환하여
+// 같은 실행 안에서 같은 메일이 두 번 나오는 경우를 걸러냅니다.
+func (s *seenStore) mark(key string) (dup bool, err error) {
+	if s.run[key] {
+		return true, nil
+	}
+	s.run[key] = true
+	s.pending = append(s.pending, key)
+	if len(s.pending) >= seenFlushSize {
+		return false, s.flush()
+	}
+	return false, nil
+}
+
+// flush는 모아 둔 키를 한 트랜잭션으로 저장합니다.
+func (s *seenStore) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	stamp := []byte(time.Now().UTC().Format(time.RFC3339))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(seenBucket)
+		for _, key := range s.pending {
+			if err := b.Put([]byte(key), stamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		s.pending = s.pending[:0]
+	}
+	return err
+}
+
+// Close는 남은 키를 저장하고 저장소를 닫습니다.
+func (s *seenStore) Close() error {
+	err := s.flush()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSeenInputs는 Message-ID가 같은 메일 두 개와 Message-ID가 없는 메일 하나를 만듭니다.
+func writeSeenInputs(t *testing.T) []collectedFile {
+	t.Helper()
+	root := t.TempDir()
+	contents := []string{
+		"Message-ID: <same@example.com>\nSubject: first\n\nbody\n",
+		"Message-ID: <same@example.com>\nSubject: second copy\n\nbody\n",
+		"Subject: no id\n\nbody\n",
+	}
+	var files []collectedFile
+	for i, c := range contents {
+		path := filepath.Join(root, string(rune('a'+i))+".eml")
+		if err := os.WriteFile(path, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, collectedFile{root: root, path: path})
+	}
+	return files
+}
+
+func TestSeenStoreAcrossRuns(t *testing.T) {
+	files := writeSeenInputs(t)
+	dbPath := filepath.Join(t.TempDir(), "seen.db")
+	run := func(reset bool) (processSummary, []string) {
+		t.Helper()
+		store, err := openSeenStore(dbPath, reset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &recordingWriter{}
+		summary, err := processFilesConcurrently(files, processOptions{workerCount: 1, ordered: true, seen: store}, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var subjects []string
+		for _, r := range w.written {
+			subjects = append(subjects, r.Subject)
+		}
+		return summary, subjects
+	}
+
+	// 첫 실행: 같은 Message-ID의 두 번째 메일은 같은 실행 안에서 건너뜀
+	summary, subjects := run(false)
+	if want := []string{"first", "no id"}; !equalStrings(subjects, want) {
+		t.Errorf("첫 실행 = %q, want %q", subjects, want)
+	}
+	if summary.succeeded != 2 || summary.seen != 1 {
+		t.Errorf("첫 실행 성공 %d, 건너뜀 %d, want 2, 1", summary.succeeded, summary.seen)
+	}
+
+	// 두 번째 실행: 모두 이전에 처리한 메일 (Message-ID가 없는 메일은 내용 해시로 판단)
+	summary, subjects = run(false)
+	if len(subjects) != 0 || summary.seen != 3 {
+		t.Errorf("두 번째 실행 = %q, 건너뜀 %d, want 없음, 3", subjects, summary.seen)
+	}
+
+	// -reset-seen: 기록을 비우고 다시 처리
+	summary, subjects = run(true)
+	if want := []string{"first", "no id"}; !equalStrings(subjects, want) || summary.seen != 1 {
+		t.Errorf("초기화 후 = %q, 건너뜀 %d, want %q, 1", subjects, summary.seen, want)
+	}
+}
+
+func TestSeenKey(t *testing.T) {
+	path := writeEml(t, "Subject: x\n\nbody\n")
+	sum, err := fileSHA256(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		messageID string
+		want      string
+	}{
+		{"<abc@example.com>", "mid:abc@example.com"},
+		{" <abc@example.com> ", "mid:abc@example.com"},
+		{"abc@example.com", "mid:abc@example.com"},
+		{"", "sha256:" + sum},
+		{"<>", "sha256:" + sum},
+	}
+	for _, tt := range tests {
+		got, err := seenKey(path, tt.messageID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("seenKey(%q) = %q, want %q", tt.messageID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDisplayNames는 -senders-only 출력에 나열하는 표시 이름의 최대 개수입니다. 나머지는 "+N more"로 줄입니다.
+const maxDisplayNames = 5
+
+// senderRow는 -senders-only 출력의 한 행입니다.
+type senderRow struct {
+	Sender           string
+	Count            int
+	DisplayNames     string // 처음 본 순서로 최대 maxDisplayNames개, 줄바꿈으로 연결
+	DisplayNameCount int
+	FirstSeen        string
+	LastSeen         string
+	URLDomains       int
+}
+
+// senderStat은 보낸 사람 주소 하나의 집계 상태입니다.
+type senderStat struct {
+	senderRow
+	names      []string // 처음 본 순서의 표시 이름 (최대 maxDisplayNames개)
+	nameSet    map[string]struct{}
+	urlDomains map[string]struct{}
+}
+
+// sendersRecordWriter는 메일별 행 대신 서로 다른 보낸 사람 주소마다 한 행(메일 수, 사용한 표시 이름, 처음/마지막 날짜,
+// URL 도메인 수)을 출력합니다 (-senders-only). 한 주소로 표시 이름을 바꿔 가며 보내는 피싱을 찾는 데 사용합니다.
+// 닫을 때 메일 수가 많은 순(같으면 주소 순)으로 format(CSV, JSON, NDJSON)에 맞게 출력합니다.
+type sendersRecordWriter struct {
+	w      io.Writer
+	format string
+	stats  map[string]*senderStat
+}
+
+func newSendersRecordWriter(w io.Writer, format string) *sendersRecordWriter {
+	return &sendersRecordWriter{w: w, format: format, stats: make(map[string]*senderStat)}
+}
+
+func (s *sendersRecordWriter) WriteRecord(r EmailRecord) error {
+	key := strings.ToLower(r.PrimaryFromEmail)
+	st, ok := s.stats[key]
+	if !ok {
+		st = &senderStat{senderRow: senderRow{Sender: key}, nameSet: make(map[string]struct{}), urlDomains: make(map[string]struct{})}
+		s.stats[key] = st
+	}
+	st.Count++
+	if name := strings.TrimSpace(r.PrimaryFromName); name != "" {
+		if _, seen := st.nameSet[name]; !seen {
+			st.nameSet[name] = struct{}{}
+			if len(st.names) < maxDisplayNames {
+				st.names = append(st.names, name)
+			}
+		}
+	}
+	if r.SentDate != "" {
+		if st.FirstSeen == "" || r.SentDate < st.FirstSeen {
+			st.FirstSeen = r.SentDate
+		}
+		if r.SentDate > st.LastSeen {
+			st.LastSeen = r.SentDate
+		}
+	}
+	for _, d := range strings.Split(r.URLDomains, "\n") {
+		if d != "" {
+			st.urlDomains[d] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// rows는 보낸 사람 행을 메일 수가 많은 순(같으면 주소 순)으로 반환합니다.
+func (s *sendersRecordWriter) rows() []senderRow {
+	rows := make([]senderRow, 0, len(s.stats))
+	for _, st := range s.stats {
+		row := st.senderRow
+		names := st.names
+		if more := len(st.nameSet) - len(names); more > 0 {
+			names = append(names[:len(names):len(names)], fmt.Sprintf("+%d more", more))
+		}
+		row.DisplayNames = strings.Join(names, "\n")
+		row.DisplayNameCount = len(st.nameSet)
+		row.URLDomains = len(st.urlDomains)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Sender < rows[j].Sender
+	})
+	return rows
+}
+
+func (s *sendersRecordWriter) Close() error {
+	rows := s.rows()
+	bw := bufio.NewWriter(s.w)
+	switch s.format {
+	case formatJSON:
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	case formatNDJSON:
+		enc := json.NewEncoder(bw)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	default:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"보낸 사람", "메일 수", "표시 이름", "표시 이름 수", "처음", "마지막", "URL 도메인 수"})
+		for _, row := range rows {
+			cw.Write([]string{row.Sender, strconv.Itoa(row.Count), row.DisplayNames, strconv.Itoa(row.DisplayNameCount),
+				row.FirstSeen, row.LastSeen, strconv.Itoa(row.URLDomains)})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// checkSendersFormat은 -senders-only를 사용할 수 있는 출력 형식인지 확인합니다.
+func checkSendersFormat(format string) error {
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("-senders-only는 CSV, -json, -ndjson 출력에서만 사용할 수 있습니다")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func writeSenders(t *testing.T, format string, records []EmailRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newSendersRecordWriter(&buf, format)
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// 주소는 대소문자를 구분하지 않고 묶으며, 메일 수가 많은 순(같으면 주소 순)으로 출력
+func TestSendersRows(t *testing.T) {
+	records := []EmailRecord{
+		{PrimaryFromEmail: "Billing@Evil.example", PrimaryFromName: "Billing", SentDate: "2024-03-02 10:00:00", URLDomains: "evil.example\ncdn.example.net"},
+		{PrimaryFromEmail: "billing@evil.example", PrimaryFromName: " 결제팀 ", SentDate: "2024-03-01 09:00:00", URLDomains: "evil.example"},
+		{PrimaryFromEmail: "billing@evil.example", PrimaryFromName: "Billing", SentDate: "2024-03-05 08:00:00"},
+		{PrimaryFromEmail: "bob@example.org", SentDate: ""},
+		{PrimaryFromEmail: "alice@example.org", PrimaryFromName: "Alice", SentDate: "2024-01-01 00:00:00"},
+	}
+	rows, err := csv.NewReader(strings.NewReader(writeSenders(t, formatCSV, records))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"보낸 사람", "메일 수", "표시 이름", "표시 이름 수", "처음", "마지막", "URL 도메인 수"},
+		{"billing@evil.example", "3", "Billing\n결제팀", "2", "2024-03-01 09:00:00", "2024-03-05 08:00:00", "2"},
+		{"alice@example.org", "1", "Alice", "1", "2024-01-01 00:00:00", "2024-01-01 00:00:00", "0"},
+		{"bob@example.org", "1", "", "0", "", "", "0"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("행 %d개, want %d개: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !equalStrings(rows[i], want[i]) {
+			t.Errorf("%d번째 행 = %q, want %q", i+1, rows[i], want[i])
+		}
+	}
+}
+
+// 표시 이름은 처음 본 순서로 maxDisplayNames개까지 나열하고 나머지는 "+N more"로 줄임
+func TestSendersDisplayNameLimit(t *testing.T) {
+	var records []EmailRecord
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G", "A"} {
+		records = append(records, EmailRecord{PrimaryFromEmail: "x@example.com", PrimaryFromName: name})
+	}
+	var rows []senderRow
+	if err := json.Unmarshal([]byte(writeSenders(t, formatJSON, records)), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("행 = %+v", rows)
+	}
+	if got, want := rows[0].DisplayNames, "A\nB\nC\nD\nE\n+2 more"; got != want {
+		t.Errorf("표시 이름 = %q, want %q", got, want)
+	}
+	if rows[0].DisplayNameCount != 7 || rows[0].Count != 8 {
+		t.Errorf("표시 이름 수 = %d, 메일 수 = %d", rows[0].DisplayNameCount, rows[0].Count)
+	}
+}
+
+func TestSendersFormats(t *testing.T) {
+	records := []EmailRecord{
+		{PrimaryFromEmail: "a@example.com"},
+		{PrimaryFromEmail: "b@example.com"},
+		{PrimaryFromEmail: "b@example.com"},
+	}
+	lines := strings.Split(strings.TrimSuffix(writeSenders(t, formatNDJSON, records), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSON 줄 = %q", lines)
+	}
+	var first senderRow
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Sender != "b@example.com" || first.Count != 2 {
+		t.Errorf("NDJSON 첫 행 = %+v", first)
+	}
+	if got := writeSenders(t, formatJSON, nil); got != "[]\n" {
+		t.Errorf("빈 JSON = %q", got)
+	}
+	if got := writeSenders(t, formatNDJSON, nil); got != "" {
+		t.Errorf("빈 NDJSON = %q", got)
+	}
+}
+
+func TestCheckSendersFormat(t *testing.T) {
+	for _, f := range []string{formatCSV, formatJSON, formatNDJSON} {
+		if err := checkSendersFormat(f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	for _, f := range []string{formatTable, formatURLs, formatTemplate, formatXML} {
+		if err := checkSendersFormat(f); err == nil {
+			t.Errorf("%s: 오류가 없음", f)
+		}
+	}
+}
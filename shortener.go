@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 단축 URL 서비스 도메인. -shortener-list 파일로 더할 수 있습니다.
+var defaultShortenerDomains = domainList{
+	"bit.ly", "bitly.com", "t.co", "tinyurl.com", "goo.gl", "ow.ly", "is.gd", "buff.ly", "cutt.ly",
+	"rebrand.ly", "rb.gy", "shorturl.at", "tiny.cc", "t.ly", "lnkd.in", "s.id", "me2.do", "han.gl", "url.kr",
+}
+
+// maxShortenerRedirects는 단축 URL을 풀 때 따라가는 최대 리디렉션 수입니다.
+const maxShortenerRedirects = 5
+
+// shortenerConcurrency는 -expand-shorteners의 동시 요청 수입니다.
+const shortenerConcurrency = 4
+
+// loadShortenerDomains는 기본 단축 URL 도메인에 목록 파일(-shortener-list)의 도메인을 더합니다.
+func loadShortenerDomains(files []string) (domainList, error) {
+	extra, err := loadDomainList(nil, files)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(domainList{}, defaultShortenerDomains...), extra...), nil
+}
+
+// markShorteners는 URLs 중 단축 URL 서비스의 URL을 ShortURLs에 기록하고 HasShortener를 설정합니다.
+func markShorteners(r *EmailRecord, shorteners domainList) {
+	var short []string
+	for _, u := range strings.Split(r.URLs, "\n") {
+		if host, ok := urlDomain(u); ok && shorteners.match(host) {
+			short = appendUnique(short, u)
+		}
+	}
+	r.ShortURLs = strings.Join(short, "\n")
+	r.HasShortener = len(short) > 0
+}
+
+// expandEntry는 캐시 항목으로, done이 닫힌 뒤에만 final/ok를 읽을 수 있습니다.
+type expandEntry struct {
+	done  chan struct{}
+	final string
+	ok    bool
+}
+
+// shortenerExpander는 단축 URL의 리디렉션을 따라가 최종 주소를 구하고 결과를 실행 동안 캐시합니다 (-expand-shorteners).
+// 이 플래그를 켜지 않으면 만들지 않으므로 네트워크 요청을 하지 않습니다.
+type shortenerExpander struct {
+	client *http.Client
+	sem    chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]*expandEntry
+}
+
+func newShortenerExpander(timeout time.Duration) *shortenerExpander {
+	return &shortenerExpander{
+		// 리디렉션은 query에서 한 단계씩 따라감
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		sem:   make(chan struct{}, shortenerConcurrency),
+		cache: make(map[string]*expandEntry),
+	}
+}
+
+// expand는 단축 URL의 최종 주소를 반환합니다. 캐시에 있으면 요청하지 않습니다.
+func (e *shortenerExpander) expand(u string) (string, bool) {
+	e.mu.Lock()
+	entry, ok := e.cache[u]
+	if !ok {
+		entry = &expandEntry{done: make(chan struct{})}
+		e.cache[u] = entry
+	}
+	e.mu.Unlock()
+	if ok {
+		<-entry.done
+		return entry.final, entry.ok
+	}
+
+	e.sem <- struct{}{}
+	final, err := e.query(u)
+	<-e.sem
+	if err != nil {
+		debugf("단축 URL 확인 실패: %s (%v)", u, err)
+	} else {
+		entry.final, entry.ok = final, true
+	}
+	close(entry.done)
+	return entry.final, entry.ok
+}
+
+// query는 리디렉션을 최대 maxShortenerRedirects번 한 단계씩 따라가 최종 주소를 반환합니다.
+// 최종 주소 자체는 요청하지 않아도 되므로, 중간 이후의 요청이 실패하면 그때까지 알아낸 주소를 반환합니다.
+func (e *shortenerExpander) query(u string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.client.Timeout)
+	defer cancel()
+	for i := 0; i < maxShortenerRedirects; i++ {
+		next, err := e.next(ctx, u)
+		if err != nil {
+			if i > 0 {
+				return u, nil
+			}
+			return "", err
+		}
+		if next == nil {
+			return u, nil
+		}
+		if next.Scheme != "http" && next.Scheme != "https" {
+			return next.String(), nil
+		}
+		u = next.String()
+	}
+	return u, nil
+}
+
+// next는 u의 리디렉션 대상(리디렉션이 아니면 nil)을 반환합니다.
+// HEAD를 지원하지 않는 서비스는 GET으로 다시 시도하며, 본문은 읽지 않습니다.
+func (e *shortenerExpander) next(ctx context.Context, u string) (*url.URL, error) {
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = e.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+	loc, err := resp.Location()
+	if errors.Is(err, http.ErrNoLocation) {
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("HTTP %s", resp.Status)
+		}
+		return nil, nil
+	}
+	return loc, err
+}
+
+// expandRecordShorteners는 ShortURLs의 최종 주소를 같은 줄 순서로 ExpandedURLs(확인하지 못하면 빈 줄)에 기록하고,
+// 최종 주소의 도메인을 URLDomains에 더합니다. exclude(-url-domain-deny, -own-domains)에 맞는 도메인은 더하지 않습니다.
+func expandRecordShorteners(e *shortenerExpander, r *EmailRecord, exclude domainList) {
+	if r.ShortURLs == "" {
+		return
+	}
+	short := strings.Split(r.ShortURLs, "\n")
+	expanded := make([]string, len(short))
+	domains := strings.Split(r.URLDomains, "\n")
+	if r.URLDomains == "" {
+		domains = nil
+	}
+	for i, u := range short {
+		final, ok := e.expand(u)
+		if !ok {
+			continue
+		}
+		expanded[i] = final
+		if host, ok := urlDomain(final); ok && host != "" && !exclude.match(host) {
+			domains = appendUnique(domains, host)
+		}
+	}
+	r.ExpandedURLs = strings.Join(expanded, "\n")
+	r.URLDomains = strings.Join(domains, "\n")
+}
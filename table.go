@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// 표 출력 기본 너비: 터미널 너비를 알 수 없을 때 사용
+const defaultTableWidth = 120
+
+// tableRecordWriter는 터미널에서 보기 좋은 정렬된 표로 주요 필드(날짜, 보낸사람, 제목)를 출력합니다.
+// 레코드를 모아 두지 않고 바로 출력할 수 있도록 열 너비를 터미널 너비로 미리 정하며,
+// 한글 등 전각 문자를 고려해 표시 너비 기준으로 자르고(…) 채웁니다.
+//
+// color가 설정되면 의심스러운 메일의 행을 빨강(위험)/노랑(주의)으로 강조합니다.
+// 순전히 화면 표시용이며 CSV/JSON 등 기계용 출력에는 적용되지 않습니다.
+type tableRecordWriter struct {
+	w           *bufio.Writer
+	width       int
+	color       bool
+	wroteHeader bool
+}
+
+// ANSI 색상 코드
+const (
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// 위험도
+const (
+	riskNone = iota
+	riskWarn
+	riskHigh
+)
+
+// 표 출력에서 주의/위험으로 강조할 피싱 의심 점수(-score)의 기준
+const (
+	warnScore = 4
+	highScore = 8
+)
+
+// recordRisk는 표 출력 색상 강조를 위한 위험도를 판단합니다.
+// SPF/DKIM/DMARC 인증 실패나 highScore 이상의 점수는 위험, softfail/오류나 warnScore 이상의 점수는 주의로 봅니다.
+func recordRisk(r EmailRecord) int {
+	if r.Score >= highScore {
+		return riskHigh
+	}
+	risk := riskNone
+	if r.Score >= warnScore {
+		risk = riskWarn
+	}
+	for _, res := range strings.Fields(r.AuthResults) {
+		_, value, _ := strings.Cut(res, "=")
+		switch value {
+		case "fail":
+			return riskHigh
+		case "softfail", "permerror", "temperror":
+			risk = riskWarn
+		}
+	}
+	return risk
+}
+
+// isTerminal은 f가 터미널인지 확인합니다. 파이프나 파일로 출력하면 false입니다.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func newTableRecordWriter(w io.Writer) *tableRecordWriter {
+	return &tableRecordWriter{w: bufio.NewWriter(w), width: terminalWidth(w)}
+}
+
+// terminalWidth는 출력 대상이 터미널이면 그 너비를, 아니면 COLUMNS 환경 변수나 기본값을 반환합니다.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultTableWidth
+}
+
+// 열 너비: 날짜는 고정, 보낸사람은 남은 너비의 1/3, 제목은 나머지
+func (t *tableRecordWriter) columnWidths() []int {
+	const dateWidth = 19
+	const gaps = 2 * 2
+	rest := t.width - dateWidth - gaps
+	if rest < 20 {
+		rest = 20
+	}
+	fromWidth := rest / 3
+	return []int{dateWidth, fromWidth, rest - fromWidth}
+}
+
+func (t *tableRecordWriter) writeRow(cells []string) error {
+	return t.writeColoredRow(cells, "")
+}
+
+func (t *tableRecordWriter) writeColoredRow(cells []string, color string) error {
+	widths := t.columnWidths()
+	var b strings.Builder
+	b.WriteString(color)
+	for i, cell := range cells {
+		cell = strings.Join(strings.Fields(cell), " ")
+		cell = runewidth.Truncate(cell, widths[i], "…")
+		if i < len(cells)-1 {
+			b.WriteString(runewidth.FillRight(cell, widths[i]))
+			b.WriteString("  ")
+		} else {
+			b.WriteString(cell)
+		}
+	}
+	if color != "" {
+		b.WriteString(ansiReset)
+	}
+	b.WriteString("\n")
+	_, err := t.w.WriteString(b.String())
+	return err
+}
+
+func (t *tableRecordWriter) writeHeader() error {
+	if t.wroteHeader {
+		return nil
+	}
+	t.wroteHeader = true
+	if err := t.writeRow([]string{"보낸 날짜", "보낸사람", "제목"}); err != nil {
+		return err
+	}
+	_, err := t.w.WriteString(strings.Repeat("-", t.width) + "\n")
+	return err
+}
+
+func (t *tableRecordWriter) WriteRecord(r EmailRecord) error {
+	if err := t.writeHeader(); err != nil {
+		return err
+	}
+	from := r.PrimaryFromEmail
+	if r.PrimaryFromName != "" {
+		from = r.PrimaryFromName + " <" + r.PrimaryFromEmail + ">"
+	}
+	var color string
+	if t.color {
+		switch recordRisk(r) {
+		case riskHigh:
+			color = ansiRed
+		case riskWarn:
+			color = ansiYellow
+		}
+	}
+	return t.writeColoredRow([]string{r.SentDate, from, r.Subject}, color)
+}
+
+func (t *tableRecordWriter) Close() error {
+	if err := t.writeHeader(); err != nil {
+		return err
+	}
+	return t.w.Flush()
+}
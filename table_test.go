@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecordRisk(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  EmailRecord
+		want int
+	}{
+		{"정상", EmailRecord{AuthResults: "spf=pass dkim=pass dmarc=pass"}, riskNone},
+		{"인증 결과 없음", EmailRecord{}, riskNone},
+		{"dkim 실패", EmailRecord{AuthResults: "spf=pass dkim=fail dmarc=pass"}, riskHigh},
+		{"spf softfail", EmailRecord{AuthResults: "spf=softfail dkim=pass"}, riskWarn},
+		{"dmarc temperror", EmailRecord{AuthResults: "dmarc=temperror"}, riskWarn},
+		{"softfail 뒤 fail", EmailRecord{AuthResults: "spf=softfail dkim=fail"}, riskHigh},
+		// 인증을 통과해도 점수가 높으면 강조
+		{"점수 주의 미만", EmailRecord{AuthResults: "spf=pass", Score: warnScore - 1}, riskNone},
+		{"점수 주의", EmailRecord{AuthResults: "spf=pass dkim=pass", Score: warnScore}, riskWarn},
+		{"점수 위험", EmailRecord{AuthResults: "spf=pass dkim=pass", Score: highScore}, riskHigh},
+		{"점수 주의 + fail", EmailRecord{AuthResults: "spf=fail", Score: warnScore}, riskHigh},
+		{"점수 위험 + softfail", EmailRecord{AuthResults: "spf=softfail", Score: 18}, riskHigh},
+	}
+	for _, tt := range tests {
+		if got := recordRisk(tt.rec); got != tt.want {
+			t.Errorf("%s: recordRisk = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTableRowColors(t *testing.T) {
+	var buf bytes.Buffer
+	w := &tableRecordWriter{w: bufio.NewWriter(&buf), width: 80, color: true}
+	records := []EmailRecord{
+		{SentDate: "2024-03-05 14:22:10", PrimaryFromEmail: "a@example.com", Subject: "정상", AuthResults: "spf=pass"},
+		{SentDate: "2024-03-05 14:22:11", PrimaryFromEmail: "b@example.com", Subject: "주의", Score: warnScore},
+		{SentDate: "2024-03-05 14:22:12", PrimaryFromEmail: "c@example.com", Subject: "피싱", AuthResults: "spf=pass dkim=pass", Score: 18},
+	}
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("줄 수 = %d, want 5:\n%s", len(lines), buf.String())
+	}
+	wantPrefix := []string{"", ansiYellow, ansiRed}
+	for i, prefix := range wantPrefix {
+		line := lines[2+i]
+		colored := strings.HasPrefix(line, "\x1b[")
+		if prefix == "" && colored || prefix != "" && !(strings.HasPrefix(line, prefix) && strings.HasSuffix(line, ansiReset)) {
+			t.Errorf("%d번째 행 색상이 다름: %q", i+1, line)
+		}
+	}
+
+	// color가 꺼져 있으면 점수가 높아도 색상 코드를 쓰지 않음
+	buf.Reset()
+	w = &tableRecordWriter{w: bufio.NewWriter(&buf), width: 80}
+	if err := w.WriteRecord(records[2]); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("color 꺼짐인데 색상 코드가 있음: %q", buf.String())
+	}
+}
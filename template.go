@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs는 -template에서 사용할 수 있는 도우미 함수입니다.
+//   - lines: 줄바꿈으로 여러 값을 담은 필드를 목록으로 나눔 ({{range lines .URLs}})
+//   - join: 목록을 구분자로 이음 ({{join (lines .URLs) ", "}})
+//   - truncate: 앞 n글자만 남기고 잘렸으면 "…"를 붙임 ({{truncate 40 .Subject}})
+var templateFuncs = template.FuncMap{
+	"lines": func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	},
+	"join": func(items []string, sep string) string { return strings.Join(items, sep) },
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); n >= 0 && len(r) > n {
+			return string(r[:n]) + "…"
+		}
+		return s
+	},
+}
+
+// loadTemplate은 -template 파일을 읽어 도우미 함수와 함께 파싱합니다.
+func loadTemplate(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+}
+
+// templateRecordWriter는 레코드를 사용자 템플릿(text/template)으로 출력합니다.
+// 기본적으로 레코드마다 템플릿을 실행하며(점(.)은 EmailRecord), all이 설정되면 모든 레코드를 모았다가
+// 닫을 때 한 번 실행합니다(점(.)은 []EmailRecord).
+type templateRecordWriter struct {
+	w       *bufio.Writer
+	tmpl    *template.Template
+	all     bool
+	records []EmailRecord
+}
+
+func newTemplateRecordWriter(w io.Writer, tmpl *template.Template, all bool) *templateRecordWriter {
+	return &templateRecordWriter{w: bufio.NewWriter(w), tmpl: tmpl, all: all}
+}
+
+func (t *templateRecordWriter) WriteRecord(r EmailRecord) error {
+	if t.all {
+		t.records = append(t.records, r)
+		return nil
+	}
+	return t.tmpl.Execute(t.w, r)
+}
+
+func (t *templateRecordWriter) Close() error {
+	if t.all {
+		records := t.records
+		if records == nil {
+			records = []EmailRecord{}
+		}
+		if err := t.tmpl.Execute(t.w, records); err != nil {
+			return err
+		}
+	}
+	return t.w.Flush()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTemplate은 t.TempDir()에 템플릿 파일을 만들어 loadTemplate으로 읽고, records를 출력한 결과를 반환합니다.
+func writeTemplate(t *testing.T, text string, all bool, records []EmailRecord) (string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.tmpl")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := newTemplateRecordWriter(&buf, tmpl, all)
+	for _, r := range records {
+		if err := w.WriteRecord(r); err != nil {
+			return buf.String(), err
+		}
+	}
+	err = w.Close()
+	return buf.String(), err
+}
+
+var templateInput = []EmailRecord{
+	{Subject: "분기 보고서 초안입니다", URLs: "https://a.example/1\nhttps://b.example/2"},
+	{Subject: "hi"},
+}
+
+func TestTemplateRecordWriter(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		all  bool
+		want string
+	}{
+		{"레코드마다", "{{.Subject}}\n", false, "분기 보고서 초안입니다\nhi\n"},
+		{"lines/join", "[{{join (lines .URLs) \", \"}}] {{len (lines .URLs)}}\n", false,
+			"[https://a.example/1, https://b.example/2] 2\n[] 0\n"},
+		{"range lines", "{{range lines .URLs}}- {{.}}\n{{end}}", false, "- https://a.example/1\n- https://b.example/2\n"},
+		{"truncate", "{{truncate 6 .Subject}}|{{truncate 0 .Subject}}|{{truncate -1 .Subject}}\n", false,
+			"분기 보고서…|…|분기 보고서 초안입니다\nhi|…|hi\n"},
+		{"전체 목록", "{{len .}}:{{range $i, $r := .}}{{if $i}},{{end}}{{$r.Subject}}{{end}}\n", true,
+			"2:분기 보고서 초안입니다,hi\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := writeTemplate(t, tt.text, tt.all, templateInput)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("출력 = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// -template-all에서 레코드가 없으면 nil 대신 빈 목록으로 실행
+func TestTemplateAllEmpty(t *testing.T) {
+	got, err := writeTemplate(t, "{{len .}} {{printf \"%T\" .}}\n", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 []main.EmailRecord\n"; got != want {
+		t.Errorf("출력 = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := writeTemplate(t, "{{.NoSuchField}}", false, templateInput); err == nil {
+		t.Error("없는 필드: 실행 오류가 없음")
+	}
+	if _, err := loadTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("없는 파일: 오류가 없음")
+	}
+	path := filepath.Join(t.TempDir(), "bad.tmpl")
+	if err := os.WriteFile(path, []byte("{{lines}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTemplate(path); err == nil {
+		t.Error("잘못된 템플릿: 파싱 오류가 없음")
+	}
+}
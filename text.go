@@ -0,0 +1,129 @@
+package main
+
+import (
+	"html"
+	"math"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// plainText는 text/plain 파트의 본문을 반환합니다.
+// quoted-printable 소프트 줄바꿈은 go-message가 디코딩하면서 이미 제거하며,
+// format=flowed(RFC 3676)이면 소프트 줄바꿈으로 나뉜 줄을 다시 이어 URL이 끊기지 않게 합니다.
+func plainText(p *mimePart) string {
+	text := strings.ReplaceAll(string(p.body), "\r\n", "\n")
+	if strings.EqualFold(p.params["format"], "flowed") {
+		text = unflowText(text, strings.EqualFold(p.params["delsp"], "yes"))
+	}
+	return text
+}
+
+// unflowText는 format=flowed 본문의 소프트 줄바꿈(줄 끝 공백)을 제거하여 문단을 한 줄로 잇고,
+// 공백 채우기(space-stuffing)로 붙은 줄 앞 공백 하나를 제거합니다.
+// delsp가 설정되면 줄 끝 공백도 원문에 없던 것으로 보고 함께 제거합니다.
+func unflowText(text string, delsp bool) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimPrefix(line, " ")
+		// "-- " 서명 구분선은 소프트 줄바꿈이 아님
+		if line != "-- " && strings.HasSuffix(line, " ") {
+			if delsp {
+				line = line[:len(line)-1]
+			}
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// textToHTML은 텍스트 본문을 HTML 저장용으로 감쌉니다.
+func textToHTML(text string) string {
+	return "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"></head><body><pre>" +
+		html.EscapeString(text) + "</pre></body></html>\n"
+}
+
+// htmlVisibleText는 HTML에서 화면에 보이는 텍스트만 공백으로 이어 반환합니다 (script/style 등 제외).
+// stripQuotes가 설정되면 인용(blockquote, Gmail의 gmail_quote)과 서명(gmail_signature) 요소도 제외합니다.
+func htmlVisibleText(htmlContent string, stripQuotes bool) string {
+	doc, err := xhtml.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return htmlContent
+	}
+	var b strings.Builder
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode {
+			switch n.Data {
+			case "script", "style", "head", "noscript", "template":
+				return
+			case "blockquote":
+				if stripQuotes {
+					return
+				}
+			}
+			if stripQuotes && hasQuoteClass(htmlAttr(n, "class")) {
+				return
+			}
+		}
+		if n.Type == xhtml.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return b.String()
+}
+
+// hasQuoteClass는 메일 프로그램이 인용/서명 요소에 붙이는 class인지 확인합니다.
+func hasQuoteClass(class string) bool {
+	for _, c := range strings.Fields(class) {
+		switch c {
+		case "gmail_quote", "gmail_signature", "moz-signature", "moz-cite-prefix":
+			return true
+		}
+	}
+	return false
+}
+
+// stripQuotedText는 텍스트 본문에서 ">"로 시작하는 인용 줄과, "-- " 서명 구분선 이후를 제거합니다.
+func stripQuotedText(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimRight(line, "\r")
+		if trimmed == "-- " || trimmed == "--" {
+			break
+		}
+		if strings.HasPrefix(strings.TrimLeft(trimmed, " \t"), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// bodyPreviewRunes는 본문 미리보기의 최대 글자 수입니다.
+const bodyPreviewRunes = 200
+
+// bodyPreview는 본문 텍스트의 공백을 하나로 줄여 앞부분만 반환합니다.
+func bodyPreview(text string) string {
+	text = strings.Join(strings.Fields(cleanText(text)), " ")
+	if r := []rune(text); len(r) > bodyPreviewRunes {
+		return string(r[:bodyPreviewRunes]) + "…"
+	}
+	return text
+}
+
+// bodyStats는 본문 단어 수와 링크 밀도(단어당 링크 수, 단어가 없으면 1단어로 계산)를 구합니다.
+// 링크가 많고 단어가 적은 메일은 스팸/피싱 지표가 됩니다.
+func bodyStats(text string, linkCount int) (words int, density float64) {
+	words = len(strings.Fields(text))
+	density = float64(linkCount) / float64(max(words, 1))
+	return words, math.Round(density*10000) / 10000
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+
+	"github.com/emersion/go-message"
+)
+
+// normalizeTransferEncoding은 Content-Transfer-Encoding 값을 표준 이름으로 정리합니다.
+// go-message는 정확한 이름만 인식하므로 "Quoted-Printable (qp)", "base64;", "quoted_printable"처럼
+// 주석/파라미터/철자 변형이 붙은 값을 인식하지 못해 본문을 디코딩하지 않습니다.
+func normalizeTransferEncoding(enc string) string {
+	if i := strings.IndexAny(enc, "(;"); i >= 0 {
+		enc = enc[:i]
+	}
+	enc = strings.ToLower(strings.Trim(strings.TrimSpace(enc), `"'`))
+	if f := strings.Fields(enc); len(f) > 0 {
+		enc = f[0]
+	}
+	switch strings.NewReplacer("-", "", "_", "").Replace(enc) {
+	case "quotedprintable", "qp":
+		return "quoted-printable"
+	case "base64", "b64":
+		return "base64"
+	case "7bit", "8bit", "binary", "":
+		return ""
+	}
+	return enc
+}
+
+// handledByGoMessage는 go-message가 그대로 디코딩하는 Content-Transfer-Encoding 값인지 확인합니다.
+func handledByGoMessage(enc string) bool {
+	switch strings.ToLower(enc) {
+	case "quoted-printable", "base64", "7bit", "8bit", "binary", "":
+		return true
+	}
+	return false
+}
+
+// transferDecoder는 정리된 전송 인코딩에 맞는 디코딩 reader를 반환합니다.
+func transferDecoder(enc string, r io.Reader) io.Reader {
+	switch enc {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	}
+	return r
+}
+
+// entityBody는 엔티티 본문을 반환합니다. go-message가 인식하지 못한 전송 인코딩은 정리한 이름으로 다시 디코딩합니다.
+// 이 경우 go-message는 인코딩된 원문에 문자셋 변환만 적용해 두는데, quoted-printable/base64 원문은 ASCII라
+// 변환 결과가 원문과 같으므로 전송 디코딩 뒤 문자셋 변환을 다시 적용합니다.
+func entityBody(e *message.Entity) io.Reader {
+	raw := e.Header.Get("Content-Transfer-Encoding")
+	if handledByGoMessage(raw) {
+		return e.Body
+	}
+	enc := normalizeTransferEncoding(raw)
+	if enc != "quoted-printable" && enc != "base64" {
+		return e.Body
+	}
+	body := transferDecoder(enc, e.Body)
+	mediaType, params, _ := e.Header.ContentType()
+	if cs := params["charset"]; strings.HasPrefix(mediaType, "text/") && cs != "" {
+		if charsetEnc, ok := lookupCharset(cs); ok {
+			body = charsetEnc.NewDecoder().Reader(body)
+		}
+	}
+	return body
+}
+
+// decodeRawBody는 raw 파싱의 본문을 헤더에 적힌 전송 인코딩으로 디코딩합니다.
+// 디코딩에 실패하면 원문을 그대로 반환합니다.
+func decodeRawBody(enc, body string) string {
+	enc = normalizeTransferEncoding(enc)
+	if enc != "quoted-printable" && enc != "base64" {
+		return body
+	}
+	decoded, err := io.ReadAll(transferDecoder(enc, strings.NewReader(body)))
+	if err != nil && len(decoded) == 0 {
+		return body
+	}
+	return string(decoded)
+}
+
+// base64Cleaner는 base64 본문의 줄바꿈/공백을 걸러냅니다.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		kept := p[:0]
+		for _, b := range p[:n] {
+			if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+				kept = append(kept, b)
+			}
+		}
+		if len(kept) > 0 || err != nil {
+			return len(kept), err
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// extractUrlsRegex는 HTML이 아닌 텍스트에서 정규식으로 URL을 추출합니다.
+func extractUrlsRegex(text string) []string {
+	return appendUnique(nil, extractUrlsRegexAll(text, false)...)
+}
+
+// extractUrlsRegexAll은 extractUrlsRegex와 같지만 중복을 제거하지 않고 나온 순서대로 모두 반환합니다.
+// isHTML이면(파싱할 수 없거나 링크 요소가 없는 HTML) DOM 파서가 속성 값을 디코딩하는 것과 같은 결과가 나오도록
+// ";"로 끝나는 엔티티(&amp; 등)를 디코딩합니다. 텍스트 본문은 "&amp;"도 그대로 둡니다.
+func extractUrlsRegexAll(text string, isHTML bool) []string {
+	var urls []string
+	for _, u := range urlRegex.FindAllString(text, -1) {
+		if isHTML {
+			u = unescapeEntities(u)
+		}
+		if u = normalizeURL(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// ";"로 끝나는 HTML 엔티티 (이름, 10진수, 16진수)
+var htmlEntityRegex = regexp.MustCompile(`&(?:[A-Za-z][A-Za-z0-9]*|#[0-9]+|#[xX][0-9A-Fa-f]+);`)
+
+// unescapeEntities는 ";"로 끝나는 엔티티만 디코딩합니다. html.UnescapeString은 "&not=2"의 "&not"처럼
+// ";" 없는 옛 엔티티 이름도 디코딩하므로 쿼리 문자열이 깨집니다.
+func unescapeEntities(s string) string {
+	return htmlEntityRegex.ReplaceAllStringFunc(s, html.UnescapeString)
+}
+
+// normalizeURL은 추출한 URL을 정리합니다. 정규식 경로와 DOM 경로가 같은 규칙을 쓰도록 공유합니다.
+// 엔티티는 디코딩하지 않습니다 (DOM 속성 값은 파서가 이미 디코딩했으므로 다시 디코딩하면 "&amp;amp;"가 "&"가 됨).
+//   - 태그/속성 경계("<", ">", 따옴표)나 공백에서 자름
+//   - 끝의 구두점(".", ",", "!" 등)과, 짝이 맞지 않는 닫는 괄호(")", "]", "}")를 제거
+//     ("https://en.wikipedia.org/wiki/Go_(language)"처럼 짝이 맞는 괄호는 유지)
+//
+// 호스트가 남지 않으면 빈 문자열을 반환합니다.
+func normalizeURL(u string) string {
+	if i := strings.IndexAny(u, "<>\"'` \t\r\n"); i >= 0 {
+		u = u[:i]
+	}
+	for u != "" {
+		last := u[len(u)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*", last) >= 0:
+		case last == ')' && strings.Count(u, "(") < strings.Count(u, ")"):
+		case last == ']' && strings.Count(u, "[") < strings.Count(u, "]"):
+		case last == '}' && strings.Count(u, "{") < strings.Count(u, "}"):
+		default:
+			if parsed, err := url.Parse(u); err != nil || parsed.Host == "" {
+				return ""
+			}
+			return u
+		}
+		u = u[:len(u)-1]
+	}
+	return ""
+}
+
+// linkSet은 본문에서 추출한 링크를 종류별로 담습니다.
+type linkSet struct {
+	urls    []string // 탐색 가능한 URL (http/https, 옵션에 따라 ftp)
+	sources []string // urls와 같은 순서의 출처 요소 (a, form, meta-refresh 등)
+	mailtos []string // mailto: 링크
+	tels    []string // tel: 링크
+	// javascript:/data:/vbscript: 등 탐색 대상이 아닌 href 수. 인라인 스크립트 링크는 그 자체로 의심 지표입니다.
+	suspiciousHrefs int
+	// 링크 텍스트가 URL이나 도메인처럼 보이는데 실제 href와 등록 도메인이 다른 <a> 수
+	textMismatches int
+	// 중복 제거 전의 모든 URL과 출처 (나온 순서대로, -no-dedup-urls). dedupe는 이 목록을 바꾸지 않습니다.
+	allURLs    []string
+	allSources []string
+}
+
+// URL 출처 중 HTML 요소가 아닌 것
+const (
+	urlSourceText            = "text"             // 정규식으로 본문 텍스트에서 추출
+	urlSourceListUnsubscribe = "list-unsubscribe" // List-Unsubscribe 헤더
+	urlSourceMetaRefresh     = "meta-refresh"     // <meta http-equiv="refresh">
+)
+
+// linkAttrs는 링크를 담는 요소와 그 속성입니다.
+var linkAttrs = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"form":   "action",
+	"iframe": "src",
+}
+
+// 의심 지표로 세는 비탐색 스킴
+var suspiciousSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+}
+
+// add는 href를 스킴에 따라 분류합니다. source는 href가 나온 요소 이름입니다.
+func (l *linkSet) add(href, source string, allowFTP bool) {
+	scheme, _, ok := strings.Cut(href, ":")
+	if !ok {
+		return
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		if u := normalizeURL(href); u != "" {
+			l.addURL(u, source)
+		}
+	case "ftp":
+		if u := normalizeURL(href); u != "" && allowFTP {
+			l.addURL(u, source)
+		}
+	case "mailto":
+		l.mailtos = append(l.mailtos, href)
+	case "tel":
+		l.tels = append(l.tels, href)
+	default:
+		if suspiciousSchemes[strings.ToLower(scheme)] {
+			l.suspiciousHrefs++
+		}
+	}
+}
+
+// addURL은 스킴 분류 없이 URL과 출처를 추가합니다.
+func (l *linkSet) addURL(u, source string) {
+	l.urls = append(l.urls, u)
+	l.sources = append(l.sources, source)
+	l.allURLs = append(l.allURLs, u)
+	l.allSources = append(l.allSources, source)
+}
+
+// merge는 다른 linkSet의 링크를 더하고 중복을 제거합니다.
+func (l *linkSet) merge(o linkSet) {
+	l.urls = append(l.urls, o.urls...)
+	l.sources = append(l.sources, o.sources...)
+	l.mailtos = append(l.mailtos, o.mailtos...)
+	l.tels = append(l.tels, o.tels...)
+	l.suspiciousHrefs += o.suspiciousHrefs
+	l.textMismatches += o.textMismatches
+	l.allURLs = append(l.allURLs, o.allURLs...)
+	l.allSources = append(l.allSources, o.allSources...)
+	l.dedupe()
+}
+
+// dedupe는 중복을 제거합니다. URL은 처음 나온 출처를 유지합니다.
+func (l *linkSet) dedupe() {
+	seen := make(map[string]bool, len(l.urls))
+	urls, sources := l.urls[:0], l.sources[:0]
+	for i, u := range l.urls {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+		sources = append(sources, l.sources[i])
+	}
+	l.urls, l.sources = urls, sources
+	l.mailtos = appendUnique(nil, l.mailtos...)
+	l.tels = appendUnique(nil, l.tels...)
+}
+
+// extractLinks는 HTML 본문의 링크(<a>/<area>/<link href>, <form action>, <iframe src>,
+// <meta http-equiv="refresh">)를 추출하여 스킴별로 분류하고, 각 URL의 출처 요소를 기록합니다.
+// <base href>가 있으면 상대 경로를 그 기준으로 해석하고(없으면 상대 경로는 건너뜀),
+// 프로토콜 상대 URL("//host/path")은 https로 해석합니다. 중복 제거 전에 #fragment는 제거합니다.
+func extractLinks(htmlContent string, allowFTP bool) (links linkSet) {
+	if strings.TrimSpace(htmlContent) == "" {
+		return links
+	}
+	doc, err := xhtml.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		links.addHTMLText(htmlContent)
+		return links
+	}
+	// 링크가 요소 없이 본문 텍스트, onclick, style 속성 등에만 있는 경우를 위해
+	// DOM에서 URL을 하나도 찾지 못하면 원문 전체에 정규식을 적용
+	defer func() {
+		if len(links.urls) == 0 {
+			links.addHTMLText(htmlContent)
+		}
+	}()
+	base := findBaseURL(doc)
+
+	var crawler func(*xhtml.Node)
+	crawler = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode {
+			source := n.Data
+			key, ok := linkAttrs[n.Data]
+			if n.Data == "meta" && strings.EqualFold(htmlAttr(n, "http-equiv"), "refresh") {
+				source, key, ok = urlSourceMetaRefresh, "content", true
+			}
+			for _, attr := range n.Attr {
+				if !ok || attr.Key != key {
+					continue
+				}
+				val := attr.Val
+				if source == urlSourceMetaRefresh {
+					if val, ok = parseMetaRefresh(val); !ok {
+						break
+					}
+				}
+				// 속성 값은 파서가 이미 엔티티를 디코딩한 값이므로 공백만 정리.
+				// 브라우저처럼 중간의 탭/줄바꿈도 제거하여 "java\tscript:" 같은 우회를 막음
+				href := strings.Map(func(r rune) rune {
+					if r == '\t' || r == '\n' || r == '\r' {
+						return -1
+					}
+					return r
+				}, strings.TrimSpace(val))
+				if u, ok := resolveHref(base, href); ok {
+					links.add(u, source, allowFTP)
+					if n.Data == "a" && linkTextMismatch(anchorText(n), u) {
+						links.textMismatches++
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			crawler(c)
+		}
+	}
+	crawler(doc)
+
+	links.dedupe()
+	return links
+}
+
+// 링크 텍스트로 쓰인 도메인 (스킴 없이 "www.example.com/login"처럼 쓴 경우)
+var linkTextDomainRegex = regexp.MustCompile(`^(?i)[a-z0-9-]+(\.[a-z0-9-]+)*\.[a-z]{2,}(/\S*)?$`)
+
+// anchorText는 요소 안의 텍스트를 이어 붙여 앞뒤 공백을 제거합니다.
+func anchorText(n *xhtml.Node) string {
+	var b strings.Builder
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+// linkTextMismatch는 링크 텍스트가 URL이나 도메인이고 그 등록 도메인이 href의 등록 도메인과 다른지 확인합니다.
+// 텍스트가 URL처럼 보이지 않으면("여기를 클릭") 불일치로 보지 않습니다.
+func linkTextMismatch(text, href string) bool {
+	var shown string
+	if strings.Contains(text, "://") {
+		shown = text
+	} else if linkTextDomainRegex.MatchString(text) {
+		shown = "http://" + text
+	} else {
+		return false
+	}
+	shownHost, ok := urlDomain(shown)
+	if !ok {
+		return false
+	}
+	hrefHost, ok := urlDomain(href)
+	if !ok {
+		return false
+	}
+	if d, ok := registeredDomain(shownHost); ok {
+		shownHost = d
+	}
+	if d, ok := registeredDomain(hrefHost); ok {
+		hrefHost = d
+	}
+	return shownHost != hrefHost
+}
+
+// addText는 본문 텍스트에서 정규식으로 찾은 URL을 추가합니다. 같은 URL은 처음 나온 것만 urls에 남기고, 모든 출현은 allURLs에 기록합니다.
+func (l *linkSet) addText(text string) {
+	l.addRegexURLs(text, false)
+}
+
+// addHTMLText는 addText와 같지만 HTML 원문에 정규식을 적용하므로 엔티티를 디코딩합니다.
+func (l *linkSet) addHTMLText(htmlContent string) {
+	l.addRegexURLs(htmlContent, true)
+}
+
+func (l *linkSet) addRegexURLs(text string, isHTML bool) {
+	for _, u := range extractUrlsRegexAll(text, isHTML) {
+		l.addURL(u, urlSourceText)
+	}
+	l.dedupe()
+}
+
+// urlOccurrences는 URL 목록(중복 포함)의 URL별 출현 횟수를 "횟수 URL" 줄로 처음 나온 순서대로 반환합니다 (URLCounts).
+func urlOccurrences(urls []string) string {
+	counts := make(map[string]int, len(urls))
+	var order []string
+	for _, u := range urls {
+		if counts[u] == 0 {
+			order = append(order, u)
+		}
+		counts[u]++
+	}
+	lines := make([]string, len(order))
+	for i, u := range order {
+		lines[i] = strconv.Itoa(counts[u]) + " " + u
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlAttr는 요소의 속성 값을 반환합니다. 없으면 빈 문자열입니다.
+func htmlAttr(n *xhtml.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// parseMetaRefresh는 <meta http-equiv="refresh"> content 값에서 이동할 URL을 꺼냅니다.
+// "0;url=...", "0; URL='...'", "5 , url = \"...\"", "url=...", "0; http://..." 같은 변형을 허용합니다.
+func parseMetaRefresh(content string) (string, bool) {
+	s := strings.TrimSpace(content)
+	// 선행 지연 시간(소수점 포함)과 구분자
+	s = strings.TrimLeft(s, "0123456789. \t")
+	s = strings.TrimLeft(s, ";, \t")
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimLeft(s[3:], " \t"); strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], " \t")
+		}
+	}
+	if len(s) > 0 && (s[0] == '\'' || s[0] == '"') {
+		q := s[0]
+		s = s[1:]
+		if i := strings.IndexByte(s, q); i >= 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.TrimSpace(s)
+	return s, s != ""
+}
+
+// findBaseURL은 문서의 첫 번째 <base href>를 절대 URL로 반환합니다. 없거나 잘못되었으면 nil입니다.
+func findBaseURL(doc *xhtml.Node) *url.URL {
+	var base *url.URL
+	var find func(*xhtml.Node) bool
+	find = func(n *xhtml.Node) bool {
+		if n.Type == xhtml.ElementNode && n.Data == "base" {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				href := strings.TrimSpace(attr.Val)
+				if strings.HasPrefix(href, "//") {
+					href = "https:" + href
+				}
+				if u, err := url.Parse(href); err == nil && u.IsAbs() {
+					base = u
+					return true
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if find(c) {
+				return true
+			}
+		}
+		return false
+	}
+	find(doc)
+	return base
+}
+
+// resolveHref는 href를 절대 URL로 해석하고 #fragment를 제거합니다.
+// 상대 경로인데 base가 없으면 false를 반환합니다.
+func resolveHref(base *url.URL, href string) (string, bool) {
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+	href, _, _ = strings.Cut(href, "#")
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	if u.IsAbs() {
+		return href, true
+	}
+	if base == nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(u)
+	resolved.Fragment = ""
+	resolved.RawFragment = ""
+	return resolved.String(), true
+}
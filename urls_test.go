@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// DOM 경로(extractLinks)와 정규식 경로(HTML 원문에 정규식 적용)는 같은 조각에서 같은 URL을 내야 함
+func TestDOMAndRegexPathsAgree(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"amp entity", `<a href="https://example.com/p?a=1&amp;b=2">go</a>`, "https://example.com/p?a=1&b=2"},
+		{"numeric entity", `<a href="https://example.com/p?a=1&#38;b=2">go</a>`, "https://example.com/p?a=1&b=2"},
+		{"hex entity", `<a href="https://example.com/p?a=1&#x26;b=2">go</a>`, "https://example.com/p?a=1&b=2"},
+		{"double-escaped amp", `<a href="https://example.com/p?a=1&amp;amp;b=2">go</a>`, "https://example.com/p?a=1&amp;b=2"},
+		{"entity-like param", `<a href="https://example.com/p?a=1&amp;not=2&amp;lt=3">go</a>`, "https://example.com/p?a=1&not=2&lt=3"},
+		{"bare ampersand", `<a href="https://example.com/p?a=1&b=2">go</a>`, "https://example.com/p?a=1&b=2"},
+		{"single quotes", `<a href='https://example.com/q'>go</a>`, "https://example.com/q"},
+		{"unquoted attribute", `<a href=https://example.com/u>go</a>`, "https://example.com/u"},
+		{"trailing period", `<a href="https://example.com/end.">go</a>`, "https://example.com/end"},
+		{"surrounding whitespace", `<a href="  https://example.com/ws  ">go</a>`, "https://example.com/ws"},
+		{"encoded slash", `<a href="https://example.com/r?u=https%3A%2F%2Fx.example">go</a>`, "https://example.com/r?u=https%3A%2F%2Fx.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dom := extractLinks(tt.snippet, false).urls
+			regex := extractUrlsRegexAll(tt.snippet, true)
+			if len(dom) != 1 || dom[0] != tt.want {
+				t.Errorf("DOM 경로 = %q, want [%q]", dom, tt.want)
+			}
+			if len(regex) != 1 || regex[0] != tt.want {
+				t.Errorf("정규식 경로 = %q, want [%q]", regex, tt.want)
+			}
+		})
+	}
+}
+
+// <base href>로 상대 경로를 해석하고, #fragment를 제거하며, 프로토콜 상대 URL은 https로 포함해야 함
+func TestBaseHrefFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{"base-href.eml", []string{
+			"https://example.com/news/2024/article.html",
+			"https://example.com/news/archive/",
+			"https://example.com/unsubscribe?u=42",
+			"https://partner.example.net/offer",
+			"https://cdn.evil.example/x",
+		}},
+		// <base>가 없으면 상대 경로는 건너뜀
+		{"relative-no-base.eml", []string{
+			"https://cdn.example.net/pixel.gif",
+			"https://example.com/full",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			rec, _, err := processEmlFile(filepath.Join("testdata", tt.fixture), parseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(rec.URLs, "\n"); !equalStrings(got, tt.want) {
+				t.Errorf("URLs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveHref(t *testing.T) {
+	base, _ := url.Parse("https://example.com/a/b/")
+	tests := []struct {
+		base   *url.URL
+		href   string
+		want   string
+		wantOK bool
+	}{
+		{base, "c.html", "https://example.com/a/b/c.html", true},
+		{base, "../c.html#x", "https://example.com/a/c.html", true},
+		{base, "/root?q=1#x", "https://example.com/root?q=1", true},
+		{base, "?q=2", "https://example.com/a/b/?q=2", true},
+		{base, "#only-fragment", "", false},
+		{base, "", "", false},
+		{base, "//other.example/p#f", "https://other.example/p", true},
+		{base, "http://abs.example/p#f", "http://abs.example/p", true},
+		{nil, "relative.html", "", false},
+		{nil, "//other.example/p", "https://other.example/p", true},
+		{nil, "mailto:a@example.com", "mailto:a@example.com", true},
+	}
+	for _, tt := range tests {
+		got, ok := resolveHref(tt.base, tt.href)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("resolveHref(%v, %q) = %q, %v, want %q, %v", tt.base, tt.href, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// 정규식 경로의 지저분한 실제 사례: 끝의 구두점, 짝이 맞지 않는 괄호, 태그 경계, 엔티티
+func TestExtractUrlsRegexMessy(t *testing.T) {
+	tests := []struct {
+		text   string
+		isHTML bool
+		want   []string
+	}{
+		{"See https://example.com/a.", false, []string{"https://example.com/a"}},
+		{"Links: https://example.com/a, https://example.com/b;", false, []string{"https://example.com/a", "https://example.com/b"}},
+		{"(see https://example.com/a)", false, []string{"https://example.com/a"}},
+		{"(https://en.wikipedia.org/wiki/Go_(language))", false, []string{"https://en.wikipedia.org/wiki/Go_(language)"}},
+		{"https://en.wikipedia.org/wiki/Go_(language).", false, []string{"https://en.wikipedia.org/wiki/Go_(language)"}},
+		{"[https://example.com/x]", false, []string{"https://example.com/x"}},
+		{"{https://example.com/x}", false, []string{"https://example.com/x"}},
+		{"Really? https://example.com/q?a=1!", false, []string{"https://example.com/q?a=1"}},
+		{"*https://example.com/bold*", false, []string{"https://example.com/bold"}},
+		{"visit https://example.com/path:", false, []string{"https://example.com/path"}},
+		{"<https://example.com/angle>", false, []string{"https://example.com/angle"}},
+		{"https://example.com/a<br>https://example.com/b", true, []string{"https://example.com/a", "https://example.com/b"}},
+		{"url='https://example.com/js')", true, []string{"https://example.com/js"}},
+		{"background:url(https://example.com/bg.png)", true, []string{"https://example.com/bg.png"}},
+		{"`https://example.com/code`", false, []string{"https://example.com/code"}},
+		{"https://", false, nil},
+		{"https://.", false, nil},
+		{"http://example.com:8080/p...", false, []string{"http://example.com:8080/p"}},
+		// 엔티티: HTML 원문은 ";"로 끝나는 엔티티만 디코딩, 텍스트 본문은 그대로
+		{"https://example.com/p?a=1&amp;b=2", true, []string{"https://example.com/p?a=1&b=2"}},
+		{"https://example.com/p?a=1&amp;b=2", false, []string{"https://example.com/p?a=1&amp;b=2"}},
+		{"https://example.com/p?a=1&amp;amp;b=2", true, []string{"https://example.com/p?a=1&amp;b=2"}},
+		{"https://example.com/?a=1&not=2&lt=3", true, []string{"https://example.com/?a=1&not=2&lt=3"}},
+		{"https://example.com/?a=1&amp;not=2&amp;lt=3", true, []string{"https://example.com/?a=1&not=2&lt=3"}},
+		{"https://example.com/p&#63;a=1&#x3D;2", true, []string{"https://example.com/p?a=1=2"}},
+		{"https://example.com/a&lt;b", true, []string{"https://example.com/a"}},
+		{"https://example.com/a&gt;b", true, []string{"https://example.com/a"}},
+	}
+	for _, tt := range tests {
+		got := extractUrlsRegexAll(tt.text, tt.isHTML)
+		if !equalStrings(got, tt.want) {
+			t.Errorf("extractUrlsRegexAll(%q, %v) = %q, want %q", tt.text, tt.isHTML, got, tt.want)
+		}
+	}
+}
+
+func TestUnescapeEntities(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a&amp;b", "a&b"},
+		{"a&amp;amp;b", "a&amp;b"},
+		{"a&not=2", "a&not=2"},
+		{"a&#38;b&#x26;c", "a&b&c"},
+		{"a&unknown;b", "a&unknown;b"},
+		{"&", "&"},
+	}
+	for _, tt := range tests {
+		if got := unescapeEntities(tt.in); got != tt.want {
+			t.Errorf("unescapeEntities(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// -where 식의 문법 (키워드와 연산자 이름은 대소문자 무시):
+//
+//	식     = and식 { "or" and식 }
+//	and식  = not식 { "and" not식 }
+//	not식  = "not" not식 | "(" 식 ")" | 필드 [ 연산자 값 ]
+//	연산자 = "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains" | "matches"
+//	값     = "문자열" | 숫자 | true | false
+//
+// 필드 이름은 EmailRecord 필드 이름(JSON 출력, -template과 같음)이며, 연산자 없이 쓴 필드는 bool 필드만 허용합니다.
+// 문자열 비교는 사전 순이므로 SentDate >= "2024-01-01"처럼 날짜를 비교할 수 있고,
+// contains와 matches(RE2 정규식)는 대소문자를 구분하지 않습니다.
+
+// recordPredicate는 레코드가 조건을 만족하는지 확인합니다.
+type recordPredicate func(r *EmailRecord) bool
+
+// whereToken은 -where 식의 토큰입니다. pos는 식에서의 글자 위치(1부터)입니다.
+type whereToken struct {
+	kind  byte // 'i' 이름, 's' 문자열, 'n' 숫자, 'o' 연산자/괄호, 0 끝
+	text  string
+	pos   int
+	value string // 문자열 토큰의 따옴표를 푼 값
+}
+
+// whereError는 -where 식의 오류 위치를 알려 줍니다.
+func whereError(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("%d번째 글자: %s", pos, fmt.Sprintf(format, args...))
+}
+
+// tokenizeWhere는 -where 식을 토큰으로 나눕니다.
+func tokenizeWhere(expr string) ([]whereToken, error) {
+	var tokens []whereToken
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		pos := utf8.RuneCountInString(expr[:i]) + 1
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"':
+			j := i + 1
+			for j < len(expr) && expr[j] != '"' {
+				if expr[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(expr) {
+				return nil, whereError(pos, "닫는 따옴표가 없습니다")
+			}
+			value, err := strconv.Unquote(expr[i : j+1])
+			if err != nil {
+				return nil, whereError(pos, "잘못된 문자열 %s", expr[i:j+1])
+			}
+			tokens = append(tokens, whereToken{kind: 's', text: expr[i : j+1], pos: pos, value: value})
+			i = j + 1
+		case r == '(' || r == ')':
+			tokens = append(tokens, whereToken{kind: 'o', text: string(r), pos: pos})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, whereError(pos, "알 수 없는 연산자 %q (==, != 사용)", op)
+			}
+			tokens = append(tokens, whereToken{kind: 'o', text: op, pos: pos})
+			i += len(op)
+		case r == '-' || r == '.' || (r >= '0' && r <= '9'):
+			j := i + 1
+			for j < len(expr) && (expr[j] == '.' || (expr[j] >= '0' && expr[j] <= '9')) {
+				j++
+			}
+			tokens = append(tokens, whereToken{kind: 'n', text: expr[i:j], pos: pos})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[j:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, whereToken{kind: 'i', text: expr[i:j], pos: pos})
+			i = j
+		default:
+			return nil, whereError(pos, "알 수 없는 문자 %q", r)
+		}
+	}
+	return append(tokens, whereToken{pos: utf8.RuneCountInString(expr) + 1}), nil
+}
+
+// whereParser는 토큰 목록을 레코드 조건 함수로 컴파일합니다.
+type whereParser struct {
+	tokens []whereToken
+	i      int
+}
+
+func (p *whereParser) peek() whereToken { return p.tokens[p.i] }
+func (p *whereParser) next() whereToken { t := p.tokens[p.i]; p.i++; return t }
+
+// keyword는 다음 토큰이 키워드(and, or, not 등)이면 넘기고 true를 반환합니다.
+func (p *whereParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == 'i' && strings.EqualFold(t.text, word) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) parseOr() (recordPredicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r *EmailRecord) bool { return l(r) || right(r) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (recordPredicate, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r *EmailRecord) bool { return l(r) && right(r) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseNot() (recordPredicate, error) {
+	if p.keyword("not") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(r *EmailRecord) bool { return !inner(r) }, nil
+	}
+	t := p.next()
+	switch {
+	case t.kind == 'o' && t.text == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != 'o' || c.text != ")" {
+			return nil, whereError(c.pos, "닫는 괄호가 필요합니다")
+		}
+		return inner, nil
+	case t.kind == 'i':
+		return p.parseComparison(t)
+	case t.kind == 0:
+		return nil, whereError(t.pos, "식이 끝나지 않았습니다")
+	default:
+		return nil, whereError(t.pos, "필드 이름이 필요합니다: %s", t.text)
+	}
+}
+
+// whereOperators는 연산자 토큰입니다. contains와 matches는 이름 토큰으로 옵니다.
+var whereOperators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// parseComparison은 "필드 연산자 값" 또는 bool 필드 하나를 조건으로 컴파일합니다.
+func (p *whereParser) parseComparison(field whereToken) (recordPredicate, error) {
+	sf, ok := reflect.TypeOf(EmailRecord{}).FieldByName(field.text)
+	if !ok {
+		return nil, whereError(field.pos, "알 수 없는 필드 %q (사용 가능: %s)", field.text, strings.Join(recordFieldNames(), ", "))
+	}
+	index := sf.Index
+	get := func(r *EmailRecord) reflect.Value { return reflect.ValueOf(r).Elem().FieldByIndex(index) }
+
+	opTok := p.peek()
+	op := opTok.text
+	switch {
+	case opTok.kind == 'o' && whereOperators[op]:
+	case opTok.kind == 'i' && (strings.EqualFold(op, "contains") || strings.EqualFold(op, "matches")):
+		op = strings.ToLower(op)
+	default:
+		if sf.Type.Kind() != reflect.Bool {
+			return nil, whereError(opTok.pos, "%s 필드 뒤에 비교 연산자가 필요합니다", field.text)
+		}
+		return func(r *EmailRecord) bool { return get(r).Bool() }, nil
+	}
+	p.i++
+	val := p.next()
+
+	switch sf.Type.Kind() {
+	case reflect.String:
+		if val.kind != 's' {
+			return nil, whereError(val.pos, "%s 필드는 문자열(\"...\")과 비교해야 합니다", field.text)
+		}
+		switch op {
+		case "contains":
+			needle := strings.ToLower(val.value)
+			return func(r *EmailRecord) bool { return strings.Contains(strings.ToLower(get(r).String()), needle) }, nil
+		case "matches":
+			re, err := compileFilterRegex(val.value, false)
+			if err != nil {
+				return nil, whereError(val.pos, "잘못된 정규식 %s (%v)", val.text, err)
+			}
+			return func(r *EmailRecord) bool { return re.MatchString(get(r).String()) }, nil
+		}
+		s := val.value
+		return func(r *EmailRecord) bool { return compareOrdered(strings.Compare(get(r).String(), s), op) }, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.ToLower(val.text))
+		if val.kind != 'i' || err != nil || (op != "==" && op != "!=") {
+			return nil, whereError(val.pos, "%s 필드는 == 또는 !=로 true/false와 비교해야 합니다", field.text)
+		}
+		return func(r *EmailRecord) bool { return (get(r).Bool() == b) == (op == "==") }, nil
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		if op == "contains" || op == "matches" {
+			return nil, whereError(opTok.pos, "%s 필드는 숫자이므로 %s를 사용할 수 없습니다", field.text, op)
+		}
+		n, err := strconv.ParseFloat(val.text, 64)
+		if val.kind != 'n' || err != nil {
+			return nil, whereError(val.pos, "%s 필드는 숫자와 비교해야 합니다", field.text)
+		}
+		return func(r *EmailRecord) bool {
+			v := get(r)
+			var f float64
+			if v.Kind() == reflect.Float64 {
+				f = v.Float()
+			} else {
+				f = float64(v.Int())
+			}
+			switch {
+			case f < n:
+				return compareOrdered(-1, op)
+			case f > n:
+				return compareOrdered(1, op)
+			}
+			return compareOrdered(0, op)
+		}, nil
+	}
+	return nil, whereError(field.pos, "%s 필드는 비교할 수 없습니다", field.text)
+}
+
+// compareOrdered는 비교 결과(-1, 0, 1)가 연산자를 만족하는지 확인합니다.
+func compareOrdered(c int, op string) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// recordFieldNames는 -where에서 쓸 수 있는 필드 이름을 정렬하여 반환합니다.
+func recordFieldNames() []string {
+	t := reflect.TypeOf(EmailRecord{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, t.Field(i).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compileWhere는 -where 식을 컴파일합니다. 오류는 식에서의 위치를 함께 알려 줍니다.
+func compileWhere(expr string) (recordPredicate, error) {
+	tokens, err := tokenizeWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != 0 {
+		return nil, whereError(t.pos, "식이 끝나야 하는 곳에 %s가 있습니다 (and/or로 연결)", t.text)
+	}
+	return pred, nil
+}
+
+// whereFilters는 -where 식 필터를 구성합니다.
+func whereFilters(expr string) (recordFilters, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	pred, err := compileWhere(expr)
+	if err != nil {
+		return nil, fmt.Errorf("-where: %w", err)
+	}
+	return recordFilters{newRecordFilter("-where", pred)}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileWhereMatches(t *testing.T) {
+	rec := EmailRecord{
+		Subject:          "Invoice #42 청구서",
+		FromEmail:        "billing@gmail.com",
+		SentDate:         "2024-03-05 14:22:10",
+		AttachmentCount:  2,
+		MessageSize:      20480,
+		Score:            7,
+		LinkDensity:      0.75,
+		HasHTML:          true,
+		IsReply:          false,
+		URLDomains:       "example.com\nevil.example",
+		PrimaryFromEmail: "billing@gmail.com",
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// 문자열 비교 (사전 순이므로 날짜 비교 가능)
+		{`FromEmail == "billing@gmail.com"`, true},
+		{`FromEmail != "billing@gmail.com"`, false},
+		{`SentDate >= "2024-01-01"`, true},
+		{`SentDate < "2024-03-05"`, false},
+		{`SentDate <= "2024-03-05 14:22:10"`, true},
+		{`SentDate > "2024-03-05 14:22:10"`, false},
+		// contains와 matches는 대소문자 무시
+		{`Subject contains "INVOICE"`, true},
+		{`Subject contains "청구서"`, true},
+		{`Subject contains "receipt"`, false},
+		{`Subject matches "^invoice #\\d+"`, true},
+		{`URLDomains matches "(?m)^evil\\."`, true},
+		// 숫자 비교
+		{`AttachmentCount > 0`, true},
+		{`AttachmentCount >= 2`, true},
+		{`AttachmentCount < 2`, false},
+		{`MessageSize == 20480`, true},
+		{`Score >= -1`, true},
+		{`Score <= 6.5`, false},
+		{`LinkDensity > 0.5`, true},
+		{`LinkDensity == .75`, true},
+		// bool 필드
+		{`HasHTML`, true},
+		{`IsReply`, false},
+		{`not IsReply`, true},
+		{`HasHTML == true`, true},
+		{`IsReply != FALSE`, false},
+		// 우선순위: not > and > or
+		{`IsReply or HasHTML and Score > 5`, true},
+		{`IsReply and HasHTML or Score > 100`, false},
+		{`not IsReply and not HasHTML`, false},
+		{`not (IsReply or HasHTML)`, false},
+		{`(IsReply or HasHTML) and Score > 100`, false},
+		{`IsReply or (HasHTML and Score > 5)`, true},
+		{`not not HasHTML`, true},
+		// 키워드와 연산자 이름은 대소문자 무시
+		{`HasHTML AND Subject CONTAINS "invoice" Or IsReply`, true},
+		{`NOT IsReply`, true},
+	}
+	for _, tt := range tests {
+		pred, err := compileWhere(tt.expr)
+		if err != nil {
+			t.Errorf("compileWhere(%s) 오류: %v", tt.expr, err)
+			continue
+		}
+		if got := pred(&rec); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileWhereErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{``, "1번째 글자: 식이 끝나지 않았습니다"},
+		{`Subjct == "x"`, "1번째 글자: 알 수 없는 필드 \"Subjct\""},
+		{`HasHTML and Bogus`, "13번째 글자: 알 수 없는 필드 \"Bogus\""},
+		{`Subject`, "8번째 글자: Subject 필드 뒤에 비교 연산자가 필요합니다"},
+		{`Subject == 3`, "12번째 글자: Subject 필드는 문자열(\"...\")과 비교해야 합니다"},
+		{`Subject = "x"`, "9번째 글자: 알 수 없는 연산자 \"=\" (==, != 사용)"},
+		{`Subject ! "x"`, "9번째 글자: 알 수 없는 연산자 \"!\" (==, != 사용)"},
+		{`Subject == "x`, "12번째 글자: 닫는 따옴표가 없습니다"},
+		{`Subject == "\q"`, "12번째 글자: 잘못된 문자열 \"\\q\""},
+		{`Subject matches "a(("`, "17번째 글자: 잘못된 정규식 \"a((\" (1번째 글자: missing closing ): `a((`)"},
+		{`AttachmentCount > "2"`, "19번째 글자: AttachmentCount 필드는 숫자와 비교해야 합니다"},
+		{`AttachmentCount > 1.2.3`, "19번째 글자: AttachmentCount 필드는 숫자와 비교해야 합니다"},
+		{`AttachmentCount > 1.5e4`, "식이 끝나야 하는 곳에 e4가 있습니다"},
+		{`AttachmentCount contains "1"`, "17번째 글자: AttachmentCount 필드는 숫자이므로 contains를 사용할 수 없습니다"},
+		{`HasHTML < true`, "11번째 글자: HasHTML 필드는 == 또는 !=로 true/false와 비교해야 합니다"},
+		{`HasHTML == "true"`, "12번째 글자: HasHTML 필드는 == 또는 !=로 true/false와 비교해야 합니다"},
+		{`(HasHTML`, "9번째 글자: 닫는 괄호가 필요합니다"},
+		{`HasHTML)`, "8번째 글자: 식이 끝나야 하는 곳에 )가 있습니다"},
+		{`HasHTML IsReply`, "9번째 글자: 식이 끝나야 하는 곳에 IsReply가 있습니다"},
+		{`HasHTML and`, "12번째 글자: 식이 끝나지 않았습니다"},
+		{`== "x"`, "1번째 글자: 필드 이름이 필요합니다: =="},
+		{`not`, "4번째 글자: 식이 끝나지 않았습니다"},
+		// 위치는 바이트가 아니라 글자 단위
+		{`Subject == "청구서" and 제목`, "22번째 글자: 알 수 없는 필드 \"제목\""},
+		{`Subject == "x" # y`, "16번째 글자: 알 수 없는 문자 '#'"},
+		// ASCII가 아닌 숫자는 숫자로 보지 않고 한 글자 그대로 알림
+		{`Score > ٣`, "9번째 글자: 알 수 없는 문자 '٣'"},
+		{`Score > ３`, "9번째 글자: 알 수 없는 문자 '３'"},
+	}
+	for _, tt := range tests {
+		_, err := compileWhere(tt.expr)
+		if err == nil {
+			t.Errorf("compileWhere(%s) 오류 없음, want %q", tt.expr, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("compileWhere(%s) = %q, want %q", tt.expr, err.Error(), tt.want)
+		}
+	}
+}
+
+func TestWhereFilters(t *testing.T) {
+	fs, err := whereFilters("   ")
+	if err != nil || fs != nil {
+		t.Errorf("빈 식: %v, %v", fs, err)
+	}
+	if _, err := whereFilters(`Bogus`); err == nil || !strings.HasPrefix(err.Error(), "-where: 1번째 글자:") {
+		t.Errorf("오류에 -where 접두사가 없음: %v", err)
+	}
+	fs, err = whereFilters(`Score >= 5`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fs.match(&EmailRecord{Score: 5}) || fs.match(&EmailRecord{Score: 4}) {
+		t.Error("-where 필터 결과가 다름")
+	}
+}
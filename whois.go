@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// 기본 RDAP 조회 주소. rdap.org는 도메인의 TLD를 관리하는 RDAP 서버로 리디렉션합니다.
+const defaultRDAPURL = "https://rdap.org/domain/"
+
+// rdapTimeout은 RDAP 요청 하나에 허용하는 시간입니다 (리디렉션 포함).
+const rdapTimeout = 15 * time.Second
+
+// rdapEntry는 캐시 항목으로, done이 닫힌 뒤에만 created/ok를 읽을 수 있습니다.
+type rdapEntry struct {
+	done    chan struct{}
+	created time.Time
+	ok      bool
+}
+
+// rdapClient는 RDAP로 도메인 등록일을 조회합니다 (-whois).
+// 등록 도메인(eTLD+1)별로 결과를 캐시하고, 요청 사이에 interval 이상 간격을 두어 RDAP 서버의 요청 제한을 지킵니다.
+type rdapClient struct {
+	client   *http.Client
+	base     string
+	interval time.Duration
+
+	mu    sync.Mutex
+	next  time.Time
+	cache map[string]*rdapEntry
+}
+
+func newRDAPClient(base string, interval time.Duration) *rdapClient {
+	return &rdapClient{
+		client:   &http.Client{Timeout: rdapTimeout},
+		base:     base,
+		interval: interval,
+		cache:    make(map[string]*rdapEntry),
+	}
+}
+
+// wait는 다음 요청 시각까지 기다립니다. 여러 워커가 동시에 요청해도 interval 간격으로 하나씩 보냅니다.
+func (c *rdapClient) wait() {
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.next
+	if slot.Before(now) {
+		slot = now
+	}
+	c.next = slot.Add(c.interval)
+	c.mu.Unlock()
+	time.Sleep(time.Until(slot))
+}
+
+// created는 등록 도메인의 등록일을 반환합니다. 캐시에 있으면 조회하지 않습니다.
+func (c *rdapClient) created(domain string) (time.Time, bool) {
+	c.mu.Lock()
+	e, ok := c.cache[domain]
+	if !ok {
+		e = &rdapEntry{done: make(chan struct{})}
+		c.cache[domain] = e
+	}
+	c.mu.Unlock()
+	if ok {
+		<-e.done
+		return e.created, e.ok
+	}
+
+	c.wait()
+	created, err := c.query(domain)
+	if err != nil {
+		debugf("RDAP 조회 실패: %s (%v)", domain, err)
+	} else {
+		e.created, e.ok = created, true
+	}
+	close(e.done)
+	return e.created, e.ok
+}
+
+// rdapDomain은 RDAP 도메인 응답 중 등록일을 찾는 데 필요한 부분입니다 (RFC 9083 §4.5).
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+}
+
+// query는 RDAP 서버에 도메인을 조회하여 registration 이벤트의 날짜를 반환합니다.
+func (c *rdapClient) query(domain string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rdapTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+url.PathEscape(domain), nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	var d rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return time.Time{}, err
+	}
+	for _, ev := range d.Events {
+		if strings.EqualFold(ev.Action, "registration") {
+			return time.Parse(time.RFC3339, ev.Date)
+		}
+	}
+	return time.Time{}, fmt.Errorf("등록일(registration) 이벤트 없음")
+}
+
+// registeredDomain은 호스트의 등록 도메인(eTLD+1)을 반환합니다. 예: "login.example.co.kr" → "example.co.kr"
+func registeredDomain(host string) (string, bool) {
+	host, ok := resolvableDomain(host)
+	if !ok {
+		return "", false
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return "", false
+	}
+	return domain, true
+}
+
+// whoisRecordDomains는 URL 도메인의 등록일을 조회하여 WhoisDomains와 같은 줄 순서로 DomainAgeDays(알 수 없으면 빈 줄)를 기록하고,
+// 등록된 지 youngDays일 미만인 도메인을 YoungDomains에 기록합니다.
+func whoisRecordDomains(c *rdapClient, r *EmailRecord, youngDays int, now time.Time) {
+	var domains []string
+	for _, host := range strings.Split(r.URLDomains, "\n") {
+		if domain, ok := registeredDomain(host); ok {
+			domains = appendUnique(domains, domain)
+		}
+	}
+	ages := make([]string, len(domains))
+	var young []string
+	for i, domain := range domains {
+		created, ok := c.created(domain)
+		if !ok {
+			continue
+		}
+		days := int(now.Sub(created).Hours() / 24)
+		ages[i] = strconv.Itoa(days)
+		if days < youngDays {
+			young = append(young, domain)
+		}
+	}
+	r.WhoisDomains = strings.Join(domains, "\n")
+	r.DomainAgeDays = strings.Join(ages, "\n")
+	r.YoungDomains = strings.Join(young, "\n")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlRecordWriter는 레코드를 <emails> 아래 <email> 요소로 하나씩 출력합니다.
+// 각 필드는 같은 이름의 자식 요소가 되며, 여러 값을 갖는 필드(URLs 등)는
+// <URLs><url>…</url><url>…</url></URLs>처럼 반복 요소로 출력합니다.
+// XML 1.0에서 허용되지 않는 제어 문자는 encoding/xml이 U+FFFD로 바꿉니다.
+type xmlRecordWriter struct {
+	w       *bufio.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+func newXMLRecordWriter(w io.Writer) *xmlRecordWriter {
+	bw := bufio.NewWriter(w)
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	return &xmlRecordWriter{w: bw, enc: enc}
+}
+
+func (x *xmlRecordWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	if _, err := x.w.WriteString(xml.Header); err != nil {
+		return err
+	}
+	return x.enc.EncodeToken(emailsElement)
+}
+
+var emailsElement = xml.StartElement{Name: xml.Name{Local: "emails"}}
+
+func (x *xmlRecordWriter) WriteRecord(r EmailRecord) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	email := xml.StartElement{Name: xml.Name{Local: "email"}}
+	if err := x.enc.EncodeToken(email); err != nil {
+		return err
+	}
+	for _, f := range recordFields(r) {
+		value := fmt.Sprint(f.value)
+		if item, ok := multiValueFields[f.name]; ok {
+			if err := x.encodeList(f.name, item, splitMultiValue(value)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := x.enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: f.name}}); err != nil {
+			return err
+		}
+	}
+	if err := x.enc.EncodeToken(email.End()); err != nil {
+		return err
+	}
+	return x.enc.Flush()
+}
+
+func (x *xmlRecordWriter) encodeList(name, item string, values []string) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := x.enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, v := range values {
+		if err := x.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: item}}); err != nil {
+			return err
+		}
+	}
+	return x.enc.EncodeToken(start.End())
+}
+
+func (x *xmlRecordWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if err := x.enc.EncodeToken(emailsElement.End()); err != nil {
+		return err
+	}
+	if err := x.enc.Flush(); err != nil {
+		return err
+	}
+	if _, err := x.w.WriteString("\n"); err != nil {
+		return err
+	}
+	return x.w.Flush()
+}
+
+// splitMultiValue는 줄바꿈으로 연결된 값을 목록으로 나눕니다. 빈 값은 빈 목록입니다.
+// 이름/주소처럼 다른 필드와 줄 위치를 맞춘 값이 어긋나지 않도록 중간의 빈 줄도 빈 항목으로 남깁니다.
+func splitMultiValue(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	values := strings.Split(value, "\n")
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return values
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlRecordWriter는 레코드를 YAML 목록의 항목으로 하나씩 출력합니다.
+// 키는 JSON 출력과 같은 필드 이름을 쓰며, 여러 값을 갖는 필드(URLs 등)는 YAML 목록으로 출력합니다.
+type yamlRecordWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newYAMLRecordWriter(w io.Writer) *yamlRecordWriter {
+	return &yamlRecordWriter{w: bufio.NewWriter(w)}
+}
+
+func (y *yamlRecordWriter) WriteRecord(r EmailRecord) error {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range recordFields(r) {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: f.name}
+		var value *yaml.Node
+		// 여러 값 필드도 비어 있으면 다른 빈 문자열 필드처럼 빈 스칼라("")로 기록
+		values := splitMultiValue(fmt.Sprint(f.value))
+		if _, ok := multiValueFields[f.name]; ok && len(values) > 0 {
+			value = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, v := range values {
+				value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+			}
+		} else {
+			value = &yaml.Node{}
+			if err := value.Encode(f.value); err != nil {
+				return err
+			}
+		}
+		node.Content = append(node.Content, key, value)
+	}
+
+	// 항목 하나짜리 목록으로 인코딩하면 "- key: value" 형태가 되어 이어 붙여도 하나의 목록이 됨
+	b, err := yaml.Marshal([]*yaml.Node{node})
+	if err != nil {
+		return err
+	}
+	y.count++
+	_, err = y.w.Write(b)
+	return err
+}
+
+func (y *yamlRecordWriter) Close() error {
+	if y.count == 0 {
+		if _, err := y.w.WriteString("[]\n"); err != nil {
+			return err
+		}
+	}
+	return y.w.Flush()
+}